@@ -0,0 +1,134 @@
+package disposable
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// BatchOptions configures CheckBatch.
+type BatchOptions struct {
+	// Concurrency is the number of inputs checked at once. DNS lookups and
+	// SMTP probes spend most of their time waiting, so batches using them
+	// benefit from a much higher value. Default: runtime.GOMAXPROCS(0)
+	Concurrency int
+
+	// DNSCheck fills Result.DNS for every input, as WithDNSCheck does, using
+	// the resolver set with WithDNSCheck or net.DefaultResolver. Checkers
+	// created with WithDNSCheck always look up DNS. Default: false
+	DNSCheck bool
+}
+
+// CheckBatch checks many emails or domains the way CheckWithContext does,
+// spreading the work over opts.Concurrency goroutines, and returns one
+// result per input in the same order.
+//
+// Inputs that can't be checked, such as empty or malformed ones, get a zero
+// Result and are reported together in a *BatchError, so one bad row doesn't
+// stop an import. If the data has expired, CheckBatch checks nothing and
+// returns ErrDataExpired.
+//
+// If ctx is cancelled, CheckBatch stops starting new checks and returns
+// ctx.Err() with the results computed so far; inputs that weren't checked
+// get a zero Result.
+func (c *Checker) CheckBatch(ctx context.Context, emails []string, opts BatchOptions) ([]Result, error) {
+	if err := c.Healthy(); err != nil {
+		return nil, err
+	}
+
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(emails))
+
+	dnsCheck := opts.DNSCheck || c.config.DNSCheck
+	results := make([]Result, len(emails))
+	errs := make([]error, len(emails))
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if i >= len(emails) {
+					return
+				}
+				results[i], errs[i] = c.check(ctx, emails[i], dnsCheck)
+			}
+		})
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+
+	var batchErr BatchError
+	for i, err := range errs {
+		if err != nil {
+			batchErr.Errs = append(batchErr.Errs, InputError{Index: i, Input: emails[i], Err: err})
+		}
+	}
+	if len(batchErr.Errs) > 0 {
+		return results, &batchErr
+	}
+	return results, nil
+}
+
+// maxStreamLine is the longest line CheckStream accepts.
+const maxStreamLine = 1 << 20
+
+// CheckStream reads email addresses from r and calls fn with the result of
+// each, in input order, without reading all of r into memory. This suits
+// mailing list exports too large to pass to CheckBatch.
+//
+// The input holds one address per line or comma-separated fields, like a CSV
+// export. Fields are trimmed of spaces and double quotes, and only fields
+// containing "@" are checked, so headers and other columns such as names are
+// skipped. Fields that can't be checked, such as "user@", are skipped too.
+// Results are computed as by CheckWithContext.
+//
+// CheckStream returns ErrDataExpired if the data has expired, an error if r
+// can't be read or holds a line longer than 1 MiB, or ctx.Err() if ctx is
+// cancelled; fn has then been called for the inputs before the failure.
+func (c *Checker) CheckStream(ctx context.Context, r io.Reader, fn func(Result)) error {
+	if err := c.Healthy(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+
+	checked := 0
+	for scanner.Scan() {
+		for field := range strings.SplitSeq(scanner.Text(), ",") {
+			field = strings.Trim(field, " \t\r\"")
+			if !strings.Contains(field, "@") {
+				continue
+			}
+
+			if checked%batchCheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			checked++
+
+			result, err := c.CheckWithContext(ctx, field)
+			if errors.Is(err, ErrDataExpired) {
+				return err
+			}
+			if err == nil {
+				fn(result)
+			}
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,214 @@
+package disposable
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCheckerCheckBatch(t *testing.T) {
+	checker, err := New(WithCacheDir(newTestCacheDir(t)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	inputs := []string{"user@mailinator.com", "user@gmail.com", "", "sub.guerrillamail.com", "user@"}
+	results, err := checker.CheckBatch(context.Background(), inputs, BatchOptions{Concurrency: 2})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("CheckBatch() error = %v, want a BatchError", err)
+	}
+	if len(batchErr.Errs) != 2 || batchErr.Errs[0].Index != 2 || batchErr.Errs[1].Index != 4 {
+		t.Errorf("BatchError.Errs = %v, want inputs 2 and 4", batchErr.Errs)
+	}
+	if !errors.Is(err, ErrEmptyInput) || !errors.Is(err, ErrInvalidDomain) {
+		t.Errorf("CheckBatch() error = %v, want it to wrap ErrEmptyInput and ErrInvalidDomain", err)
+	}
+
+	if len(results) != len(inputs) {
+		t.Fatalf("CheckBatch() returned %d results, want %d", len(results), len(inputs))
+	}
+	tests := []struct {
+		disposable bool
+		domain     string
+	}{
+		{true, "mailinator.com"},
+		{false, "gmail.com"},
+		{false, ""},
+		{true, "sub.guerrillamail.com"},
+		{false, ""},
+	}
+	for i, tt := range tests {
+		if results[i].Disposable != tt.disposable || results[i].Domain != tt.domain {
+			t.Errorf("CheckBatch()[%d] = %+v, want disposable %v for domain %q", i, results[i], tt.disposable, tt.domain)
+		}
+	}
+}
+
+func TestCheckerCheckBatchLarge(t *testing.T) {
+	checker, err := New(WithCacheDir(newTestCacheDir(t)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	inputs := make([]string, 5000)
+	for i := range inputs {
+		if i%2 == 0 {
+			inputs[i] = fmt.Sprintf("user%d@mailinator.com", i)
+		} else {
+			inputs[i] = fmt.Sprintf("user%d@gmail.com", i)
+		}
+	}
+
+	for _, concurrency := range []int{0, 1, 16, 10000} {
+		results, err := checker.CheckBatch(context.Background(), inputs, BatchOptions{Concurrency: concurrency})
+		if err != nil {
+			t.Fatalf("CheckBatch(concurrency %d) error = %v", concurrency, err)
+		}
+		for i, r := range results {
+			if r.Disposable != (i%2 == 0) {
+				t.Fatalf("CheckBatch(concurrency %d)[%d].Disposable = %v, want %v", concurrency, i, r.Disposable, i%2 == 0)
+			}
+		}
+	}
+
+	if results, err := checker.CheckBatch(context.Background(), nil, BatchOptions{}); err != nil || len(results) != 0 {
+		t.Errorf("CheckBatch(nil) = (%v, %v), want no results and no error", results, err)
+	}
+}
+
+func TestCheckerCheckBatchCancel(t *testing.T) {
+	checker, err := New(WithCacheDir(newTestCacheDir(t)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	inputs := []string{"user@mailinator.com", "user@gmail.com"}
+	results, err := checker.CheckBatch(ctx, inputs, BatchOptions{})
+	if err != context.Canceled {
+		t.Fatalf("CheckBatch() error = %v, want %v", err, context.Canceled)
+	}
+	if len(results) != len(inputs) {
+		t.Fatalf("CheckBatch() returned %d results, want %d", len(results), len(inputs))
+	}
+	for i, r := range results {
+		if r != (Result{}) {
+			t.Errorf("CheckBatch()[%d] = %+v, want a zero Result for an unchecked input", i, r)
+		}
+	}
+}
+
+func TestCheckerCheckBatchDNS(t *testing.T) {
+	resolver := &fakeResolver{
+		mx: map[string][]*net.MX{"gmail.com": {{Host: "mx.gmail.com.", Pref: 10}}},
+	}
+	checker, err := New(WithCacheDir(newTestCacheDir(t)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+	checker.config.DNSResolver = resolver
+
+	inputs := []string{"user@gmail.com", "user@typo-domain.example"}
+
+	results, err := checker.CheckBatch(context.Background(), inputs, BatchOptions{})
+	if err != nil {
+		t.Fatalf("CheckBatch() error = %v", err)
+	}
+	if results[0].DNS != DNSUnknown {
+		t.Errorf("CheckBatch()[0].DNS = %v without DNSCheck, want %v", results[0].DNS, DNSUnknown)
+	}
+
+	results, err = checker.CheckBatch(context.Background(), inputs, BatchOptions{DNSCheck: true})
+	if err != nil {
+		t.Fatalf("CheckBatch() error = %v", err)
+	}
+	if results[0].DNS != DNSMX || results[1].DNS != DNSNotFound {
+		t.Errorf("CheckBatch() DNS = [%v %v], want [%v %v]", results[0].DNS, results[1].DNS, DNSMX, DNSNotFound)
+	}
+}
+
+func TestCheckerCheckStream(t *testing.T) {
+	checker, err := New(WithCacheDir(newTestCacheDir(t)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string // inputs of the results, in order
+	}{
+		{"lines", "user@mailinator.com\nuser@gmail.com\r\n\n  other@yopmail.com  \n", []string{"user@mailinator.com", "user@gmail.com", "other@yopmail.com"}},
+		{"csv", "name,email\nJane,jane@mailinator.com\n\"Doe, John\",\"john@gmail.com\"\n", []string{"jane@mailinator.com", "john@gmail.com"}},
+		{"several per line", "a@mailinator.com, b@gmail.com", []string{"a@mailinator.com", "b@gmail.com"}},
+		{"invalid skipped", "user@\n@\nuser@localhost\nok@gmail.com", []string{"ok@gmail.com"}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := checker.CheckStream(context.Background(), strings.NewReader(tt.input), func(r Result) {
+				got = append(got, r.Input)
+				if want := strings.HasSuffix(r.Input, "mailinator.com") || strings.HasSuffix(r.Input, "yopmail.com"); r.Disposable != want {
+					t.Errorf("Result(%q).Disposable = %v, want %v", r.Input, r.Disposable, want)
+				}
+			})
+			if err != nil {
+				t.Fatalf("CheckStream() error = %v", err)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("CheckStream() checked %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckerCheckStreamErrors(t *testing.T) {
+	checker, err := New(WithCacheDir(newTestCacheDir(t)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	// Lines longer than the limit fail the scan
+	long := strings.Repeat("a", maxStreamLine) + "@gmail.com\n"
+	err = checker.CheckStream(context.Background(), strings.NewReader(long), func(Result) {})
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("CheckStream() error = %v, want %v", err, bufio.ErrTooLong)
+	}
+
+	// Read errors are returned after the results read so far
+	readErr := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader("user@mailinator.com\n"), iotest.ErrReader(readErr))
+	calls := 0
+	err = checker.CheckStream(context.Background(), r, func(Result) { calls++ })
+	if !errors.Is(err, readErr) || calls != 1 {
+		t.Errorf("CheckStream() = %v after %d calls, want %v after 1", err, calls, readErr)
+	}
+
+	// Cancellation stops the stream
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	err = checker.CheckStream(ctx, strings.NewReader("user@mailinator.com\n"), func(Result) { calls++ })
+	if err != context.Canceled || calls != 0 {
+		t.Errorf("CheckStream() = %v after %d calls, want %v after 0", err, calls, context.Canceled)
+	}
+}
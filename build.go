@@ -0,0 +1,102 @@
+package disposable
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
+	"github.com/rezmoss/go-is-disposable-email/internal/wildcard"
+)
+
+// BuildDataFile builds a data.bin file from domain lists, for users who
+// curate their own lists. Domains are normalized, a trailing dot and a
+// leading "*." are removed, and duplicates are dropped. Wildcard patterns
+// such as "*.temp-mail.*" or "mail-temp-*.com" are stored as patterns and
+// match as described for WithCustomBlocklist. The result can be
+// loaded with Checker.LoadBytes, served from a URL set with WithDataURL, or
+// placed in the cache directory.
+//
+// The data is stamped as created at the time given by Config.TimeSource;
+// WithTimeSource is the only option with an effect here.
+//
+// It returns an error wrapping ErrInvalidDomain if any entry isn't a valid
+// domain or pattern, and an error if the blocklist has no domains, since such
+// data would be rejected when loaded.
+func BuildDataFile(blocklist, allowlist []string, opts ...Option) ([]byte, error) {
+	config := DefaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	block, blockPatterns, err := buildTrie(blocklist)
+	if err != nil {
+		return nil, fmt.Errorf("blocklist: %w", err)
+	}
+	if block.Size() == 0 {
+		return nil, errors.New("blocklist is empty")
+	}
+
+	allow, allowPatterns, err := buildTrie(allowlist)
+	if err != nil {
+		return nil, fmt.Errorf("allowlist: %w", err)
+	}
+
+	df := trie.NewDataFile(block, allow, config.TimeSource())
+	df.BlockPatterns = blockPatterns
+	df.AllowPatterns = allowPatterns
+	return trie.SerializeDataFile(df, trie.DefaultCompressionLevel)
+}
+
+// buildTrie normalizes and validates domains and inserts them into a new trie,
+// returning wildcard patterns separately. Blank entries are skipped.
+func buildTrie(domains []string) (*trie.Trie, []string, error) {
+	var (
+		entries  []string
+		patterns []string
+	)
+	for _, entry := range domains {
+		entry = strings.TrimSpace(entry)
+		if !wildcard.IsPattern(entry) {
+			entries = append(entries, strings.TrimPrefix(entry, "*."))
+			continue
+		}
+
+		p, err := wildcard.Compile(strings.ToLower(entry))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %q", ErrInvalidDomain, entry)
+		}
+		if !slices.Contains(patterns, p.String()) {
+			patterns = append(patterns, p.String())
+		}
+	}
+
+	valid, invalid := NormalizeDomains(entries)
+	if len(invalid) > 0 {
+		return nil, nil, fmt.Errorf("%w: %q", ErrInvalidDomain, invalid[0])
+	}
+
+	t := trie.New()
+	for _, domain := range valid {
+		if !t.Insert(domain) {
+			return nil, nil, fmt.Errorf("%w: %q", ErrInvalidDomain, domain)
+		}
+	}
+	return t, patterns, nil
+}
+
+// ConvertToFlat converts the contents of a data.bin file to the flat format
+// read by WithMmapData. Flat files are uncompressed and larger, but can be
+// queried without decoding them.
+func ConvertToFlat(fileData []byte) ([]byte, error) {
+	_, _, df, err := trie.Deserialize(fileData)
+	if err != nil {
+		return nil, &DeserializationError{Source: "bytes", Err: err}
+	}
+	flat, err := trie.MarshalFlat(df)
+	if err != nil {
+		return nil, &DeserializationError{Source: "bytes", Err: err}
+	}
+	return flat, nil
+}
@@ -0,0 +1,150 @@
+package disposable
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
+)
+
+func TestBuildDataFile(t *testing.T) {
+	data, err := BuildDataFile(
+		[]string{"Custom-Temp.com", "custom-temp.com", "*.wild.test", "trailing.test.", " ", "*.Rotating.*"},
+		[]string{"ok.custom-temp.com", "rotating.gov*"},
+	)
+	if err != nil {
+		t.Fatalf("BuildDataFile() error = %v", err)
+	}
+
+	// The bytes load like a downloaded data file
+	checker, err := New(WithCacheDir(newTestCacheDir(t)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	if err := checker.LoadBytes(data); err != nil {
+		t.Fatalf("LoadBytes() error = %v", err)
+	}
+
+	blocklist := checker.GetBlocklist()
+	sort.Strings(blocklist)
+	expected := []string{"custom-temp.com", "trailing.test", "wild.test"}
+	if !reflect.DeepEqual(blocklist, expected) {
+		t.Errorf("GetBlocklist() = %v, want %v", blocklist, expected)
+	}
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"user@custom-temp.com", true},
+		{"user@a.wild.test", true},
+		{"user@ok.custom-temp.com", false},
+		{"user@rotating.xyz", true},
+		{"user@rotating.gov.uk", false},
+		{"user@mailinator.com", false},
+	}
+	for _, tt := range tests {
+		if result := checker.IsDisposable(tt.input); result != tt.expected {
+			t.Errorf("IsDisposable(%q) = %v, want %v", tt.input, result, tt.expected)
+		}
+	}
+
+	// And as a cache file for a new checker
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), data, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	fromCache, err := New(WithCacheDir(dir), WithDataURL("http://127.0.0.1:0/unused"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer fromCache.Close()
+
+	if !fromCache.IsDisposable("custom-temp.com") {
+		t.Error("Expected custom-temp.com to be disposable")
+	}
+}
+
+func TestBuildDataFileTimeSource(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, err := BuildDataFile([]string{"custom-temp.com"}, nil,
+		WithTimeSource(func() time.Time { return created }))
+	if err != nil {
+		t.Fatalf("BuildDataFile() error = %v", err)
+	}
+
+	checker, err := New(WithCacheDir(newTestCacheDir(t)), WithNoCacheWrite())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	if err := checker.LoadBytes(data); err != nil {
+		t.Fatalf("LoadBytes() error = %v", err)
+	}
+	if got := checker.Stats().LastUpdated; !got.Equal(created) {
+		t.Errorf("LastUpdated = %v, want %v", got, created)
+	}
+}
+
+func TestBuildDataFileErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		blocklist []string
+		allowlist []string
+	}{
+		{"empty blocklist", nil, []string{"gmail.com"}},
+		{"blank blocklist", []string{"", "  "}, nil},
+		{"invalid blocklist entry", []string{"ok.com", "not a domain"}, nil},
+		{"invalid allowlist entry", []string{"ok.com"}, []string{"bad$.com"}},
+		{"empty label", []string{"ok.com", "double..dot.com"}, nil},
+		{"single label", []string{"localhost"}, nil},
+		{"patterns only", []string{"*.temp-mail.*"}, nil},
+		{"invalid pattern", []string{"ok.com", "*.*"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildDataFile(tt.blocklist, tt.allowlist); err == nil {
+				t.Errorf("BuildDataFile(%q, %q) error = nil, want error", tt.blocklist, tt.allowlist)
+			}
+		})
+	}
+
+	_, err := BuildDataFile([]string{"bad$.com"}, nil)
+	if !errors.Is(err, ErrInvalidDomain) {
+		t.Errorf("BuildDataFile() error = %v, want %v", err, ErrInvalidDomain)
+	}
+}
+
+func TestConvertToFlat(t *testing.T) {
+	data, err := BuildDataFile([]string{"custom-temp.com"}, []string{"ok.custom-temp.com"})
+	if err != nil {
+		t.Fatalf("BuildDataFile() error = %v", err)
+	}
+
+	flat, err := ConvertToFlat(data)
+	if err != nil {
+		t.Fatalf("ConvertToFlat() error = %v", err)
+	}
+	ff, err := trie.ParseFlat(flat)
+	if err != nil {
+		t.Fatalf("ParseFlat() error = %v", err)
+	}
+	blocklist, allowlist := ff.NewTries()
+	if !blocklist.Contains("custom-temp.com") || !allowlist.Contains("ok.custom-temp.com") {
+		t.Errorf("ConvertToFlat() lists = %v, %v", blocklist.GetAll(), allowlist.GetAll())
+	}
+
+	var deserErr *DeserializationError
+	if _, err := ConvertToFlat([]byte("not a data file")); !errors.As(err, &deserErr) {
+		t.Errorf("ConvertToFlat(invalid) error = %v, want DeserializationError", err)
+	}
+}
@@ -1,16 +1,29 @@
 package disposable
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rezmoss/go-is-disposable-email/data"
 	"github.com/rezmoss/go-is-disposable-email/internal/trie"
+	"github.com/rezmoss/go-is-disposable-email/internal/wildcard"
 )
 
 // Checker performs disposable email detection with custom configuration.
@@ -24,19 +37,112 @@ type Checker struct {
 	lastUpdated time.Time
 	version     string
 
+	// Number of sources listing each blocklist entry of the data, nil if
+	// the data file has no source counts
+	sourceCounts map[string]int
+
+	// Mail providers from the data, for Suggest; nil if it has none
+	providers []string
+
+	// Provider rules from the data by domain, for NormalizeEmail; empty if
+	// it has none
+	providerRules map[string]*ProviderRule
+
+	// Domains added at runtime via AddDomains/AddAllowlist that are not part
+	// of the loaded data. They are re-applied after every refresh.
+	runtimeBlocklist map[string]struct{}
+	runtimeAllowlist map[string]struct{}
+
+	// Domains from the configuration and the overrides file that are not
+	// part of the loaded data, rebuilt on every load
+	customBlocklist map[string]struct{}
+	customAllowlist map[string]struct{}
+
+	// Wildcard patterns such as "*.temp-mail.*" from the data and from the
+	// configuration and the overrides file
+	blockPatterns patternList
+	allowPatterns patternList
+
+	// Compiled patterns from WithBlockPatterns
+	blockRegexps []*regexp.Regexp
+
+	// Expiry times of runtime blocklist additions made with AddDomainsTTL,
+	// and the earliest of them in Unix nanoseconds (zero if none), so
+	// lookups can skip sweeping with a single atomic load
+	runtimeExpiry map[string]time.Time
+	nextExpiry    atomic.Int64
+
+	// Soft TTL state in Unix nanoseconds: when the data becomes stale, and
+	// when a lookup may next trigger a refresh of stale data
+	softDeadline atomic.Int64
+	softRetryAt  atomic.Int64
+	softRefresh  chan struct{}
+
+	// Canonicalized full addresses blocked or allowed regardless of domain
+	blockedEmails map[string]struct{}
+	allowedEmails map[string]struct{}
+
+	// Cached blocklist.MinimalSize() plus one, or zero when the blocklist
+	// changed since it was computed. Reset under c.mu whenever the blocklist
+	// is modified.
+	effectiveCount atomic.Int64
+
+	// TLD shards available and loaded, nil unless WithLazyTLDLoading is used
+	shards *shardState
+
+	// Encoded data file the current data was loaded from, for PersistCache.
+	// Nil when loaded from TLD shards.
+	rawData []byte
+
+	// Shared by all downloads so connections are reused
+	httpClient *http.Client
+
+	// Connects to mail servers for VerifyMailbox; replaced in tests
+	dialSMTP func(ctx context.Context, addr string) (net.Conn, error)
+
 	cancelFunc context.CancelFunc
 	wg         sync.WaitGroup
+
+	// Random numbers from Config.RandSource, which need not be safe for
+	// concurrent use
+	rngMu sync.Mutex
+	rng   *rand.Rand
+
+	refreshMu sync.Mutex
+	refresh   *refreshCall // in-flight refresh shared by concurrent callers
+}
+
+// refreshCall is a refresh in progress that concurrent callers wait on.
+type refreshCall struct {
+	done chan struct{}
+	err  error
 }
 
 // New creates a new Checker with the given options.
+//
+// Conflicting options, such as a hard TTL shorter than the soft TTL, make New
+// fail with an InitializationError listing every conflict.
+//
+// Each Checker has its own copy of the domain data, so domains added to it at
+// runtime never affect the package-level functions or other Checkers. Libraries
+// and tests that need custom domains should use their own Checker rather than
+// mutating the shared default one.
 func New(opts ...Option) (*Checker, error) {
 	config := DefaultConfig()
 	for _, opt := range opts {
 		opt(config)
 	}
+	if err := config.validate(); err != nil {
+		return nil, &InitializationError{Reason: "invalid configuration", Err: err}
+	}
+	blockRegexps, err := compileBlockPatterns(config.BlockPatterns)
+	if err != nil {
+		return nil, &InitializationError{Reason: "invalid configuration", Err: err}
+	}
 
 	// Set default cache directory if not specified
-	if config.CacheDir == "" {
+	defaultCacheDir := config.CacheDir == ""
+	if defaultCacheDir {
 		cacheDir, err := getDefaultCacheDir()
 		if err != nil {
 			return nil, &InitializationError{Reason: "failed to get cache directory", Err: err}
@@ -44,28 +150,87 @@ func New(opts ...Option) (*Checker, error) {
 		config.CacheDir = cacheDir
 	}
 
+	// Releases publish a digest next to the default data file; custom data
+	// URLs are only verified with WithChecksumURL
+	if config.ChecksumURL == "" && config.DataURL == data.DefaultDataURL {
+		config.ChecksumURL = data.DefaultDataURL + ChecksumSuffix
+	}
+	if config.DeltaURL == "" && config.DataURL == data.DefaultDataURL {
+		config.DeltaURL = data.DefaultDataURL + DeltaSuffix
+	}
+
+	// The embedded snapshot is used without touching the filesystem, unless
+	// an overrides file is configured explicitly
+	if config.Mode == ModeEmbedded {
+		config.NoCacheWrite = true
+		if config.EmbeddedData == nil {
+			config.EmbeddedData = data.Snapshot
+		}
+	} else if config.OverridesFile == "" {
+		config.OverridesFile = filepath.Join(config.CacheDir, DefaultOverridesFileName)
+	}
+
 	// Ensure cache directory exists
-	if err := os.MkdirAll(config.CacheDir, 0755); err != nil {
-		return nil, &CacheError{Path: config.CacheDir, Operation: "create", Err: err}
+	if !config.NoCacheWrite {
+		err := os.MkdirAll(config.CacheDir, 0755)
+		if err == nil && defaultCacheDir {
+			err = checkWritable(config.CacheDir)
+		}
+		if err != nil {
+			if !defaultCacheDir {
+				return nil, &CacheError{Path: config.CacheDir, Operation: "create", Err: err}
+			}
+
+			// Sandboxed environments may not allow writing anywhere, so
+			// keep the data in memory rather than failing
+			config.Logger.Printf("Warning: default cache directory %s is not writable, keeping data in memory only: %v", config.CacheDir, err)
+			config.NoCacheWrite = true
+		}
 	}
 
 	c := &Checker{
-		config:    config,
-		blocklist: trie.New(),
-		allowlist: trie.New(),
+		config:           config,
+		blocklist:        trie.New(),
+		allowlist:        trie.New(),
+		runtimeBlocklist: make(map[string]struct{}),
+		runtimeAllowlist: make(map[string]struct{}),
+		runtimeExpiry:    make(map[string]time.Time),
+		blockedEmails:    make(map[string]struct{}),
+		allowedEmails:    make(map[string]struct{}),
+		softRefresh:      make(chan struct{}, 1),
+		rng:              rand.New(config.RandSource),
+		httpClient:       &http.Client{Timeout: config.HTTPTimeout, Transport: config.HTTPTransport},
+		dialSMTP:         dialTCP,
+		blockRegexps:     blockRegexps,
 	}
 
 	// Initialize - download data if needed
-	if err := c.init(context.Background()); err != nil {
+	fromCache, err := c.init(context.Background())
+	if err != nil {
 		return nil, err
 	}
 
-	// Start auto-refresh if configured
-	if config.AutoRefresh {
+	refreshNow := config.ConcurrentInit && fromCache
+	if config.AutoRefresh || refreshNow || config.SoftTTL > 0 {
 		ctx, cancel := context.WithCancel(context.Background())
 		c.cancelFunc = cancel
-		c.wg.Add(1)
-		go c.autoRefreshWorker(ctx)
+
+		// Refresh cached data in the background if configured
+		if refreshNow {
+			c.wg.Add(1)
+			go c.backgroundRefresh(ctx)
+		}
+
+		// Start auto-refresh if configured
+		if config.AutoRefresh {
+			c.wg.Add(1)
+			go c.autoRefreshWorker(ctx)
+		}
+
+		if config.SoftTTL > 0 {
+			c.wg.Add(1)
+			go c.softRefreshWorker(ctx)
+		}
 	}
 
 	return c, nil
@@ -83,70 +248,287 @@ func getDefaultCacheDir() (string, error) {
 	return filepath.Join(os.TempDir(), "disposable-email"), nil
 }
 
+// checkWritable returns an error if files can't be created in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// now returns the current time from the configured time source.
+func (c *Checker) now() time.Time {
+	return c.config.TimeSource()
+}
+
 // getDataFilePath returns the path to the data.bin file.
 func (c *Checker) getDataFilePath() string {
-	return filepath.Join(c.config.CacheDir, data.DataFileName)
+	return filepath.Join(c.config.CacheDir, c.config.CacheFileName)
 }
 
 // init initializes the checker by loading data.
-func (c *Checker) init(ctx context.Context) error {
+// It reports whether the data came from the cache.
+//
+// In ModeEmbedded, only the embedded data is loaded. Otherwise, data from
+// Config.EmbeddedData replaces cached data that is older than it, and is used
+// when there is no cache and the download fails.
+func (c *Checker) init(ctx context.Context) (bool, error) {
+	if c.config.MmapData != "" {
+		if err := c.loadMmap(); err != nil {
+			return false, &InitializationError{Reason: "failed to load memory-mapped data", Err: err}
+		}
+		return false, nil
+	}
+	if c.config.Mode == ModeEmbedded {
+		if err := c.loadEmbedded(false); err != nil {
+			return false, &InitializationError{Reason: "failed to load embedded data", Err: err}
+		}
+		return false, nil
+	}
+
 	// Try to load from cache first
 	if err := c.loadFromCache(); err == nil {
 		c.config.Logger.Printf("Loaded data from cache: %s", c.getDataFilePath())
-		c.applyCustomDomains()
-		return nil
+		if c.config.EmbeddedData != nil {
+			if err := c.loadEmbedded(true); err != nil {
+				c.config.Logger.Printf("Warning: failed to load embedded data: %v", err)
+			}
+		}
+		return true, nil
 	}
 
 	// Download fresh data
 	c.config.Logger.Printf("Downloading data from %s...", c.config.DataURL)
 	if err := c.downloadAndLoad(ctx); err != nil {
-		return &InitializationError{Reason: "no cached data and download failed", Err: err}
+		if c.config.EmbeddedData == nil {
+			return false, &InitializationError{Reason: "no cached data and download failed", Err: err}
+		}
+		c.config.Logger.Printf("Download failed, using embedded data: %v", err)
+		if embErr := c.loadEmbedded(false); embErr != nil {
+			return false, &InitializationError{Reason: "no cached data, download and embedded data failed", Err: errors.Join(err, embErr)}
+		}
+		// Like cached data, embedded data may be stale
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// loadEmbedded loads Config.EmbeddedData. If onlyIfNewer is set, the current
+// data is kept unless the embedded data was created after it.
+func (c *Checker) loadEmbedded(onlyIfNewer bool) error {
+	blocklist, allowlist, dataFile, err := c.decodeData(c.config.EmbeddedData, "embedded")
+	if err != nil {
+		return err
+	}
+
+	if onlyIfNewer {
+		c.mu.RLock()
+		current := c.lastUpdated
+		c.mu.RUnlock()
+		if !dataFile.CreatedAt.After(current) {
+			return nil
+		}
+		c.config.Logger.Printf("Embedded data (%s) is newer than cache (%s)",
+			dataFile.CreatedAt.Format(time.RFC3339), current.Format(time.RFC3339))
+	}
+
+	return c.useDecoded(blocklist, allowlist, dataFile, c.config.EmbeddedData, "embedded")
+}
+
+// loadMmap maps Config.MmapData and swaps it in. The tries are overlays on
+// the mapped lists, so nothing is decoded and nothing is saved to the cache.
+func (c *Checker) loadMmap() error {
+	ff, err := trie.OpenFlat(c.config.MmapData)
+	if err != nil {
+		return &DeserializationError{Source: "mmap", Err: err}
+	}
+	blocklist, allowlist := ff.NewTries()
+	dataFile := ff.DataFile()
+
+	if blocklist.Size() == 0 {
+		return &DeserializationError{Source: "mmap", Err: errors.New("blocklist is empty")}
 	}
+	if c.config.StrictValidation {
+		if err := blocklist.Validate(); err != nil {
+			return &DeserializationError{Source: "mmap", Err: fmt.Errorf("blocklist: %w", err)}
+		}
+		if err := allowlist.Validate(); err != nil {
+			return &DeserializationError{Source: "mmap", Err: fmt.Errorf("allowlist: %w", err)}
+		}
+	}
+
+	c.setData(blocklist, allowlist, dataFile, nil, nil)
+
+	c.config.Logger.Printf("Mapped %d blocklist and %d allowlist domains from %s (version: %s)",
+		blocklist.Size(), allowlist.Size(), c.config.MmapData, dataFile.Version)
 
-	c.applyCustomDomains()
 	return nil
 }
 
-// applyCustomDomains adds custom blocklist/allowlist domains.
-func (c *Checker) applyCustomDomains() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// applyCustomDomains adds custom blocklist/allowlist domains, overrides and
+// runtime additions on top of freshly loaded tries.
+// The caller must hold c.mu.
+func (c *Checker) applyCustomDomains(blocklist, allowlist *trie.Trie, overrideBlock, overrideAllow []string) {
+	c.customBlocklist = make(map[string]struct{})
+	c.customAllowlist = make(map[string]struct{})
+	c.blockPatterns.custom = nil
+	c.allowPatterns.custom = nil
 
 	for _, domain := range c.config.CustomBlocklist {
-		c.blocklist.Insert(NormalizeDomain(domain))
+		insertCustom(blocklist, c.customBlocklist, &c.blockPatterns, NormalizeDomain(domain))
 	}
 	for _, domain := range c.config.CustomAllowlist {
-		c.allowlist.Insert(NormalizeDomain(domain))
+		insertCustom(allowlist, c.customAllowlist, &c.allowPatterns, NormalizeDomain(domain))
+	}
+
+	for _, domain := range overrideBlock {
+		insertCustom(blocklist, c.customBlocklist, &c.blockPatterns, domain)
+	}
+	for _, domain := range overrideAllow {
+		insertCustom(allowlist, c.customAllowlist, &c.allowPatterns, domain)
+	}
+
+	reapplyRuntime(blocklist, c.runtimeBlocklist)
+	reapplyRuntime(allowlist, c.runtimeAllowlist)
+}
+
+// insertCustom inserts domain into t, recording it in custom unless t
+// already contains it from the data. Wildcard patterns are added to patterns
+// instead.
+func insertCustom(t *trie.Trie, custom map[string]struct{}, patterns *patternList, domain string) {
+	if domain == "" {
+		return
+	}
+	if wildcard.IsPattern(domain) {
+		patterns.addCustom(domain)
+		return
+	}
+	if _, ok := custom[domain]; !ok && t.Contains(domain) {
+		return
+	}
+	t.Insert(domain)
+	custom[domain] = struct{}{}
+}
+
+// reapplyRuntime inserts runtime additions into t. Domains that t already
+// contains are no longer tracked, so clearing them later can't remove data
+// that came from the data file or the configuration.
+func reapplyRuntime(t *trie.Trie, runtime map[string]struct{}) {
+	for domain := range runtime {
+		if t.Contains(domain) {
+			delete(runtime, domain)
+			continue
+		}
+		t.Insert(domain)
+	}
+}
+
+// addRuntime inserts domains into t, tracking the ones that are new.
+func addRuntime(t *trie.Trie, runtime map[string]struct{}, domains []string) {
+	for _, domain := range domains {
+		domain = NormalizeDomain(domain)
+		if domain == "" || t.Contains(domain) {
+			continue
+		}
+		t.Insert(domain)
+		runtime[domain] = struct{}{}
 	}
 }
 
 // loadFromCache loads data from the cached data.bin file.
+// Cached data downloaded from a URL other than Config.DataURL is a miss.
 func (c *Checker) loadFromCache() error {
 	dataPath := c.getDataFilePath()
 
+	if err := c.checkCacheSource(); err != nil {
+		return &CacheError{Path: dataPath, Operation: "read", Err: err}
+	}
+
+	// Shards written by an earlier run avoid decoding the full data file
+	if c.lazyTLDLoading() {
+		if m, err := c.readShardManifest(); err == nil {
+			c.setShards(m, m.dataFile(), nil)
+			return nil
+		}
+	}
+
 	fileData, err := os.ReadFile(dataPath)
 	if err != nil {
 		return &CacheError{Path: dataPath, Operation: "read", Err: err}
 	}
+	if err := c.verifyCacheChecksum(fileData); err != nil {
+		return &CacheError{Path: dataPath, Operation: "verify", Err: err}
+	}
 
-	blocklist, allowlist, dataFile, err := trie.Deserialize(fileData)
+	blocklist, allowlist, dataFile, err := c.decodeData(fileData, "cache")
 	if err != nil {
-		return &DeserializationError{Source: "cache", Err: err}
+		return err
 	}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.setDecoded(blocklist, allowlist, dataFile, fileData)
+	return nil
+}
 
-	c.blocklist = blocklist
-	c.allowlist = allowlist
-	c.initialized = true
-	c.lastUpdated = dataFile.CreatedAt
-	c.version = dataFile.Version
+// writeCache saves fileData to the cache along with its source and digest.
+// Each file is replaced atomically, and the digest last: if writing stops
+// midway, the data left doesn't match the digest and the cache is rejected
+// on the next load instead of being used with the wrong contents.
+func (c *Checker) writeCache(fileData []byte, source string) error {
+	if err := writeFileAtomic(c.getDataFilePath(), fileData); err != nil {
+		return err
+	}
+	if err := c.writeCacheSource(source); err != nil {
+		return fmt.Errorf("recording source: %w", err)
+	}
+	if err := c.writeCacheChecksum(fileData); err != nil {
+		return fmt.Errorf("recording checksum: %w", err)
+	}
+	return nil
+}
+
+// cacheSourcePath returns the path of the file recording the URL the cached
+// data was downloaded from.
+func (c *Checker) cacheSourcePath() string {
+	return c.getDataFilePath() + ".source"
+}
+
+// writeCacheSource records where the data just saved to the cache came from.
+// Only downloads have a URL, which deltas applied to them share; for other
+// sources the record is removed, so the cache is accepted whatever URL is
+// configured.
+func (c *Checker) writeCacheSource(source string) error {
+	if source != "download" && source != "delta" {
+		err := os.Remove(c.cacheSourcePath())
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return writeFileAtomic(c.cacheSourcePath(), []byte(c.config.DataURL))
+}
 
+// checkCacheSource fails if the cached data was downloaded from a URL other
+// than Config.DataURL. Caches without a recorded URL are accepted.
+func (c *Checker) checkCacheSource() error {
+	recorded, err := os.ReadFile(c.cacheSourcePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if url := string(recorded); url != c.config.DataURL {
+		return fmt.Errorf("cached data was downloaded from %s, not %s", url, c.config.DataURL)
+	}
 	return nil
 }
 
 // downloadAndLoad downloads fresh data and loads it.
+// The current tries are only replaced once the new data has been fully
+// deserialized, so a failed download never clears existing data.
 func (c *Checker) downloadAndLoad(ctx context.Context) error {
 	// Download data
 	fileData, err := c.downloadData(ctx)
@@ -154,151 +536,1226 @@ func (c *Checker) downloadAndLoad(ctx context.Context) error {
 		return err
 	}
 
-	// Deserialize to validate
-	blocklist, allowlist, dataFile, err := trie.Deserialize(fileData)
+	return c.loadData(fileData, "download")
+}
+
+// LoadBytes loads the domain database from the contents of a data.bin file,
+// e.g. one fetched by a sidecar, without downloading anything. The data is
+// validated and saved to the cache before replacing the current data, and
+// custom domains are re-applied on top of it. On error the current data is kept.
+func (c *Checker) LoadBytes(data []byte) error {
+	return c.loadData(data, "bytes")
+}
+
+// loadData decodes fileData, saves it to the cache and swaps it in.
+func (c *Checker) loadData(fileData []byte, source string) error {
+	blocklist, allowlist, dataFile, err := c.decodeData(fileData, source)
 	if err != nil {
-		return &DeserializationError{Source: "download", Err: err}
+		return err
+	}
+
+	return c.useDecoded(blocklist, allowlist, dataFile, fileData, source)
+}
+
+// useDecoded validates decoded data, saves fileData to the cache and swaps
+// the data in.
+func (c *Checker) useDecoded(blocklist, allowlist *trie.Trie, dataFile *trie.DataFile, fileData []byte, source string) error {
+	if validate := c.config.DownloadValidator; validate != nil {
+		if err := validate(dataFile); err != nil {
+			return &DeserializationError{Source: source, Err: fmt.Errorf("rejected by validator: %w", err)}
+		}
 	}
 
 	// Save to cache
-	dataPath := c.getDataFilePath()
-	if err := os.WriteFile(dataPath, fileData, 0644); err != nil {
-		c.config.Logger.Printf("Warning: failed to save to cache: %v", err)
-		// Continue anyway - we have the data in memory
+	if !c.config.NoCacheWrite {
+		if err := c.writeCache(fileData, source); err != nil {
+			c.config.Logger.Printf("Warning: failed to save to cache: %v", err)
+			// Continue anyway - we have the data in memory
+		}
+	}
+
+	c.setDecoded(blocklist, allowlist, dataFile, fileData)
+
+	c.config.Logger.Printf("Loaded %d blocklist and %d allowlist domains (version: %s)",
+		len(dataFile.Blocklist), len(dataFile.Allowlist), dataFile.Version)
+
+	return nil
+}
+
+// decodeData deserializes and validates the contents of a data file. With
+// lazy TLD loading, the tries are nil unless they were built for strict
+// validation, since the lists are split into shards instead.
+func (c *Checker) decodeData(fileData []byte, source string) (*trie.Trie, *trie.Trie, *trie.DataFile, error) {
+	dataFile, err := trie.DecodeDataFile(fileData)
+	if err != nil {
+		return nil, nil, nil, &DeserializationError{Source: source, Err: err}
+	}
+
+	if len(dataFile.Blocklist) == 0 {
+		return nil, nil, nil, &DeserializationError{Source: source, Err: errors.New("blocklist is empty")}
+	}
+
+	if c.lazyTLDLoading() && !c.config.StrictValidation {
+		return nil, nil, dataFile, nil
+	}
+
+	blocklist, allowlist := trie.NewTries(dataFile)
+	if c.config.StrictValidation {
+		if err := blocklist.Validate(); err != nil {
+			return nil, nil, nil, &DeserializationError{Source: source, Err: fmt.Errorf("blocklist: %w", err)}
+		}
+		if err := allowlist.Validate(); err != nil {
+			return nil, nil, nil, &DeserializationError{Source: source, Err: fmt.Errorf("allowlist: %w", err)}
+		}
+	}
+
+	return blocklist, allowlist, dataFile, nil
+}
+
+// setData applies custom domains to freshly loaded tries and swaps them in.
+// shards is nil unless the tries are to be filled lazily from TLD shards.
+// raw is the encoded data file the tries were decoded from, if available.
+func (c *Checker) setData(blocklist, allowlist *trie.Trie, dataFile *trie.DataFile, shards *shardState, raw []byte) {
+	overrideBlock, overrideAllow := c.loadOverrides()
+
+	if c.config.AllowlistMode == AllowlistReplace {
+		allowlist = trie.New()
 	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.blockPatterns.data = compilePatterns(dataFile.BlockPatterns)
+	c.allowPatterns.data = nil
+	if c.config.AllowlistMode != AllowlistReplace {
+		c.allowPatterns.data = compilePatterns(dataFile.AllowPatterns)
+	}
+	c.applyCustomDomains(blocklist, allowlist, overrideBlock, overrideAllow)
+
 	c.blocklist = blocklist
 	c.allowlist = allowlist
+	c.shards = shards
+	c.rawData = raw
+	c.effectiveCount.Store(0)
 	c.initialized = true
 	c.lastUpdated = dataFile.CreatedAt
+	c.softDeadline.Store(dataFile.CreatedAt.Add(c.config.SoftTTL).UnixNano())
 	c.version = dataFile.Version
-
-	c.config.Logger.Printf("Loaded %d blocklist and %d allowlist domains (version: %s)",
-		blocklist.Size(), allowlist.Size(), dataFile.Version)
-
-	return nil
+	c.sourceCounts = dataFile.SourceCounts
+	c.providers = dataFile.Providers
+	c.providerRules = indexProviderRules(dataFile.ProviderRules)
 }
 
 // downloadData downloads fresh data from the configured URL.
 func (c *Checker) downloadData(ctx context.Context) ([]byte, error) {
-	client := &http.Client{
-		Timeout: c.config.HTTPTimeout,
+	fileData, err := c.download(ctx, c.config.DataURL)
+	if err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.DataURL, nil)
+	if err := c.verifyDownload(ctx, c.config.ChecksumURL, c.config.DataURL, fileData); err != nil {
+		return nil, err
+	}
+	return fileData, nil
+}
+
+// download fetches url, returning a DownloadError on failure.
+func (c *Checker) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, &DownloadError{URL: c.config.DataURL, Err: err}
+		return nil, &DownloadError{URL: url, Err: err}
 	}
 
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, &DownloadError{URL: c.config.DataURL, Err: err}
+		return nil, &DownloadError{URL: url, Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, &DownloadError{URL: c.config.DataURL, StatusCode: resp.StatusCode}
+		return nil, &DownloadError{URL: url, StatusCode: resp.StatusCode}
 	}
 
 	fileData, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, &DownloadError{URL: c.config.DataURL, Err: err}
+		return nil, &DownloadError{URL: url, Err: err}
 	}
-
 	return fileData, nil
 }
 
+// backgroundRefresh refreshes cached data once after initialization.
+func (c *Checker) backgroundRefresh(ctx context.Context) {
+	defer c.wg.Done()
+
+	if err := c.RefreshWithContext(ctx); err != nil {
+		c.config.Logger.Printf("Background refresh failed, keeping cached data: %v", err)
+	} else {
+		c.config.Logger.Printf("Background refresh completed successfully")
+	}
+}
+
 // autoRefreshWorker periodically refreshes the data.
 func (c *Checker) autoRefreshWorker(ctx context.Context) {
 	defer c.wg.Done()
 
-	ticker := time.NewTicker(c.config.RefreshInterval)
-	defer ticker.Stop()
+	timer := time.NewTimer(c.config.RefreshInterval + c.jitter())
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			if err := c.RefreshWithContext(ctx); err != nil {
 				c.config.Logger.Printf("Auto-refresh failed: %v", err)
 			} else {
 				c.config.Logger.Printf("Auto-refresh completed successfully")
 			}
+			timer.Reset(c.config.RefreshInterval + c.jitter())
 		}
 	}
 }
 
-// IsDisposable checks if an email address or domain is from a disposable email service.
-func (c *Checker) IsDisposable(emailOrDomain string) bool {
-	return c.IsDisposableWithContext(context.Background(), emailOrDomain)
+// jitter returns a random delay between zero and Config.RefreshJitter.
+func (c *Checker) jitter() time.Duration {
+	if c.config.RefreshJitter <= 0 {
+		return 0
+	}
+
+	c.rngMu.Lock()
+	defer c.rngMu.Unlock()
+	return time.Duration(c.rng.Int64N(int64(c.config.RefreshJitter) + 1))
 }
 
-// IsDisposableWithContext is like IsDisposable but accepts a context for cancellation.
-func (c *Checker) IsDisposableWithContext(ctx context.Context, emailOrDomain string) bool {
-	domain := ExtractDomain(emailOrDomain)
-	if domain == "" {
-		return false
-	}
+// softRefreshRetry is the minimum time between refreshes triggered by lookups
+// of stale data, so that a failing or still stale download isn't retried on
+// every lookup.
+const softRefreshRetry = time.Minute
 
-	domain = NormalizeDomain(domain)
+// softRefreshWorker refreshes the data whenever a lookup finds it older than
+// the soft TTL.
+func (c *Checker) softRefreshWorker(ctx context.Context) {
+	defer c.wg.Done()
 
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.softRefresh:
+			if err := c.RefreshWithContext(ctx); err != nil {
+				c.config.Logger.Printf("Refresh of stale data failed, serving it meanwhile: %v", err)
+			} else {
+				c.config.Logger.Printf("Refresh of stale data completed successfully")
+			}
+		}
+	}
+}
 
-	// Check allowlist first (takes precedence)
-	if c.allowlist.ContainsHierarchical(domain) {
-		return false
+// checkSoftTTL asks softRefreshWorker to refresh the data if it is older
+// than the soft TTL and no refresh was triggered within softRefreshRetry.
+func (c *Checker) checkSoftTTL() {
+	if c.config.SoftTTL <= 0 {
+		return
 	}
 
-	// Check blocklist with hierarchical matching
-	return c.blocklist.ContainsHierarchical(domain)
+	now := c.now().UnixNano()
+	retryAt := c.softRetryAt.Load()
+	if now < c.softDeadline.Load() || now < retryAt {
+		return
+	}
+	if c.softRetryAt.CompareAndSwap(retryAt, now+int64(softRefreshRetry)) {
+		select {
+		case c.softRefresh <- struct{}{}:
+		default:
+		}
+	}
 }
 
-// Refresh updates the domain database by downloading fresh data.
-func (c *Checker) Refresh() error {
-	return c.RefreshWithContext(context.Background())
-}
+// Healthy reports whether the data is fresh enough to rely on. It returns an
+// error wrapping ErrDataExpired if the data is older than the hard TTL set
+// with WithHardTTL, and nil otherwise.
+func (c *Checker) Healthy() error {
+	if c.config.HardTTL <= 0 {
+		return nil
+	}
 
-// RefreshWithContext is like Refresh but accepts a context for cancellation/timeout.
-func (c *Checker) RefreshWithContext(ctx context.Context) error {
-	if err := c.downloadAndLoad(ctx); err != nil {
-		return err // Already a typed error (DownloadError or DeserializationError)
+	c.mu.RLock()
+	lastUpdated := c.lastUpdated
+	c.mu.RUnlock()
+
+	if age := c.now().Sub(lastUpdated); age > c.config.HardTTL {
+		return fmt.Errorf("%w: data is %v old, hard TTL is %v", ErrDataExpired, age.Round(time.Second), c.config.HardTTL)
 	}
-	c.applyCustomDomains()
 	return nil
 }
 
-// AddDomains adds custom domains to the blocklist at runtime.
-func (c *Checker) AddDomains(domains ...string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// IsDisposable checks if an email address or domain is from a disposable email service.
+func (c *Checker) IsDisposable(emailOrDomain string) bool {
+	return c.IsDisposableWithContext(context.Background(), emailOrDomain)
+}
 
-	for _, domain := range domains {
-		c.blocklist.Insert(NormalizeDomain(domain))
+// batchCheckInterval is how many inputs IsDisposableBatch checks between
+// looking at its context.
+const batchCheckInterval = 1024
+
+// IsDisposableHost reports whether the host of a URL, such as a website field
+// on a signup form, is a disposable domain. The host is matched the same way
+// as IsDisposable matches domains. URLs without a scheme, like
+// "example.com/path", are accepted; ports, userinfo, paths and queries are
+// ignored. It returns false if the URL can't be parsed or has no host.
+func (c *Checker) IsDisposableHost(rawURL string) bool {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "//" + rawURL
 	}
-}
 
-// AddAllowlist adds domains to the allowlist at runtime.
-func (c *Checker) AddAllowlist(domains ...string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
 
-	for _, domain := range domains {
-		c.allowlist.Insert(NormalizeDomain(domain))
+	host := u.Hostname()
+	if host == "" {
+		return false
 	}
+	return c.IsDisposable(host)
 }
 
-// GetBlocklist returns a copy of all blocked domains.
-func (c *Checker) GetBlocklist() []string {
-	c.mu.RLock()
+// IsDisposableBatch checks many emails or domains, returning one result per
+// input in the same order.
+//
+// The context is checked periodically. If it is cancelled, IsDisposableBatch
+// stops and returns the results computed so far together with ctx.Err(): the
+// returned slice is shorter than inputs, and results[i] is valid for every
+// index it contains.
+func (c *Checker) IsDisposableBatch(ctx context.Context, inputs []string) ([]bool, error) {
+	results := make([]bool, 0, len(inputs))
+	for i, input := range inputs {
+		if i%batchCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+		}
+		results = append(results, c.IsDisposableWithContext(ctx, input))
+	}
+	return results, nil
+}
+
+// IsDisposableWith is like IsDisposable but also applies extraBlock and
+// extraAllow for this call only, without modifying the checker. This allows
+// per-tenant rules on a single shared checker.
+//
+// Extra entries match hierarchically like the loaded data, so "example.com"
+// also covers "mail.example.com". A match in extraAllow wins over extraBlock,
+// and both win over the checker's own data.
+func (c *Checker) IsDisposableWith(emailOrDomain string, extraBlock, extraAllow []string) bool {
+	domain := NormalizeDomain(ExtractDomain(emailOrDomain))
+	if domain == "" {
+		return false
+	}
+
+	if matchesAny(domain, extraAllow) {
+		return false
+	}
+	if matchesAny(domain, extraBlock) {
+		return true
+	}
+
+	return c.IsDisposable(emailOrDomain)
+}
+
+// matchesAny reports whether domain equals or is a subdomain of any entry.
+// Entries are normalized before comparison.
+func matchesAny(domain string, entries []string) bool {
+	for _, entry := range entries {
+		entry = NormalizeDomain(entry)
+		if entry == "" {
+			continue
+		}
+		if domain == entry || (strings.HasSuffix(domain, entry) && domain[len(domain)-len(entry)-1] == '.') {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDisposableWithContext is like IsDisposable but accepts a context for cancellation.
+func (c *Checker) IsDisposableWithContext(ctx context.Context, emailOrDomain string) bool {
+	domain := ExtractDomain(emailOrDomain)
+	if domain == "" {
+		return false
+	}
+
+	domain = NormalizeDomain(domain)
+	c.checkSoftTTL()
+	c.sweepExpired()
+	c.ensureShard(domain)
+
+	if onDisposable := c.config.OnDisposable; onDisposable != nil {
+		c.mu.RLock()
+		cl := c.classifyLocked(emailOrDomain, domain)
+		c.mu.RUnlock()
+
+		if !cl.Disposable() {
+			return false
+		}
+		onDisposable(domain, cl.Matched)
+		return true
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Per-address rules take precedence over the domain
+	if rule, _ := c.emailRule(emailOrDomain); rule != RuleNone {
+		return rule == RuleBlockedEmail
+	}
+
+	return c.isBlocked(domain)
+}
+
+// emailRule returns the per-address rule matching emailOrDomain, if any,
+// along with the canonical address. The caller must hold c.mu for reading.
+func (c *Checker) emailRule(emailOrDomain string) (Rule, string) {
+	if len(c.blockedEmails) == 0 && len(c.allowedEmails) == 0 {
+		return RuleNone, ""
+	}
+
+	email := canonicalEmail(emailOrDomain)
+	if email == "" {
+		return RuleNone, ""
+	}
+	if _, ok := c.allowedEmails[email]; ok {
+		return RuleAllowedEmail, email
+	}
+	if _, ok := c.blockedEmails[email]; ok {
+		return RuleBlockedEmail, email
+	}
+	return RuleNone, ""
+}
+
+// CanonicalEmail returns a normalized form of an email address for
+// deduplication: it is trimmed and lowercased, and any subaddress tag is
+// removed from the local part, so "User+News@Example.com" becomes
+// "user@example.com". The separators are configured with
+// WithSubaddressSeparators. It returns empty string if the input is not a
+// valid email address, including when its domain fails IsValidDomain, as
+// "a@b" and "x@." do.
+func (c *Checker) CanonicalEmail(email string) string {
+	return stripSubaddress(canonicalEmail(email), c.config.SubaddressSeparators)
+}
+
+// IsRoleAddress reports whether email's local part names a role rather than
+// a person, such as "admin@", "info@", "sales@" or "noreply@", which are
+// poor targets for transactional mail. Subaddress tags are ignored, so
+// "support+billing@example.com" is a role address. The roles are configured
+// with WithRoleAccounts. It returns false if the input is not a valid email
+// address; the domain is not checked against the data.
+func (c *Checker) IsRoleAddress(email string) bool {
+	return isRoleAddress(stripSubaddress(canonicalEmail(email), c.config.SubaddressSeparators), c.config.RoleAccounts)
+}
+
+// isRoleAddress reports whether the local part of a canonical email address
+// is one of roles.
+func isRoleAddress(email string, roles []string) bool {
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 {
+		return false
+	}
+	return slices.Contains(roles, email[:at])
+}
+
+// stripSubaddress removes the subaddress tag from the local part of a
+// canonical email address.
+func stripSubaddress(email string, seps []rune) string {
+	if email == "" {
+		return ""
+	}
+
+	at := strings.LastIndexByte(email, '@')
+	local, domain := email[:at], email[at+1:]
+
+	// A separator at the very start would leave an empty local part
+	if i := strings.IndexFunc(local, func(r rune) bool {
+		return slices.Contains(seps, r)
+	}); i > 0 {
+		local = local[:i]
+	}
+
+	return local + "@" + domain
+}
+
+// canonicalEmail lowercases and trims an email address. It returns empty
+// string if the input is not a well-formed address with a local part and a
+// domain accepted by IsValidDomain.
+func canonicalEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	local, domain, ok := splitAddress(email)
+	if !ok || local == "" || !IsValidDomain(domain) {
+		return ""
+	}
+	return local + "@" + domain
+}
+
+// isBlocked reports whether a normalized domain is disposable.
+// The caller must hold c.mu for reading.
+func (c *Checker) isBlocked(domain string) bool {
+	// Check allowlist first (takes precedence)
+	if listContains(c.allowlist, domain) {
+		return false
+	}
+	if _, _, ok := c.allowPatterns.match(domain); ok {
+		return false
+	}
+
+	if c.config.BlockIPLiterals && IsIPLiteral(domain) {
+		return true
+	}
+	if c.config.BlockReservedDomains && IsReservedDomain(domain) {
+		return true
+	}
+
+	// Check blocklist with hierarchical matching
+	if listContains(c.blocklist, domain) {
+		return true
+	}
+	if _, _, ok := c.blockPatterns.match(domain); ok {
+		return true
+	}
+	if _, ok := c.matchBlockPattern(domain); ok {
+		return true
+	}
+
+	_, ok := c.matchHeuristic(domain)
+	return ok
+}
+
+// matchHeuristic returns the first configured heuristic pattern contained in
+// domain.
+func (c *Checker) matchHeuristic(domain string) (string, bool) {
+	for _, pattern := range c.config.HeuristicPatterns {
+		if strings.Contains(domain, pattern) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// matchBlockPattern returns the first pattern set with WithBlockPatterns
+// that matches domain.
+func (c *Checker) matchBlockPattern(domain string) (string, bool) {
+	for _, re := range c.blockRegexps {
+		if re.MatchString(domain) {
+			return re.String(), true
+		}
+	}
+	return "", false
+}
+
+// Rule identifies which list decided the result of a lookup.
+type Rule int
+
+const (
+	// RuleNone means neither list matched; the domain is not disposable.
+	RuleNone Rule = iota
+	// RuleBlocklist means a blocklist entry matched; the domain is disposable.
+	RuleBlocklist
+	// RuleAllowlist means an allowlist entry matched; the domain is not disposable.
+	RuleAllowlist
+	// RuleBlockedEmail means the full address was blocked with AddBlockedEmail.
+	RuleBlockedEmail
+	// RuleAllowedEmail means the full address was allowed with AddAllowedEmail.
+	RuleAllowedEmail
+	// RuleIPLiteral means the domain is an IP address and WithBlockIPLiterals is set.
+	RuleIPLiteral
+	// RuleHeuristic means the domain contains a pattern set with WithHeuristicPatterns.
+	RuleHeuristic
+	// RuleReserved means the domain is reserved for testing and WithBlockReservedDomains is set.
+	RuleReserved
+	// RuleBlockPattern means the domain matches a regular expression set with WithBlockPatterns.
+	RuleBlockPattern
+)
+
+// String returns the string representation of the Rule.
+func (r Rule) String() string {
+	switch r {
+	case RuleNone:
+		return "none"
+	case RuleBlocklist:
+		return "blocklist"
+	case RuleAllowlist:
+		return "allowlist"
+	case RuleBlockedEmail:
+		return "blocked-email"
+	case RuleAllowedEmail:
+		return "allowed-email"
+	case RuleIPLiteral:
+		return "ip-literal"
+	case RuleHeuristic:
+		return "heuristic"
+	case RuleReserved:
+		return "reserved"
+	case RuleBlockPattern:
+		return "block-pattern"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON encodes the Rule as its string representation.
+func (r Rule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// Classification describes how a lookup was decided.
+type Classification struct {
+	Domain  string // Normalized domain that was checked
+	Rule    Rule   // List whose entry decided the result
+	Matched string // Entry that matched: the domain, one of its parents, the full address, or a wildcard, regular expression or heuristic pattern
+	Custom  bool   // Whether Matched came from custom domains, overrides or runtime additions rather than the data
+}
+
+// Disposable reports whether the classification marks the domain as disposable.
+func (cl Classification) Disposable() bool {
+	switch cl.Rule {
+	case RuleBlocklist, RuleBlockedEmail, RuleIPLiteral, RuleHeuristic, RuleReserved, RuleBlockPattern:
+		return true
+	default:
+		return false
+	}
+}
+
+// Classify reports which rule decides whether emailOrDomain is disposable,
+// and which stored entry matched.
+//
+// The semantics for overlapping entries are:
+//   - An entry matches a domain if it equals the domain or is one of its
+//     parents, at label boundaries ("example.com" matches "a.example.com"
+//     but not "myexample.com"). Matching stops at the registrable domain:
+//     an entry that is a public suffix, such as "co.uk", only matches
+//     itself, never "example.co.uk".
+//   - If any allowlist entry matches, the allowlist wins, regardless of
+//     whether a blocklist entry is more specific. With "example.com"
+//     blocked and "mail.example.com" allowed, "sub.mail.example.com" is
+//     allowed and "foo.example.com" is blocked; with "example.com" allowed
+//     and "mail.example.com" blocked, "mail.example.com" is allowed.
+//   - When several entries in the same list match, the one closest to the
+//     root is reported.
+//   - Full addresses added with AddBlockedEmail or AddAllowedEmail take
+//     precedence over all domain entries.
+func (c *Checker) Classify(emailOrDomain string) Classification {
+	domain := NormalizeDomain(ExtractDomain(emailOrDomain))
+	if domain == "" {
+		return Classification{}
+	}
+	c.checkSoftTTL()
+	c.sweepExpired()
+	c.ensureShard(domain)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.classifyLocked(emailOrDomain, domain)
+}
+
+// classifyLocked classifies the normalized domain of emailOrDomain. The
+// caller must hold c.mu for reading.
+func (c *Checker) classifyLocked(emailOrDomain, domain string) Classification {
+	if rule, email := c.emailRule(emailOrDomain); rule != RuleNone {
+		return Classification{Domain: domain, Rule: rule, Matched: email, Custom: true}
+	}
+
+	if matched, ok := listMatch(c.allowlist, domain); ok {
+		return Classification{Domain: domain, Rule: RuleAllowlist, Matched: matched,
+			Custom: isCustom(matched, c.customAllowlist, c.runtimeAllowlist)}
+	}
+	if pattern, custom, ok := c.allowPatterns.match(domain); ok {
+		return Classification{Domain: domain, Rule: RuleAllowlist, Matched: pattern, Custom: custom}
+	}
+	if c.config.BlockIPLiterals && IsIPLiteral(domain) {
+		return Classification{Domain: domain, Rule: RuleIPLiteral}
+	}
+	if c.config.BlockReservedDomains && IsReservedDomain(domain) {
+		return Classification{Domain: domain, Rule: RuleReserved}
+	}
+	if matched, ok := listMatch(c.blocklist, domain); ok {
+		return Classification{Domain: domain, Rule: RuleBlocklist, Matched: matched,
+			Custom: isCustom(matched, c.customBlocklist, c.runtimeBlocklist)}
+	}
+	if pattern, custom, ok := c.blockPatterns.match(domain); ok {
+		return Classification{Domain: domain, Rule: RuleBlocklist, Matched: pattern, Custom: custom}
+	}
+	if pattern, ok := c.matchBlockPattern(domain); ok {
+		return Classification{Domain: domain, Rule: RuleBlockPattern, Matched: pattern}
+	}
+	if pattern, ok := c.matchHeuristic(domain); ok {
+		return Classification{Domain: domain, Rule: RuleHeuristic, Matched: pattern}
+	}
+	return Classification{Domain: domain, Rule: RuleNone}
+}
+
+// Result is the detailed outcome of Check, suitable for audit logs.
+type Result struct {
+	Input      string `json:"input"`      // Email or domain as given
+	Disposable bool   `json:"disposable"` // Whether the email or domain is disposable
+	Domain     string `json:"domain"`     // Normalized domain that was checked
+	Rule       Rule   `json:"rule"`       // List whose entry decided the result; RuleNone if nothing matched
+	Matched    string `json:"matched"`    // Entry that decided the result, e.g. the parent of a subdomain
+	Custom     bool   `json:"custom"`     // Whether Matched came from custom domains, overrides or runtime additions
+	Version    string `json:"version"`    // Version of the data the lookup used
+
+	// Whether the domain resolves, with WithDNSCheck; DNSUnknown otherwise
+	DNS DNSStatus `json:"dns"`
+
+	// Whether the mailbox exists, with WithSMTPProbe and an email address
+	// as input; MailboxUnknown otherwise
+	Mailbox MailboxStatus `json:"mailbox"`
+}
+
+// Check reports whether emailOrDomain is disposable along with how that was
+// decided, as Classify does, and the version of the data used. Like
+// CheckDomain, it returns ErrEmptyInput for empty input, ErrInvalidDomain if
+// no valid domain can be extracted, and ErrDataExpired while the data is
+// expired under WithHardTTL. Email addresses that ValidateSyntax rejects,
+// such as "user name@example.com", return ErrInvalidSyntax. IP addresses
+// such as "user@[IPv6:2001:db8::1]" with WithBlockIPLiterals, and reserved
+// names such as "user@localhost" with WithBlockReservedDomains, are checked
+// even though IsValidDomain rejects them.
+func (c *Checker) Check(emailOrDomain string) (Result, error) {
+	return c.CheckWithContext(context.Background(), emailOrDomain)
+}
+
+// CheckWithContext is like Check but accepts a context, which limits the DNS
+// lookup made with WithDNSCheck and the probe made with WithSMTPProbe. Failed
+// lookups and probes don't fail the check; Result.DNS or Result.Mailbox is
+// unknown then, and LookupDNS or VerifyMailbox reports the error.
+func (c *Checker) CheckWithContext(ctx context.Context, emailOrDomain string) (Result, error) {
+	return c.check(ctx, emailOrDomain, c.config.DNSCheck)
+}
+
+// check implements CheckWithContext, looking up DNS if dnsCheck is set.
+func (c *Checker) check(ctx context.Context, emailOrDomain string, dnsCheck bool) (Result, error) {
+	if strings.TrimSpace(emailOrDomain) == "" {
+		return Result{}, ErrEmptyInput
+	}
+
+	domain := NormalizeDomain(ExtractDomain(emailOrDomain))
+	if !c.isCheckableDomain(domain) {
+		return Result{}, fmt.Errorf("%w: %q", ErrInvalidDomain, emailOrDomain)
+	}
+	if strings.Contains(emailOrDomain, "@") {
+		if err := ValidateSyntax(strings.TrimSpace(emailOrDomain)); err != nil {
+			return Result{}, fmt.Errorf("%w in %q", err, emailOrDomain)
+		}
+	}
+	if err := c.Healthy(); err != nil {
+		return Result{}, err
+	}
+	c.checkSoftTTL()
+	c.sweepExpired()
+	c.ensureShard(domain)
+
+	c.mu.RLock()
+	cl := c.classifyLocked(emailOrDomain, domain)
+	version := c.version
+	c.mu.RUnlock()
+
+	result := Result{
+		Input:      emailOrDomain,
+		Disposable: cl.Disposable(),
+		Domain:     cl.Domain,
+		Rule:       cl.Rule,
+		Matched:    cl.Matched,
+		Custom:     cl.Custom,
+		Version:    version,
+	}
+	if dnsCheck {
+		result.DNS, _ = c.LookupDNS(ctx, domain)
+	}
+	if c.config.SMTPProbe && strings.Contains(emailOrDomain, "@") {
+		result.Mailbox, _ = c.VerifyMailbox(ctx, emailOrDomain)
+	}
+	return result, nil
+}
+
+// isCheckableDomain reports whether Check classifies the normalized domain:
+// whether IsValidDomain accepts it, or it is an IP address with
+// WithBlockIPLiterals, or a reserved name without dots such as "localhost"
+// with WithBlockReservedDomains. IsValidDomain requires a dot, so without
+// this those options would never decide a Result.
+func (c *Checker) isCheckableDomain(domain string) bool {
+	switch {
+	case IsValidDomain(domain):
+		return true
+	case c.config.BlockIPLiterals && IsIPLiteral(domain):
+		return true
+	default:
+		return c.config.BlockReservedDomains && !strings.Contains(domain, ".") && IsReservedDomain(domain)
+	}
+}
+
+// Confidence returns the number of data sources that list the blocklist entry
+// matching domain, as recorded by the update tool with source counts enabled.
+// Callers can apply their own threshold, e.g. treating domains listed by a
+// single source as suspicious rather than disposable.
+//
+// The second result is false if no blocklist entry in the data matches or the
+// data has no source counts. Custom domains, runtime additions and the
+// allowlist are not considered; use IsDisposable for the decision itself.
+func (c *Checker) Confidence(domain string) (int, bool) {
+	domain = NormalizeDomain(ExtractDomain(domain))
+	if domain == "" {
+		return 0, false
+	}
+	c.ensureShard(domain)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Like Classify, report the matching entry closest to the root
+	count, found := 0, false
+	for d := domain; d != ""; {
+		if n, ok := c.sourceCounts[d]; ok {
+			count, found = n, true
+		}
+		_, d, _ = strings.Cut(d, ".")
+	}
+	return count, found
+}
+
+// MatchingEntries returns every blocklist and allowlist entry that matches
+// the domain of emailOrDomain, ordered from the root, e.g. both
+// "example.com" and "mail.example.com" for "a.mail.example.com". Classify
+// reports only the entry that decided the result; this shows all of them,
+// which helps explain lookups where the lists overlap.
+func (c *Checker) MatchingEntries(emailOrDomain string) (blocked, allowed []string) {
+	domain := NormalizeDomain(ExtractDomain(emailOrDomain))
+	if domain == "" {
+		return nil, nil
+	}
+	c.sweepExpired()
+	c.ensureShard(domain)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return listMatches(c.blocklist, domain), listMatches(c.allowlist, domain)
+}
+
+// listMatch returns the entry of t that matches domain hierarchically, as
+// trie.HierarchicalMatch does, except that entries which are public
+// suffixes, such as "co.uk", only match themselves. Otherwise one slipping
+// into a list would cover every domain registered under it.
+func listMatch(t *trie.Trie, domain string) (string, bool) {
+	// Looking up the suffix allocates, so it is only done for a match that
+	// may be one
+	match, ok := t.HierarchicalMatch(domain)
+	if !ok || match == domain {
+		return match, ok
+	}
+	if suffix := publicSuffix(domain); len(match) <= len(suffix) {
+		return t.HierarchicalMatchBelow(domain, suffix)
+	}
+	return match, true
+}
+
+// listContains reports whether listMatch finds an entry.
+func listContains(t *trie.Trie, domain string) bool {
+	_, ok := listMatch(t, domain)
+	return ok
+}
+
+// listMatches returns all entries of t that match domain, ordered from the
+// root, leaving out public suffixes other than domain itself.
+func listMatches(t *trie.Trie, domain string) []string {
+	suffix := publicSuffix(domain)
+	return slices.DeleteFunc(t.AllSuffixMatches(domain), func(entry string) bool {
+		return entry != domain && len(entry) <= len(suffix)
+	})
+}
+
+// isCustom reports whether domain is in any of the given sets.
+func isCustom(domain string, sets ...map[string]struct{}) bool {
+	for _, set := range sets {
+		if _, ok := set[domain]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Coverage partitions domains by whether the checker already reports them as
+// disposable, using the same hierarchical matching as IsDisposable. This is
+// useful for reconciling an external blocklist with the package's data:
+// covered entries are redundant, uncovered ones are unique to the caller.
+// Entries are returned as given, in input order.
+func (c *Checker) Coverage(domains []string) (covered, uncovered []string) {
+	c.sweepExpired()
+	for _, input := range domains {
+		if domain := NormalizeDomain(ExtractDomain(input)); domain != "" {
+			c.ensureShard(domain)
+		}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, input := range domains {
+		domain := NormalizeDomain(ExtractDomain(input))
+		if domain != "" && c.isBlocked(domain) {
+			covered = append(covered, input)
+		} else {
+			uncovered = append(uncovered, input)
+		}
+	}
+
+	return covered, uncovered
+}
+
+// MatchBreakdown counts how the blocklist matches a sample of email addresses
+// or domains: exact if the domain itself is listed, hierarchical if only one
+// of its parents is, and none otherwise. A small hierarchical count suggests
+// that parent matching rarely makes a difference for the sample.
+//
+// Domains covered by the allowlist count as none, like invalid inputs.
+// Heuristic patterns, IP literals and reserved domains are not considered.
+func (c *Checker) MatchBreakdown(domains []string) (exact, hierarchical, none int) {
+	c.sweepExpired()
+	for _, input := range domains {
+		if domain := NormalizeDomain(ExtractDomain(input)); domain != "" {
+			c.ensureShard(domain)
+		}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, input := range domains {
+		domain := NormalizeDomain(ExtractDomain(input))
+		switch {
+		case domain == "" || listContains(c.allowlist, domain):
+			none++
+		case c.blocklist.Contains(domain):
+			exact++
+		case listContains(c.blocklist, domain):
+			hierarchical++
+		default:
+			none++
+		}
+	}
+
+	return exact, hierarchical, none
+}
+
+// TLDStats counts the domains under tld, such as "zip" or ".zip", that the
+// data and custom lists know about: total is the number of distinct entries
+// in the blocklist and allowlist, and blocked the number of blocklist entries
+// that are not allowed. blocked == total means every known domain under the
+// TLD is blocked, which can help decide whether a TLD-wide policy is
+// warranted. Multi-label suffixes such as "co.uk" work too.
+func (c *Checker) TLDStats(tld string) (blocked int, total int) {
+	tld = NormalizeDomain(strings.TrimPrefix(strings.TrimSpace(tld), "."))
+	if tld == "" {
+		return 0, 0
+	}
+	c.ensureShard(tld)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, domain := range c.blocklist.GetUnder(tld) {
+		total++
+		if !listContains(c.allowlist, domain) {
+			blocked++
+		}
+	}
+	for _, domain := range c.allowlist.GetUnder(tld) {
+		if !c.blocklist.Contains(domain) {
+			total++
+		}
+	}
+	return blocked, total
+}
+
+// DecisionDiff returns the inputs on which a and b disagree about whether they
+// are disposable, in input order. Inputs may be email addresses or domains.
+// This is useful for checking which decisions a configuration change would
+// flip before rolling it out, e.g. by comparing the current Checker with one
+// built from the proposed options.
+func DecisionDiff(a, b *Checker, domains []string) []string {
+	var diff []string
+	for _, input := range domains {
+		if a.IsDisposable(input) != b.IsDisposable(input) {
+			diff = append(diff, input)
+		}
+	}
+	return diff
+}
+
+// Prime runs each domain through the lookup path once to warm any lookup
+// caches, avoiding cold-start latency for an application's most common
+// domains. The results are discarded. With WithLazyTLDLoading, this loads the
+// shards of the domains' TLDs. Prime is effectively a no-op when no lookup
+// caches are enabled.
+func (c *Checker) Prime(domains ...string) {
+	for _, domain := range domains {
+		c.IsDisposable(domain)
+	}
+}
+
+// CheckDomain is a strict variant of IsDisposable that validates its input.
+// It returns ErrEmptyInput for empty input and ErrInvalidDomain if no valid
+// domain can be extracted, instead of reporting such input as not disposable.
+// With WithHardTTL, it returns ErrDataExpired while the data is expired.
+func (c *Checker) CheckDomain(emailOrDomain string) (bool, error) {
+	if strings.TrimSpace(emailOrDomain) == "" {
+		return false, ErrEmptyInput
+	}
+
+	domain := NormalizeDomain(ExtractDomain(emailOrDomain))
+	if !IsValidDomain(domain) {
+		return false, fmt.Errorf("%w: %q", ErrInvalidDomain, emailOrDomain)
+	}
+	if err := c.Healthy(); err != nil {
+		return false, err
+	}
+
+	return c.IsDisposable(domain), nil
+}
+
+// Refresh updates the domain database by downloading fresh data. If a delta
+// for the loaded data is published at Config.DeltaURL, only the changes are
+// downloaded.
+//
+// If the download or deserialization fails, the previously loaded data is kept
+// and continues to be used for lookups.
+func (c *Checker) Refresh() error {
+	return c.RefreshWithContext(context.Background())
+}
+
+// RefreshWithContext is like Refresh but accepts a context for cancellation/timeout.
+//
+// Concurrent calls are coalesced: while a refresh is in flight, other callers
+// wait for it and receive its result instead of starting another download.
+// The in-flight download runs under the context of the caller that started it;
+// a waiting caller whose own context is done returns ctx.Err() early.
+func (c *Checker) RefreshWithContext(ctx context.Context) error {
+	c.refreshMu.Lock()
+	if call := c.refresh; call != nil {
+		c.refreshMu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	c.refresh = call
+	c.refreshMu.Unlock()
+
+	call.err = c.refreshData(ctx) // Already a typed error (DownloadError or DeserializationError)
+
+	c.refreshMu.Lock()
+	c.refresh = nil
+	c.refreshMu.Unlock()
+	close(call.done)
+
+	return call.err
+}
+
+// AddDomains adds custom domains to the blocklist at runtime.
+// Runtime additions survive refreshes and can be undone with ClearCustom.
+func (c *Checker) AddDomains(domains ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	addRuntime(c.blocklist, c.runtimeBlocklist, domains)
+	c.effectiveCount.Store(0)
+
+	// Domains previously added with a TTL become permanent
+	for _, domain := range domains {
+		delete(c.runtimeExpiry, NormalizeDomain(domain))
+	}
+}
+
+// AddDomainsTTL adds domains to the blocklist at runtime for the given
+// duration, e.g. to block domains temporarily during an abuse wave. Expired
+// domains stop matching and are dropped on the next lookup. Adding a domain
+// again extends its expiry, while AddDomains makes it permanent.
+//
+// Domains already in the data or added permanently are not affected, and a
+// non-positive ttl adds nothing. Expiry uses the time source set with
+// WithTimeSource.
+func (c *Checker) AddDomainsTTL(ttl time.Duration, domains ...string) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiry := c.now().Add(ttl)
+	for _, domain := range domains {
+		domain = NormalizeDomain(domain)
+		if domain == "" {
+			continue
+		}
+		if _, isRuntime := c.runtimeBlocklist[domain]; isRuntime {
+			if _, hasTTL := c.runtimeExpiry[domain]; !hasTTL {
+				continue // permanent addition
+			}
+		} else if c.blocklist.Contains(domain) {
+			continue
+		} else {
+			c.blocklist.Insert(domain)
+		}
+		c.runtimeBlocklist[domain] = struct{}{}
+		c.runtimeExpiry[domain] = expiry
+	}
+	c.effectiveCount.Store(0)
+	c.updateNextExpiry()
+}
+
+// sweepExpired removes runtime additions whose TTL has passed. It is cheap
+// when nothing has expired.
+func (c *Checker) sweepExpired() {
+	if next := c.nextExpiry.Load(); next == 0 || c.now().UnixNano() < next {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for domain, expiry := range c.runtimeExpiry {
+		if now.Before(expiry) {
+			continue
+		}
+		delete(c.runtimeExpiry, domain)
+
+		// A refresh may have made it part of the data
+		if _, ok := c.runtimeBlocklist[domain]; ok {
+			c.blocklist.Remove(domain)
+			delete(c.runtimeBlocklist, domain)
+		}
+	}
+	c.effectiveCount.Store(0)
+	c.updateNextExpiry()
+}
+
+// updateNextExpiry recomputes the earliest expiry of TTL additions.
+// The caller must hold c.mu.
+func (c *Checker) updateNextExpiry() {
+	var next int64
+	for _, expiry := range c.runtimeExpiry {
+		if n := expiry.UnixNano(); next == 0 || n < next {
+			next = n
+		}
+	}
+	c.nextExpiry.Store(next)
+}
+
+// AddAllowlist adds domains to the allowlist at runtime.
+// Runtime additions survive refreshes and can be undone with ClearCustom.
+func (c *Checker) AddAllowlist(domains ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	addRuntime(c.allowlist, c.runtimeAllowlist, domains)
+}
+
+// AddBlockedEmail blocks specific full addresses, even on domains that are
+// otherwise not disposable. Per-address rules are checked before the domain.
+func (c *Checker) AddBlockedEmail(emails ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, email := range emails {
+		if email = canonicalEmail(email); email != "" {
+			c.blockedEmails[email] = struct{}{}
+		}
+	}
+}
+
+// AddAllowedEmail allows specific full addresses, even on disposable domains.
+// Allowed addresses take precedence over blocked ones.
+func (c *Checker) AddAllowedEmail(emails ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, email := range emails {
+		if email = canonicalEmail(email); email != "" {
+			c.allowedEmails[email] = struct{}{}
+		}
+	}
+}
+
+// OverlaySize reports how many domains added at runtime via AddDomains and
+// AddAllowlist are held on top of the loaded data. Domains that the data
+// already contains are not counted. This can be used to alert on unbounded
+// growth of runtime additions; ClearCustom resets both counts to zero.
+func (c *Checker) OverlaySize() (block int, allow int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.runtimeBlocklist), len(c.runtimeAllowlist)
+}
+
+// ClearCustom removes all domains added at runtime via AddDomains and
+// AddAllowlist, and all addresses added via AddBlockedEmail and
+// AddAllowedEmail. Domains from the data file and from WithCustomBlocklist or
+// WithCustomAllowlist are kept.
+func (c *Checker) ClearCustom() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clearRuntime()
+}
+
+// clearRuntime removes all runtime additions. The caller must hold c.mu.
+func (c *Checker) clearRuntime() {
+	clear(c.blockedEmails)
+	clear(c.allowedEmails)
+
+	for domain := range c.runtimeBlocklist {
+		c.blocklist.Remove(domain)
+	}
+	c.effectiveCount.Store(0)
+	for domain := range c.runtimeAllowlist {
+		c.allowlist.Remove(domain)
+	}
+	clear(c.runtimeBlocklist)
+	clear(c.runtimeAllowlist)
+	clear(c.runtimeExpiry)
+	c.nextExpiry.Store(0)
+}
+
+// GetBlocklist returns a copy of all blocked domains.
+func (c *Checker) GetBlocklist() []string {
+	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.blocklist.GetAll()
 }
 
+// FindBlocked returns the blocked domains matching pattern, sorted. It walks
+// the whole blocklist, so it is meant for occasional administrative use such
+// as curation, not for request paths.
+func (c *Checker) FindBlocked(pattern *regexp.Regexp) []string {
+	c.mu.RLock()
+	domains := c.blocklist.GetAll()
+	c.mu.RUnlock()
+
+	var matches []string
+	for _, domain := range domains {
+		if pattern.MatchString(domain) {
+			matches = append(matches, domain)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
 // GetAllowlist returns a copy of all allowlisted domains.
 func (c *Checker) GetAllowlist() []string {
 	c.mu.RLock()
@@ -306,32 +1763,197 @@ func (c *Checker) GetAllowlist() []string {
 	return c.allowlist.GetAll()
 }
 
+// ExportMinimal writes the smallest equivalent blocklist to w, one domain per
+// line. Subdomains of other blocked domains are omitted since hierarchical
+// matching already covers them, which makes the output suitable for systems
+// that support wildcard or suffix rules.
+func (c *Checker) ExportMinimal(w io.Writer) error {
+	c.mu.RLock()
+	domains := c.blocklist.Minimal()
+	c.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, domain := range domains {
+		if _, err := fmt.Fprintln(bw, domain); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ExportFormat selects the line format written by ExportBlocklist.
+type ExportFormat int
+
+const (
+	// ExportPlain writes one domain per line.
+	ExportPlain ExportFormat = iota
+
+	// ExportHosts writes hosts file lines such as "0.0.0.0 tempmail.com".
+	ExportHosts
+
+	// ExportDnsmasq writes dnsmasq rules such as
+	// "address=/tempmail.com/0.0.0.0". Since these also match subdomains,
+	// only the minimal blocklist is written, as with ExportMinimal.
+	ExportDnsmasq
+)
+
+// String returns the string representation of the ExportFormat.
+func (f ExportFormat) String() string {
+	switch f {
+	case ExportPlain:
+		return "plain"
+	case ExportHosts:
+		return "hosts"
+	case ExportDnsmasq:
+		return "dnsmasq"
+	default:
+		return "unknown"
+	}
+}
+
+// ExportBlocklist writes the blocklist to w in the given format, one sorted
+// entry per line. The hosts and dnsmasq formats null-route the domains to
+// 0.0.0.0, so the same list can drive blocking at the DNS layer. Hosts files
+// don't match subdomains, so only the listed domains themselves are blocked
+// there.
+func (c *Checker) ExportBlocklist(w io.Writer, format ExportFormat) error {
+	var line string
+	switch format {
+	case ExportPlain:
+		line = "%s\n"
+	case ExportHosts:
+		line = "0.0.0.0 %s\n"
+	case ExportDnsmasq:
+		line = "address=/%s/0.0.0.0\n"
+	default:
+		return fmt.Errorf("unknown export format %d", format)
+	}
+
+	c.mu.RLock()
+	var domains []string
+	if format == ExportDnsmasq {
+		domains = c.blocklist.Minimal()
+	} else {
+		domains = c.blocklist.GetAll()
+	}
+	c.mu.RUnlock()
+	sort.Strings(domains)
+
+	bw := bufio.NewWriter(w)
+	for _, domain := range domains {
+		if _, err := fmt.Fprintf(bw, line, domain); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// PersistCache writes the currently loaded data file to path, replacing any
+// existing file atomically, and its SHA-256 digest to path plus ".sha256".
+// The file has the data.bin format and can be loaded with LoadBytes or used
+// as a cache file. Custom domains and runtime additions are not included.
+//
+// Combined with WithNoCacheWrite, this lets applications decide when data is
+// written to disk.
+func (c *Checker) PersistCache(path string) error {
+	c.mu.RLock()
+	raw := c.rawData
+	c.mu.RUnlock()
+
+	// Data loaded from TLD shards is still in the cache file
+	if raw == nil {
+		var err error
+		dataPath := c.getDataFilePath()
+		if raw, err = os.ReadFile(dataPath); err != nil {
+			return &CacheError{Path: dataPath, Operation: "read", Err: err}
+		}
+	}
+
+	if err := writeFileAtomic(path, raw); err != nil {
+		return &CacheError{Path: path, Operation: "write", Err: err}
+	}
+	line := checksum(raw) + "  " + filepath.Base(path) + "\n"
+	if err := writeFileAtomic(path+ChecksumSuffix, []byte(line)); err != nil {
+		return &CacheError{Path: path + ChecksumSuffix, Operation: "write", Err: err}
+	}
+	return nil
+}
+
 // Stats returns statistics about the current database.
 func (c *Checker) Stats() Statistics {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return Statistics{
-		BlocklistCount: c.blocklist.Size(),
-		AllowlistCount: c.allowlist.Size(),
-		LastUpdated:    c.lastUpdated,
-		Mode:           c.config.Mode,
-		Version:        c.version,
+	stats := Statistics{
+		BlocklistCount:          c.blocklist.Size(),
+		EffectiveBlocklistCount: c.effectiveBlocklistCount(),
+		AllowlistCount:          c.allowlist.Size(),
+		LastUpdated:             c.lastUpdated,
+		Mode:                    c.config.Mode,
+		Version:                 c.version,
+	}
+
+	// TLDs not loaded yet are counted from the shard manifest
+	if c.shards != nil {
+		unloaded := c.shards.unloaded()
+		stats.BlocklistCount += unloaded.Blocklist
+		stats.EffectiveBlocklistCount += unloaded.EffectiveBlocklist
+		if c.config.AllowlistMode != AllowlistReplace {
+			stats.AllowlistCount += unloaded.Allowlist
+		}
+	}
+	return stats
+}
+
+// effectiveBlocklistCount returns the size of the minimal covering set of the
+// blocklist, walking the blocklist only when it changed since the last call.
+// The caller must hold c.mu.
+func (c *Checker) effectiveBlocklistCount() int {
+	if n := c.effectiveCount.Load(); n > 0 {
+		return int(n - 1)
 	}
+
+	n := c.blocklist.MinimalSize()
+	c.effectiveCount.Store(int64(n) + 1)
+	return n
 }
 
 // Close releases resources held by the Checker and stops the auto-refresh goroutine.
+// It also cancels a background refresh started by WithConcurrentInit.
 //
 // Close MUST be called when you are done using a Checker that was created with
-// WithAutoRefresh. Failing to call Close will result in a goroutine leak.
+// WithAutoRefresh or WithConcurrentInit. Failing to call Close will result in a goroutine leak.
 // It is safe to call Close multiple times; subsequent calls are no-ops.
 //
+// Close waits for background work to stop. With WithShutdownTimeout, it waits
+// at most that long and returns ErrShutdownTimeout if the work is still running.
+//
 // For Checkers without auto-refresh, calling Close is optional but recommended
 // for consistency.
 func (c *Checker) Close() error {
 	if c.cancelFunc != nil {
 		c.cancelFunc()
 	}
-	c.wg.Wait()
-	return nil
+
+	timeout := c.config.ShutdownTimeout
+	if timeout <= 0 {
+		c.wg.Wait()
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		return ErrShutdownTimeout
+	}
 }
@@ -0,0 +1,120 @@
+package disposable
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// ChecksumSuffix is appended to the name or URL of a data file to get that
+// of its SHA-256 digest, e.g. "data.v2.bin.sha256".
+const ChecksumSuffix = ".sha256"
+
+// maxChecksumSize bounds the checksum file read from a URL. A digest line in
+// sha256sum format is well below it.
+const maxChecksumSize = 4096
+
+// checksum returns the hex-encoded SHA-256 digest of data.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// parseChecksum returns the digest in the contents of a checksum file, either
+// a bare hex digest or a line in sha256sum format like "<digest>  data.bin".
+func parseChecksum(content []byte) (string, error) {
+	fields := bytes.Fields(content)
+	if len(fields) == 0 {
+		return "", errors.New("empty checksum file")
+	}
+
+	digest := string(bytes.ToLower(fields[0]))
+	if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("malformed SHA-256 digest %q", fields[0])
+	}
+	return digest, nil
+}
+
+// verifyChecksum returns a ChecksumError if data doesn't have the digest in
+// the checksum file contents.
+func verifyChecksum(data, content []byte, source string) error {
+	expected, err := parseChecksum(content)
+	if err != nil {
+		return err
+	}
+	if actual := checksum(data); actual != expected {
+		return &ChecksumError{Source: source, Expected: expected, Actual: actual, Size: len(data)}
+	}
+	return nil
+}
+
+// verifyDownload checks data downloaded from dataURL against the digest at
+// url. A missing checksum file fails like any other download error, unless
+// Config.AllowMissingChecksum is set; then it is logged and the data
+// accepted.
+func (c *Checker) verifyDownload(ctx context.Context, url, dataURL string, fileData []byte) error {
+	if url == "" {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return &DownloadError{URL: url, Err: err}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &DownloadError{URL: url, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && c.config.AllowMissingChecksum {
+		c.config.Logger.Printf("Warning: no checksum at %s, data not verified", url)
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &DownloadError{URL: url, StatusCode: resp.StatusCode}
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxChecksumSize))
+	if err != nil {
+		return &DownloadError{URL: url, Err: err}
+	}
+
+	if err := verifyChecksum(fileData, content, "download"); err != nil {
+		return &DownloadError{URL: dataURL, Err: err}
+	}
+	return nil
+}
+
+// cacheChecksumPath returns the path of the digest of the cached data file.
+func (c *Checker) cacheChecksumPath() string {
+	return c.getDataFilePath() + ChecksumSuffix
+}
+
+// writeCacheChecksum records the digest of the data just saved to the cache.
+func (c *Checker) writeCacheChecksum(fileData []byte) error {
+	line := checksum(fileData) + "  " + c.config.CacheFileName + "\n"
+	return writeFileAtomic(c.cacheChecksumPath(), []byte(line))
+}
+
+// verifyCacheChecksum checks cached data against its recorded digest, which
+// catches files truncated or corrupted on disk, and data files replaced
+// without their digest. Caches written before digests were recorded have
+// none and are accepted.
+func (c *Checker) verifyCacheChecksum(fileData []byte) error {
+	content, err := os.ReadFile(c.cacheChecksumPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return verifyChecksum(fileData, content, "cache")
+}
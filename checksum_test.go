@@ -0,0 +1,217 @@
+package disposable
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezmoss/go-is-disposable-email/data"
+)
+
+func TestParseChecksum(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{digest, digest, false},
+		{digest + "  data.bin\n", digest, false},
+		{strings.ToUpper(digest) + " *data.bin", digest, false},
+		{"", "", true},
+		{"not-hex  data.bin", "", true},
+		{digest[:62] + "  data.bin", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseChecksum([]byte(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseChecksum(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("parseChecksum(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCheckerChecksumURL(t *testing.T) {
+	good := buildTestDataFile(t, time.Now(), "checksummed.test")
+	other := buildTestDataFile(t, time.Now(), "other.test")
+
+	tests := []struct {
+		name         string
+		checksum     string // served checksum file, "" for 404
+		allowMissing bool
+		wantErr      bool
+	}{
+		{"matching", checksum(good) + "  data.bin\n", false, false},
+		{"mismatch", checksum(other) + "  data.bin\n", false, true},
+		{"truncated download", checksum(append(good, 0)), false, true},
+		{"missing", "", false, true},
+		{"missing allowed", "", true, false},
+		{"mismatch with missing allowed", checksum(other), true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/data.bin":
+					w.Write(good)
+				case "/data.bin.sha256":
+					if tt.checksum == "" {
+						http.NotFound(w, r)
+						return
+					}
+					w.Write([]byte(tt.checksum))
+				}
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			opts := []Option{WithCacheDir(dir), WithDataURL(server.URL + "/data.bin"),
+				WithChecksumURL(server.URL + "/data.bin.sha256")}
+			if tt.allowMissing {
+				opts = append(opts, WithAllowMissingChecksum())
+			}
+			checker, err := New(opts...)
+			if tt.wantErr {
+				if !IsDownloadError(err) {
+					t.Fatalf("New() error = %v, want a download error", err)
+				}
+				if tt.checksum != "" && !IsChecksumError(err) {
+					t.Fatalf("New() error = %v, want it caused by a checksum mismatch", err)
+				}
+				if _, statErr := os.Stat(filepath.Join(dir, "data.bin")); statErr == nil {
+					t.Error("Expected data failing verification not to be cached")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer checker.Close()
+
+			if !checker.IsDisposable("checksummed.test") {
+				t.Error("Expected downloaded data to be loaded")
+			}
+		})
+	}
+}
+
+func TestCheckerDefaultChecksumURL(t *testing.T) {
+	dir := newTestCacheDir(t)
+
+	checker, err := New(WithCacheDir(dir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	if want := data.DefaultDataURL + ChecksumSuffix; checker.config.ChecksumURL != want {
+		t.Errorf("ChecksumURL = %q, want %q", checker.config.ChecksumURL, want)
+	}
+
+	// Custom data URLs are not verified unless asked to
+	custom, err := New(WithCacheDir(dir), WithDataURL("http://127.0.0.1:0/data.bin"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer custom.Close()
+
+	if custom.config.ChecksumURL != "" {
+		t.Errorf("ChecksumURL = %q for a custom data URL, want none", custom.config.ChecksumURL)
+	}
+}
+
+func TestCheckerCacheChecksum(t *testing.T) {
+	good := buildTestDataFile(t, time.Now(), "cached.test")
+	fresh := buildTestDataFile(t, time.Now(), "fresh.test")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(fresh)
+	}))
+	defer server.Close()
+
+	// Loading data records its digest next to the cache file
+	dir := t.TempDir()
+	checker, err := New(WithCacheDir(dir), WithDataURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	dataPath := filepath.Join(dir, "data.bin")
+	content, err := os.ReadFile(dataPath + ChecksumSuffix)
+	if err != nil {
+		t.Fatalf("Failed to read cache checksum: %v", err)
+	}
+	if want := checksum(fresh) + "  data.bin\n"; string(content) != want {
+		t.Errorf("cache checksum = %q, want %q", content, want)
+	}
+
+	// Files are written through temporary files, none of which is left
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("Temporary file %s left in the cache directory", entry.Name())
+		}
+	}
+
+	// A corrupted cache file fails verification instead of decoding
+	corrupted := append([]byte(nil), fresh...)
+	corrupted[len(corrupted)/2] ^= 0xff
+	if err := os.WriteFile(dataPath, corrupted, 0644); err != nil {
+		t.Fatalf("Failed to write data.bin: %v", err)
+	}
+
+	err = checker.loadFromCache()
+	var cacheErr *CacheError
+	if !errors.As(err, &cacheErr) || cacheErr.Operation != "verify" || !IsChecksumError(err) {
+		t.Errorf("loadFromCache() error = %v, want a cache verify error caused by a checksum mismatch", err)
+	}
+
+	// So a new checker downloads the data again
+	redownloaded, err := New(WithCacheDir(dir), WithDataURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer redownloaded.Close()
+	if !redownloaded.IsDisposable("fresh.test") {
+		t.Error("Expected the data to be downloaded again")
+	}
+
+	// A data file replaced without its digest fails verification too,
+	// however recently it was written
+	if err := os.WriteFile(dataPath, good, 0644); err != nil {
+		t.Fatalf("Failed to write data.bin: %v", err)
+	}
+	newer := time.Now().Add(time.Hour)
+	if err := os.Chtimes(dataPath, newer, newer); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+	if err := checker.loadFromCache(); !IsChecksumError(err) {
+		t.Errorf("loadFromCache() error = %v, want a checksum mismatch", err)
+	}
+
+	// A cache written before digests were recorded has none to check
+	if err := os.Remove(dataPath + ChecksumSuffix); err != nil {
+		t.Fatalf("Failed to remove cache checksum: %v", err)
+	}
+	if err := checker.loadFromCache(); err != nil {
+		t.Fatalf("loadFromCache() error = %v", err)
+	}
+	if !checker.IsDisposable("cached.test") {
+		t.Error("Expected the cache file without a digest to be loaded")
+	}
+}
@@ -0,0 +1,235 @@
+// disposable-serve runs a shared disposable email checker behind a JSON HTTP
+// API, so services in any language can use one copy of the data instead of
+// each downloading and caching data.bin.
+//
+// Endpoints:
+//
+//	GET  /check?email=user@example.com  check one email or domain
+//	POST /check/batch                   check {"emails": [...]} at once
+//	GET  /stats                         size and version of the loaded data
+//	POST /refresh                       download the latest data now
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	disposable "github.com/rezmoss/go-is-disposable-email"
+)
+
+// options holds the settings for the server.
+type options struct {
+	Addr            string
+	RefreshInterval time.Duration
+	CacheDir        string // Checker cache directory, the default if empty
+	DataURL         string // Data file URL, the default if empty
+	MaxBatch        int    // Most emails accepted by one batch request
+	Concurrency     int    // Workers per batch request, GOMAXPROCS if 0
+	DNSCheck        bool   // Report DNS status in every result
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "Address to listen on")
+	refresh := flag.Duration("refresh", 24*time.Hour, "How often to download the latest data")
+	cacheDir := flag.String("cache-dir", "", "Directory for the cached data file (default: user cache directory)")
+	dataURL := flag.String("data-url", "", "URL of the data file (default: latest release)")
+	maxBatch := flag.Int("max-batch", 10000, "Maximum number of emails in one batch request")
+	concurrency := flag.Int("concurrency", 0, "Workers per batch request (default: number of CPUs)")
+	dnsCheck := flag.Bool("dns", false, "Report whether each domain has MX or address records")
+	flag.Parse()
+
+	opts := options{
+		Addr:            *addr,
+		RefreshInterval: *refresh,
+		CacheDir:        *cacheDir,
+		DataURL:         *dataURL,
+		MaxBatch:        *maxBatch,
+		Concurrency:     *concurrency,
+		DNSCheck:        *dnsCheck,
+	}
+
+	if err := run(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(opts options) error {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+
+	checkerOpts := []disposable.Option{
+		disposable.WithAutoRefresh(opts.RefreshInterval),
+		disposable.WithLogger(logger),
+	}
+	if opts.CacheDir != "" {
+		checkerOpts = append(checkerOpts, disposable.WithCacheDir(opts.CacheDir))
+	}
+	if opts.DataURL != "" {
+		checkerOpts = append(checkerOpts, disposable.WithDataURL(opts.DataURL))
+	}
+	if opts.DNSCheck {
+		checkerOpts = append(checkerOpts, disposable.WithDNSCheck(nil))
+	}
+
+	checker, err := disposable.New(checkerOpts...)
+	if err != nil {
+		return err
+	}
+	defer checker.Close()
+
+	server := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           newHandler(checker, opts),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		logger.Printf("Listening on %s", opts.Addr)
+		errc <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// batchRequest is the body of POST /check/batch.
+type batchRequest struct {
+	Emails []string `json:"emails"`
+}
+
+// batchResponse is the reply to POST /check/batch. Results has one entry per
+// email, in order; emails that couldn't be checked have a zero result and an
+// entry in Errors.
+type batchResponse struct {
+	Results []disposable.Result `json:"results"`
+	Errors  []inputError        `json:"errors"`
+}
+
+// inputError describes an email of a batch that couldn't be checked.
+type inputError struct {
+	Index int    `json:"index"`
+	Input string `json:"input"`
+	Error string `json:"error"`
+}
+
+// errorResponse is the body of every error reply.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// newHandler returns the API served for checker.
+func newHandler(checker *disposable.Checker, opts options) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /check", func(w http.ResponseWriter, r *http.Request) {
+		result, err := checker.CheckWithContext(r.Context(), r.URL.Query().Get("email"))
+		if err != nil {
+			writeError(w, checkStatus(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+
+	mux.HandleFunc("POST /check/batch", func(w http.ResponseWriter, r *http.Request) {
+		var req batchRequest
+		body := http.MaxBytesReader(w, r.Body, maxBatchBodySize(opts.MaxBatch))
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge,
+					fmt.Errorf("request body exceeds %d bytes for a batch of at most %d emails", tooLarge.Limit, opts.MaxBatch))
+				return
+			}
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+		if len(req.Emails) > opts.MaxBatch {
+			writeError(w, http.StatusRequestEntityTooLarge,
+				fmt.Errorf("batch of %d emails exceeds the limit of %d", len(req.Emails), opts.MaxBatch))
+			return
+		}
+
+		results, err := checker.CheckBatch(r.Context(), req.Emails, disposable.BatchOptions{Concurrency: opts.Concurrency})
+		resp := batchResponse{Results: results, Errors: []inputError{}}
+		var batchErr *disposable.BatchError
+		switch {
+		case errors.As(err, &batchErr):
+			for _, e := range batchErr.Errs {
+				resp.Errors = append(resp.Errors, inputError{Index: e.Index, Input: e.Input, Error: e.Err.Error()})
+			}
+		case err != nil:
+			writeError(w, checkStatus(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, checker.Stats())
+	})
+
+	mux.HandleFunc("POST /refresh", func(w http.ResponseWriter, r *http.Request) {
+		if err := checker.RefreshWithContext(r.Context()); err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, checker.Stats())
+	})
+
+	return mux
+}
+
+// maxEmailBodySize is the room a batch body gets per email: the longest
+// address, 254 octets, with room for JSON escapes, quotes and separators.
+const maxEmailBodySize = 1024
+
+// maxBatchBodySize returns the largest body accepted by POST /check/batch
+// when batches hold at most maxBatch emails, so a body is never read
+// further than a valid batch could reach.
+func maxBatchBodySize(maxBatch int) int64 {
+	return int64(max(maxBatch, 0)+1) * maxEmailBodySize
+}
+
+// checkStatus returns the HTTP status for an error from a check.
+func checkStatus(err error) int {
+	switch {
+	case errors.Is(err, disposable.ErrEmptyInput), errors.Is(err, disposable.ErrInvalidDomain),
+		errors.Is(err, disposable.ErrInvalidSyntax):
+		return http.StatusBadRequest
+	case errors.Is(err, disposable.ErrDataExpired):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
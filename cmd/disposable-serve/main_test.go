@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	disposable "github.com/rezmoss/go-is-disposable-email"
+)
+
+// newTestChecker returns a checker loaded from the repository's data.v2.bin,
+// refreshing from a local server. It also returns the number of refreshes
+// served.
+func newTestChecker(t *testing.T) (*disposable.Checker, *atomic.Int32) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "data", "data.v2.bin"))
+	if err != nil {
+		t.Skipf("data/data.v2.bin not available: %v", err)
+	}
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), data, 0644); err != nil {
+		t.Fatalf("Failed to write data.bin: %v", err)
+	}
+
+	checker, err := disposable.New(disposable.WithCacheDir(dir), disposable.WithDataURL(srv.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { checker.Close() })
+	return checker, &hits
+}
+
+func TestCheckEndpoint(t *testing.T) {
+	checker, _ := newTestChecker(t)
+	handler := newHandler(checker, options{MaxBatch: 10})
+
+	tests := []struct {
+		query      string
+		status     int
+		disposable bool
+	}{
+		{"email=user%40mailinator.com", http.StatusOK, true},
+		{"email=user%40gmail.com", http.StatusOK, false},
+		{"email=guerrillamail.com", http.StatusOK, true},
+		{"", http.StatusBadRequest, false},
+		{"email=user%40", http.StatusBadRequest, false},
+		{"email=user+name%40gmail.com", http.StatusBadRequest, false},
+		{"email=a.%40gmail.com", http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/check?"+tt.query, nil))
+
+		if rec.Code != tt.status {
+			t.Errorf("GET /check?%s status = %d, want %d", tt.query, rec.Code, tt.status)
+			continue
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("GET /check?%s Content-Type = %q, want %q", tt.query, got, "application/json")
+		}
+		if tt.status != http.StatusOK {
+			var body errorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Error == "" {
+				t.Errorf("GET /check?%s body = %q, want an error message", tt.query, rec.Body)
+			}
+			continue
+		}
+
+		var result struct{ Disposable bool }
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode body: %v", err)
+		}
+		if result.Disposable != tt.disposable {
+			t.Errorf("GET /check?%s disposable = %v, want %v", tt.query, result.Disposable, tt.disposable)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/check", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /check status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestCheckBatchEndpoint(t *testing.T) {
+	checker, _ := newTestChecker(t)
+	handler := newHandler(checker, options{MaxBatch: 3})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/check/batch", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"emails": ["user@mailinator.com", "", "user@gmail.com"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /check/batch status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var resp struct {
+		Results []struct{ Disposable bool }
+		Errors  []inputError
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if len(resp.Results) != 3 || !resp.Results[0].Disposable || resp.Results[2].Disposable {
+		t.Errorf("results = %+v, want [disposable, zero, not disposable]", resp.Results)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Index != 1 {
+		t.Errorf("errors = %+v, want one for index 1", resp.Errors)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"invalid JSON", `{"emails":`, http.StatusBadRequest},
+		{"too many", `{"emails": ["a@b.com", "c@d.com", "e@f.com", "g@h.com"]}`, http.StatusRequestEntityTooLarge},
+		{"empty", `{"emails": []}`, http.StatusOK},
+		{"oversized body", `{"emails": ["` + strings.Repeat("a", 8192) + `@b.com"]}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		if rec := post(tt.body); rec.Code != tt.status {
+			t.Errorf("POST /check/batch (%s) status = %d, want %d", tt.name, rec.Code, tt.status)
+		}
+	}
+}
+
+func TestStatsAndRefreshEndpoints(t *testing.T) {
+	checker, hits := newTestChecker(t)
+	handler := newHandler(checker, options{MaxBatch: 10})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /stats status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var stats map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if count, _ := stats["blocklist_count"].(float64); count == 0 {
+		t.Errorf("GET /stats blocklist_count = %v, want a positive count", stats["blocklist_count"])
+	}
+
+	before := hits.Load()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/refresh", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /refresh status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if hits.Load() != before+1 {
+		t.Errorf("POST /refresh made %d downloads, want 1", hits.Load()-before)
+	}
+}
+
+func TestCheckStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{disposable.ErrEmptyInput, http.StatusBadRequest},
+		{disposable.ErrInvalidDomain, http.StatusBadRequest},
+		{fmt.Errorf("%w: empty local part", disposable.ErrInvalidSyntax), http.StatusBadRequest},
+		{disposable.ErrDataExpired, http.StatusServiceUnavailable},
+		{os.ErrPermission, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := checkStatus(tt.err); got != tt.want {
+			t.Errorf("checkStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
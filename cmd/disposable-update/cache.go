@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// sourceCache stores downloaded source bodies together with their HTTP
+// validators (ETag/Last-Modified) so later runs can send conditional requests.
+type sourceCache struct {
+	dir string
+}
+
+// cacheEntry is the sidecar metadata stored next to a cached body.
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// newSourceCache returns a cache rooted at dir, or nil if dir is empty.
+func newSourceCache(dir string) (*sourceCache, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &sourceCache{dir: dir}, nil
+}
+
+// paths returns the metadata and body file paths for a URL.
+func (c *sourceCache) paths(url string) (metaPath, bodyPath string) {
+	sum := sha256.Sum256([]byte(url))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, key+".json"), filepath.Join(c.dir, key+".body")
+}
+
+// load returns the cached entry and body for a URL, if both are present.
+func (c *sourceCache) load(url string) (*cacheEntry, []byte, bool) {
+	metaPath, bodyPath := c.paths(url)
+
+	metaData, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(metaData, &entry); err != nil || entry.URL != url {
+		return nil, nil, false
+	}
+
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	return &entry, body, true
+}
+
+// store saves the body and validators for a URL. Responses without any
+// validator are not cached since they can't be revalidated.
+func (c *sourceCache) store(entry *cacheEntry, body []byte) error {
+	if entry.ETag == "" && entry.LastModified == "" {
+		return nil
+	}
+
+	metaPath, bodyPath := c.paths(entry.URL)
+
+	metaData, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Drop the old validators before replacing the body, so a run that dies
+	// midway leaves no metadata for a body it didn't finish; both files are
+	// replaced whole, so neither is ever read half-written
+	if err := os.Remove(metaPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := writeFileAtomic(bodyPath, body); err != nil {
+		return err
+	}
+	return writeFileAtomic(metaPath, metaData)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it over path, so readers see either the old or the new contents in full.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
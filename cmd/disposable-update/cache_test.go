@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDownloadSourceConditional(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("# list\ntempmail.com\nmailinator.com\n"))
+	}))
+	defer server.Close()
+
+	cache, err := newSourceCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("newSourceCache error: %v", err)
+	}
+
+	client := server.Client()
+	expected := []string{"tempmail.com", "mailinator.com"}
+
+	first, _, err := downloadSource(client, cache, server.URL)
+	if err != nil {
+		t.Fatalf("first downloadSource error: %v", err)
+	}
+	if !reflect.DeepEqual(first, expected) {
+		t.Errorf("first download = %v, want %v", first, expected)
+	}
+
+	second, _, err := downloadSource(client, cache, server.URL)
+	if err != nil {
+		t.Fatalf("second downloadSource error: %v", err)
+	}
+	if !reflect.DeepEqual(second, expected) {
+		t.Errorf("cached download = %v, want %v", second, expected)
+	}
+
+	if requests != 2 || notModified != 1 {
+		t.Errorf("Expected 2 requests with 1 not modified, got %d and %d", requests, notModified)
+	}
+}
+
+func TestDownloadSourceWithoutCache(t *testing.T) {
+	var conditional bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = r.Header.Get("If-None-Match") != ""
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("tempmail.com\n"))
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := downloadSource(server.Client(), nil, server.URL); err != nil {
+			t.Fatalf("downloadSource error: %v", err)
+		}
+	}
+
+	if conditional {
+		t.Error("Expected no conditional request without a cache")
+	}
+}
+
+func TestSourceCacheSkipsUnvalidated(t *testing.T) {
+	cache, err := newSourceCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("newSourceCache error: %v", err)
+	}
+
+	url := "https://example.com/list.txt"
+	if err := cache.store(&cacheEntry{URL: url}, []byte("tempmail.com\n")); err != nil {
+		t.Fatalf("store error: %v", err)
+	}
+	if _, _, ok := cache.load(url); ok {
+		t.Error("Expected response without validators to not be cached")
+	}
+}
+
+func TestSourceCacheStoreFailureDropsValidators(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := newSourceCache(dir)
+	if err != nil {
+		t.Fatalf("newSourceCache error: %v", err)
+	}
+
+	url := "https://example.com/list.txt"
+	if err := cache.store(&cacheEntry{URL: url, ETag: `"v1"`}, []byte("tempmail.com\n")); err != nil {
+		t.Fatalf("store error: %v", err)
+	}
+	if _, _, ok := cache.load(url); !ok {
+		t.Fatal("Expected the first response to be cached")
+	}
+
+	// A directory in place of the body makes replacing it fail
+	_, bodyPath := cache.paths(url)
+	if err := os.Remove(bodyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(bodyPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bodyPath, "x"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.store(&cacheEntry{URL: url, ETag: `"v2"`}, []byte("tempmail.com\nmailinator.com\n")); err == nil {
+		t.Fatal("Expected store to fail when the body can't be replaced")
+	}
+	if _, _, ok := cache.load(url); ok {
+		t.Error("Expected no cached entry after a failed store")
+	}
+
+	tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if len(tmps) != 0 {
+		t.Errorf("Temporary files left behind: %v", tmps)
+	}
+}
@@ -4,16 +4,23 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"sort"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/rezmoss/go-is-disposable-email/data"
+	"github.com/rezmoss/go-is-disposable-email/internal/idna"
 	"github.com/rezmoss/go-is-disposable-email/internal/trie"
 )
 
@@ -52,27 +59,120 @@ func (s *UpdateStats) Summary() string {
 	return strings.Join(parts, ", ")
 }
 
+// options holds the settings for an update run.
+type options struct {
+	OutputDir   string
+	SourcesFile string
+	ManualFile  string
+	Verbose     bool
+	Timeout     time.Duration
+	SummaryFile string
+	CacheDir    string // Source download cache, disabled if empty
+	Level       int    // gzip compression level of data.bin
+	Counts      bool   // Record how many sources list each blocklist domain
+
+	// Also drop blocklist entries that are subdomains of an allowlisted
+	// domain, instead of exact matches only
+	HierarchicalAllowlist bool
+
+	// Spool each source to disk and insert its domains straight into the
+	// tries instead of collecting all sources in maps first
+	LowMem bool
+}
+
 func main() {
-	outputDir := flag.String("o", "./data", "Output directory for data.bin")
+	outputDir := flag.String("o", "./data", "Output directory for data.v2.bin and data.bin")
 	sourcesFile := flag.String("sources", "", "Path to sources.txt file (default: <output-dir>/sources.txt)")
 	manualFile := flag.String("manual", "", "Path to manual additions file")
 	verbose := flag.Bool("v", false, "Verbose output")
 	timeout := flag.Duration("timeout", 60*time.Second, "HTTP timeout for downloads")
 	summaryFile := flag.String("summary", "", "Write update summary to file (for CI)")
+	cacheDir := flag.String("cache-dir", "", "Cache source downloads here and use conditional requests on later runs")
+	validateFile := flag.String("validate", "", "Validate a data.bin file and print its stats (- for stdin)")
+	checkMode := flag.Bool("check", false, "Check the domains given as arguments against a data.bin file")
+	dataFile := flag.String("data", "", "Path to data.bin for -check and -flat (default: <output-dir>/data.v2.bin, - for stdin)")
+	flatFile := flag.String("flat", "", "Convert data.bin to the flat format read by WithMmapData and write it to this path")
+	deltaBase := flag.String("delta-base", "", "Write the delta from this data file, the previous release's, to the -data file as <data>.delta")
+	level := flag.Int("level", trie.DefaultCompressionLevel, "gzip compression level for data.bin (1 fastest to 9 smallest, 0 none, -1 default, -2 Huffman only)")
+	counts := flag.Bool("counts", false, "Record how many sources list each blocklist domain in data.bin")
+	hierarchicalAllowlist := flag.Bool("hierarchical-allowlist", false, "Also remove blocklist domains that are subdomains of an allowlisted domain (default: exact matches only)")
+	lowMem := flag.Bool("low-mem", false, "Stream sources through temporary files into the tries to reduce memory use (incompatible with -counts)")
 	flag.Parse()
 
+	if *validateFile != "" {
+		if err := validateData(*validateFile, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *dataFile == "" {
+		*dataFile = filepath.Join(*outputDir, data.AssetName)
+	}
+
+	if *flatFile != "" {
+		if err := writeFlat(*dataFile, *flatFile, os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *deltaBase != "" {
+		if err := writeReleaseDelta(*deltaBase, *dataFile, *level); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *checkMode {
+		if err := checkDomains(*dataFile, flag.Args(), os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Default sources file location
 	if *sourcesFile == "" {
 		*sourcesFile = filepath.Join(*outputDir, "sources.txt")
 	}
 
-	if err := run(*outputDir, *sourcesFile, *manualFile, *verbose, *timeout, *summaryFile); err != nil {
+	opts := options{
+		OutputDir:   *outputDir,
+		SourcesFile: *sourcesFile,
+		ManualFile:  *manualFile,
+		Verbose:     *verbose,
+		Timeout:     *timeout,
+		SummaryFile: *summaryFile,
+		CacheDir:    *cacheDir,
+		Level:       *level,
+		Counts:      *counts,
+
+		HierarchicalAllowlist: *hierarchicalAllowlist,
+		LowMem:                *lowMem,
+	}
+
+	if err := run(opts); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func run(outputDir, sourcesFile, manualFile string, verbose bool, timeout time.Duration, summaryFile string) error {
+func run(opts options) error {
+	// Fail before downloading anything
+	if opts.Level < gzip.HuffmanOnly || opts.Level > gzip.BestCompression {
+		return fmt.Errorf("invalid compression level %d", opts.Level)
+	}
+	if opts.LowMem && opts.Counts {
+		return fmt.Errorf("source counts are not supported in low-memory mode")
+	}
+
+	outputDir, sourcesFile, manualFile := opts.OutputDir, opts.SourcesFile, opts.ManualFile
+	verbose, summaryFile := opts.Verbose, opts.SummaryFile
+
 	log := func(format string, args ...any) {
 		if verbose {
 			fmt.Printf(format+"\n", args...)
@@ -84,7 +184,8 @@ func run(outputDir, sourcesFile, manualFile string, verbose bool, timeout time.D
 
 	// Load existing data to compare changes
 	stats := &UpdateStats{}
-	outputPath := filepath.Join(outputDir, "data.bin")
+	outputPath := filepath.Join(outputDir, data.AssetName)
+	legacyPath := filepath.Join(outputDir, data.LegacyAssetName)
 	if existingData, err := os.ReadFile(outputPath); err == nil {
 		if oldBlocklist, oldAllowlist, _, err := trie.Deserialize(existingData); err == nil {
 			stats.OldBlocklistCount = oldBlocklist.Size()
@@ -101,46 +202,71 @@ func run(outputDir, sourcesFile, manualFile string, verbose bool, timeout time.D
 	}
 	log("Loaded %d sources", len(sources))
 
-	client := &http.Client{Timeout: timeout}
+	// One client for all sources, so connections to the same host are reused
+	client := &http.Client{Timeout: opts.Timeout}
 
-	blocklist := make(map[string]struct{})
+	cache, err := newSourceCache(opts.CacheDir)
+	if err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	blocklist := make(map[string]int) // Number of sources listing each domain
 	allowlist := make(map[string]struct{})
 	successfulSources := 0
 
+	// In low-memory mode, domains go straight into the tries instead
+	blocklistTrie := trie.New()
+	allowlistTrie := trie.New()
+	addManual := func(domains []string) {
+		if opts.LowMem {
+			for _, domain := range domains {
+				insertValid(blocklistTrie, domain)
+			}
+			return
+		}
+		addCounted(blocklist, domains)
+	}
+
 	// Download from all sources
 	for _, src := range sources {
 		log("Downloading %s...", src.Name)
 
-		domains, err := downloadSource(client, src.URL)
+		var (
+			n        int
+			warnings []LineWarning
+		)
+		if opts.LowMem {
+			target := blocklistTrie
+			if src.Type == SourceTypeAllowlist {
+				target = allowlistTrie
+			}
+			n, warnings, err = streamSource(client, cache, src.URL, target)
+		} else {
+			n, warnings, err = collectSource(client, cache, src, blocklist, allowlist)
+		}
 		if err != nil {
 			logError("Failed to download %s: %v (skipping)", src.Name, err)
 			stats.FailedSources = append(stats.FailedSources, src.Name)
 			continue
 		}
 
+		// Report data quality so that bad sources can be curated
+		if len(warnings) > 0 {
+			log("  %d of %d entries in %s are malformed", len(warnings), n, src.Name)
+			for _, w := range warnings[:min(len(warnings), maxReportedWarnings)] {
+				log("    %s", w)
+			}
+		}
+
 		// Validate: skip empty sources
-		if len(domains) == 0 {
+		if n == 0 {
 			logError("Source %s returned empty data (skipping)", src.Name)
 			stats.FailedSources = append(stats.FailedSources, src.Name)
 			continue
 		}
 
-		log("  Downloaded %d domains from %s", len(domains), src.Name)
+		log("  Downloaded %d domains from %s", n, src.Name)
 		successfulSources++
-
-		for _, domain := range domains {
-			domain = normalizeDomain(domain)
-			if domain == "" || !isValidDomain(domain) {
-				continue
-			}
-
-			switch src.Type {
-			case SourceTypeBlocklist:
-				blocklist[domain] = struct{}{}
-			case SourceTypeAllowlist:
-				allowlist[domain] = struct{}{}
-			}
-		}
 	}
 
 	// Check if we have any successful sources
@@ -156,12 +282,7 @@ func run(outputDir, sourcesFile, manualFile string, verbose bool, timeout time.D
 			log("  Warning: could not load manual file: %v", err)
 		} else {
 			log("  Loaded %d manual domains", len(manualDomains))
-			for _, domain := range manualDomains {
-				domain = normalizeDomain(domain)
-				if domain != "" && isValidDomain(domain) {
-					blocklist[domain] = struct{}{}
-				}
-			}
+			addManual(manualDomains)
 		}
 	}
 
@@ -174,37 +295,70 @@ func run(outputDir, sourcesFile, manualFile string, verbose bool, timeout time.D
 			log("  Warning: could not load manual file: %v", err)
 		} else {
 			log("  Loaded %d manual domains", len(manualDomains))
-			for _, domain := range manualDomains {
-				domain = normalizeDomain(domain)
-				if domain != "" && isValidDomain(domain) {
-					blocklist[domain] = struct{}{}
-				}
-			}
+			addManual(manualDomains)
 		}
 	}
 
-	// Remove allowlisted domains from blocklist
-	for domain := range allowlist {
-		delete(blocklist, domain)
+	// Providers for typo suggestions are kept in their given order
+	var providers []string
+	providersPath := filepath.Join(outputDir, "providers.txt")
+	if _, err := os.Stat(providersPath); err == nil {
+		log("Loading providers from %s...", providersPath)
+		providers, err = loadProvidersFile(providersPath)
+		if err != nil {
+			log("  Warning: could not load providers file: %v", err)
+		} else {
+			log("  Loaded %d providers", len(providers))
+		}
 	}
 
-	log("Total unique blocklist domains: %d", len(blocklist))
-	log("Total unique allowlist domains: %d", len(allowlist))
+	// Rules for normalizing addresses by provider
+	var providerRules []trie.ProviderRule
+	rulesPath := filepath.Join(outputDir, "provider-rules.txt")
+	if _, err := os.Stat(rulesPath); err == nil {
+		log("Loading provider rules from %s...", rulesPath)
+		providerRules, err = LoadProviderRulesFromFile(rulesPath)
+		if err != nil {
+			log("  Warning: could not load provider rules: %v", err)
+		} else {
+			log("  Loaded %d provider rules", len(providerRules))
+		}
+	}
 
-	// Validate: don't save if we ended up with an empty blocklist
-	if len(blocklist) == 0 {
-		return fmt.Errorf("blocklist is empty after processing, not updating data.bin to preserve existing data")
+	// Wildcard patterns for providers that rotate TLDs or numbered names
+	var blockPatterns, allowPatterns []string
+	patternsPath := filepath.Join(outputDir, "patterns.txt")
+	if _, err := os.Stat(patternsPath); err == nil {
+		log("Loading patterns from %s...", patternsPath)
+		blockPatterns, allowPatterns, err = LoadPatternsFromFile(patternsPath)
+		if err != nil {
+			log("  Warning: could not load patterns: %v", err)
+		} else {
+			log("  Loaded %d blocklist and %d allowlist patterns", len(blockPatterns), len(allowPatterns))
+		}
 	}
 
-	// Build tries
-	blocklistTrie := trie.New()
-	for domain := range blocklist {
-		blocklistTrie.Insert(domain)
+	// Remove allowlisted domains from blocklist
+	if opts.LowMem {
+		removeAllowlistedTrie(blocklistTrie, allowlistTrie, opts.HierarchicalAllowlist)
+	} else {
+		removeAllowlisted(blocklist, allowlist, opts.HierarchicalAllowlist)
+
+		// Build tries
+		for domain := range blocklist {
+			blocklistTrie.Insert(domain)
+		}
+		for domain := range allowlist {
+			allowlistTrie.Insert(domain)
+		}
 	}
 
-	allowlistTrie := trie.New()
-	for domain := range allowlist {
-		allowlistTrie.Insert(domain)
+	log("Total unique blocklist domains: %d", blocklistTrie.Size())
+	log("Total unique allowlist domains: %d", allowlistTrie.Size())
+
+	// Validate: don't save if we ended up with an empty blocklist
+	if blocklistTrie.Size() == 0 {
+		return fmt.Errorf("blocklist is empty after processing, not updating data.bin to preserve existing data")
 	}
 
 	// Ensure output directory exists
@@ -215,7 +369,16 @@ func run(outputDir, sourcesFile, manualFile string, verbose bool, timeout time.D
 	// Serialize and write to file
 	log("Writing %s...", outputPath)
 
-	data, err := trie.Serialize(blocklistTrie, allowlistTrie)
+	dataFile := trie.NewDataFile(blocklistTrie, allowlistTrie, time.Now())
+	if opts.Counts {
+		dataFile.SourceCounts = blocklist
+	}
+	dataFile.Providers = providers
+	dataFile.ProviderRules = providerRules
+	dataFile.BlockPatterns = blockPatterns
+	dataFile.AllowPatterns = allowPatterns
+
+	data, err := trie.SerializeDataFile(dataFile, opts.Level)
 	if err != nil {
 		return fmt.Errorf("failed to serialize: %w", err)
 	}
@@ -224,6 +387,23 @@ func run(outputDir, sourcesFile, manualFile string, verbose bool, timeout time.D
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
+	// Published next to data.v2.bin so that checkers can verify downloads
+	if err := os.WriteFile(outputPath+".sha256", checksumLine(data, filepath.Base(outputPath)), 0644); err != nil {
+		return fmt.Errorf("failed to write checksum: %w", err)
+	}
+
+	// Releases before format 2 download data.bin and can't read format 2
+	legacy, err := trie.SerializeDataFileV1(dataFile, opts.Level)
+	if err != nil {
+		return fmt.Errorf("failed to serialize in format 1: %w", err)
+	}
+	if err := os.WriteFile(legacyPath, legacy, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.WriteFile(legacyPath+".sha256", checksumLine(legacy, filepath.Base(legacyPath)), 0644); err != nil {
+		return fmt.Errorf("failed to write checksum: %w", err)
+	}
+
 	// Update stats
 	stats.NewBlocklistCount = blocklistTrie.Size()
 	stats.NewAllowlistCount = allowlistTrie.Size()
@@ -248,10 +428,10 @@ func run(outputDir, sourcesFile, manualFile string, verbose bool, timeout time.D
 
 	// Also write a text version of the lists for reference
 	if verbose {
-		if err := writeTextList(filepath.Join(outputDir, "blocklist.txt"), blocklist); err != nil {
+		if err := writeTextList(filepath.Join(outputDir, "blocklist.txt"), blocklistTrie.GetAll()); err != nil {
 			log("  Warning: could not write blocklist.txt: %v", err)
 		}
-		if err := writeTextList(filepath.Join(outputDir, "allowlist.txt"), allowlist); err != nil {
+		if err := writeTextList(filepath.Join(outputDir, "allowlist.txt"), allowlistTrie.GetAll()); err != nil {
 			log("  Warning: could not write allowlist.txt: %v", err)
 		}
 	}
@@ -259,30 +439,273 @@ func run(outputDir, sourcesFile, manualFile string, verbose bool, timeout time.D
 	return nil
 }
 
-func downloadSource(client *http.Client, url string) ([]string, error) {
-	resp, err := client.Get(url)
+// loadDataFile deserializes a data file from path, or from stdin if path is "-".
+func loadDataFile(path string, stdin io.Reader) (*trie.Trie, *trie.Trie, *trie.DataFile, error) {
+	if path == "-" {
+		return trie.DeserializeFromReader(stdin)
+	}
+
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
+	}
+	return trie.Deserialize(data)
+}
+
+// validateData checks that a data file can be loaded and prints its stats.
+func validateData(path string, stdin io.Reader, w io.Writer) error {
+	blocklist, allowlist, dataFile, err := loadDataFile(path, stdin)
+	if err != nil {
+		return fmt.Errorf("invalid data file: %w", err)
+	}
+
+	if blocklist.Size() == 0 {
+		return fmt.Errorf("invalid data file: blocklist is empty")
+	}
+
+	fmt.Fprintf(w, "Valid data file (version %s, created %s)\n", dataFile.Version, dataFile.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "  Blocklist domains: %d\n", blocklist.Size())
+	fmt.Fprintf(w, "  Allowlist domains: %d\n", allowlist.Size())
+	return nil
+}
+
+// writeFlat converts the data file at dataPath, or stdin if dataPath is "-",
+// to the flat format and writes it to path.
+func writeFlat(dataPath, path string, stdin io.Reader) error {
+	blocklist, _, dataFile, err := loadDataFile(dataPath, stdin)
+	if err != nil {
+		return fmt.Errorf("failed to load data file: %w", err)
+	}
+	if blocklist.Size() == 0 {
+		return fmt.Errorf("invalid data file: blocklist is empty")
+	}
+
+	flat, err := trie.MarshalFlat(dataFile)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, flat, 0644)
+}
+
+// checkDomains checks each domain against a data file and prints the result.
+func checkDomains(path string, domains []string, stdin io.Reader, w io.Writer) error {
+	if len(domains) == 0 {
+		return fmt.Errorf("no domains given to check")
+	}
+
+	blocklist, allowlist, _, err := loadDataFile(path, stdin)
+	if err != nil {
+		return fmt.Errorf("failed to load data file: %w", err)
+	}
+
+	for _, domain := range domains {
+		if idx := strings.LastIndex(domain, "@"); idx != -1 {
+			domain = domain[idx+1:]
+		}
+		domain = normalizeDomain(domain)
+
+		status := "ok"
+		if !allowlist.ContainsHierarchical(domain) && blocklist.ContainsHierarchical(domain) {
+			status = "disposable"
+		}
+		fmt.Fprintf(w, "%s: %s\n", domain, status)
+	}
+
+	return nil
+}
+
+// collectSource downloads a source and adds its valid domains to blocklist or
+// allowlist, depending on the source type. It returns the number of entries
+// the source listed and the malformed ones.
+func collectSource(client *http.Client, cache *sourceCache, src Source, blocklist map[string]int, allowlist map[string]struct{}) (int, []LineWarning, error) {
+	domains, warnings, err := downloadSource(client, cache, src.URL)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	switch src.Type {
+	case SourceTypeBlocklist:
+		addCounted(blocklist, domains)
+	case SourceTypeAllowlist:
+		for _, domain := range domains {
+			domain = normalizeDomain(domain)
+			if domain != "" && isValidDomain(domain) {
+				allowlist[domain] = struct{}{}
+			}
+		}
+	}
+	return len(domains) + len(warnings), warnings, nil
+}
+
+// streamSource downloads a source to a temporary file and inserts its valid
+// domains into t while reading them back, so that no more than one copy of
+// the domains is held in memory. It returns the number of entries the source
+// listed and the malformed ones. t is only modified once the download has
+// completed.
+func streamSource(client *http.Client, cache *sourceCache, url string, t *trie.Trie) (int, []LineWarning, error) {
+	f, err := os.CreateTemp("", "disposable-source-*")
+	if err != nil {
+		return 0, nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := fetchSource(client, cache, url, func(r io.Reader) error {
+		_, err := io.Copy(f, r)
+		return err
+	}); err != nil {
+		return 0, nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, nil, err
+	}
+
+	var warnings []LineWarning
+	n, err := scanLines(f, func(lineNo int, line string) {
+		if w, ok := checkLine(lineNo, line); ok {
+			warnings = append(warnings, w)
+			return
+		}
+		insertValid(t, line)
+	})
+	return n, warnings, err
+}
+
+// downloadSource downloads a source and returns its well-formed entries and
+// warnings for the malformed ones.
+func downloadSource(client *http.Client, cache *sourceCache, url string) ([]string, []LineWarning, error) {
+	var (
+		lines    []string
+		warnings []LineWarning
+	)
+	err := fetchSource(client, cache, url, func(r io.Reader) error {
+		var err error
+		lines, warnings, err = parseLinesWithWarnings(r)
+		return err
+	})
+	return lines, warnings, err
+}
+
+// fetchSource requests url and calls read with the response body. With a
+// cache, the request is conditional and an unchanged source is read from the
+// cache instead.
+func fetchSource(client *http.Client, cache *sourceCache, url string, read func(io.Reader) error) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	var cachedBody []byte
+	if cache != nil {
+		if entry, body, ok := cache.load(url); ok {
+			cachedBody = body
+			if entry.ETag != "" {
+				req.Header.Set("If-None-Match", entry.ETag)
+			}
+			if entry.LastModified != "" {
+				req.Header.Set("If-Modified-Since", entry.LastModified)
+			}
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cachedBody != nil {
+		return read(bytes.NewReader(cachedBody))
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if cache == nil {
+		return read(resp.Body)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
 	}
 
-	return parseLines(resp.Body)
+	entry := &cacheEntry{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if err := cache.store(entry, body); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not cache %s: %v\n", url, err)
+	}
+
+	return read(bytes.NewReader(body))
 }
 
 func parseLines(r io.Reader) ([]string, error) {
 	var lines []string
+	_, err := scanLines(r, func(_ int, line string) {
+		lines = append(lines, line)
+	})
+	return lines, err
+}
+
+// maxReportedWarnings limits the malformed lines printed per source.
+const maxReportedWarnings = 10
+
+// LineWarning describes a source line that doesn't hold a valid domain.
+type LineWarning struct {
+	Line   int    // Line number, starting at 1
+	Text   string // The trimmed line
+	Reason string // Why the line was rejected, e.g. "contains whitespace"
+}
+
+func (w LineWarning) String() string {
+	return fmt.Sprintf("line %d: %s: %q", w.Line, w.Reason, w.Text)
+}
+
+// parseLinesWithWarnings is parseLines, but only returns the lines that hold
+// a valid domain and reports the others, which parseLines keeps for later
+// validation to drop silently.
+func parseLinesWithWarnings(r io.Reader) ([]string, []LineWarning, error) {
+	var (
+		lines    []string
+		warnings []LineWarning
+	)
+	_, err := scanLines(r, func(lineNo int, line string) {
+		if w, ok := checkLine(lineNo, line); ok {
+			warnings = append(warnings, w)
+			return
+		}
+		lines = append(lines, line)
+	})
+	return lines, warnings, err
+}
+
+// checkLine returns a warning if line doesn't hold a valid domain.
+func checkLine(lineNo int, line string) (LineWarning, bool) {
+	reason := domainProblem(normalizeDomain(line))
+	if reason == "" {
+		return LineWarning{}, false
+	}
+	return LineWarning{Line: lineNo, Text: line, Reason: reason}, true
+}
+
+// scanLines calls fn with the line number and text of each non-empty,
+// non-comment line of r, trimmed, and returns the number of such lines.
+func scanLines(r io.Reader, fn func(lineNo int, line string)) (int, error) {
+	n, lineNo := 0, 0
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
+		lineNo++
 		line := strings.TrimSpace(scanner.Text())
 		if line != "" && !strings.HasPrefix(line, "#") {
-			lines = append(lines, line)
+			fn(lineNo, line)
+			n++
 		}
 	}
-	return lines, scanner.Err()
+	return n, scanner.Err()
 }
 
 func loadManualFile(path string) ([]string, error) {
@@ -295,34 +718,110 @@ func loadManualFile(path string) ([]string, error) {
 	return parseLines(f)
 }
 
+// checksumLine returns the SHA-256 digest of data in sha256sum format.
+func checksumLine(data []byte, name string) []byte {
+	sum := sha256.Sum256(data)
+	return []byte(hex.EncodeToString(sum[:]) + "  " + name + "\n")
+}
+
+// writeReleaseDelta writes the delta from the data file at basePath to the
+// one at dataPath next to the latter, e.g. as data.v2.bin.delta, with its
+// digest.
+// Checkers refresh from the latest release, so basePath is the data file of
+// the release before the one being published, not the previous commit's.
+func writeReleaseDelta(basePath, dataPath string, level int) error {
+	if dataPath == "-" {
+		return fmt.Errorf("the delta is written next to the data file, which can't be read from stdin")
+	}
+	_, _, base, err := loadDataFile(basePath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to load base data file: %w", err)
+	}
+	_, _, target, err := loadDataFile(dataPath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to load data file: %w", err)
+	}
+
+	data, err := trie.SerializeDelta(trie.NewDelta(base, target), level)
+	if err != nil {
+		return fmt.Errorf("failed to serialize delta: %w", err)
+	}
+	path := dataPath + ".delta"
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	// Checkers verifying downloads only apply deltas matching it
+	return os.WriteFile(path+".sha256", checksumLine(data, filepath.Base(path)), 0644)
+}
+
+// loadProvidersFile reads a list of mail provider domains, normalized and
+// without duplicates or invalid entries, keeping their order.
+func loadProvidersFile(path string) ([]string, error) {
+	lines, err := loadManualFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []string
+	for _, line := range lines {
+		domain := normalizeDomain(line)
+		if isValidDomain(domain) && !slices.Contains(providers, domain) {
+			providers = append(providers, domain)
+		}
+	}
+	return providers, nil
+}
+
+// normalizeDomain lowercases and trims a domain and converts
+// internationalized domains to punycode, the form lookups use. Wildcard
+// entries such as "*.example.com" are reduced to their base domain, since
+// hierarchical matching already covers all subdomains. Domains that can't be
+// converted are returned lowercased, for isValidDomain to reject.
 func normalizeDomain(domain string) string {
 	domain = strings.TrimSpace(domain)
 	domain = strings.ToLower(domain)
+	domain = strings.TrimPrefix(domain, "*.")
+	if ascii, err := idna.ToASCII(domain); err == nil {
+		domain = ascii
+	}
 	return domain
 }
 
 func isValidDomain(domain string) bool {
+	return domainProblem(domain) == ""
+}
+
+// domainProblem returns why domain is not valid, or "" if it is.
+func domainProblem(domain string) string {
 	if domain == "" {
-		return false
+		return "empty domain"
+	}
+
+	if strings.ContainsFunc(domain, unicode.IsSpace) {
+		return "contains whitespace"
 	}
 
 	if !strings.Contains(domain, ".") {
-		return false
+		return "no dot"
 	}
 
 	parts := strings.Split(domain, ".")
+	if len(parts) > trie.MaxLabels {
+		// Lookups never inspect that deep, so the entry could never match
+		return fmt.Sprintf("more than %d labels", trie.MaxLabels)
+	}
 	for _, part := range parts {
 		if part == "" {
-			return false
+			return "empty label"
 		}
 		for _, c := range part {
 			if !isValidDomainChar(c) {
-				return false
+				return fmt.Sprintf("invalid character %q", c)
 			}
 		}
 	}
 
-	return true
+	return ""
 }
 
 func isValidDomainChar(c rune) bool {
@@ -332,12 +831,72 @@ func isValidDomainChar(c rune) bool {
 		c == '_'
 }
 
-func writeTextList(path string, domains map[string]struct{}) error {
-	sorted := make([]string, 0, len(domains))
-	for domain := range domains {
-		sorted = append(sorted, domain)
+// removeAllowlisted deletes the allowlisted domains from blocklist. By default
+// only exact matches are removed, so "promo.example.com" stays blocked when
+// "example.com" is allowlisted; with hierarchical set, subdomains of
+// allowlisted domains are removed too.
+func removeAllowlisted(blocklist map[string]int, allowlist map[string]struct{}, hierarchical bool) {
+	for domain := range allowlist {
+		delete(blocklist, domain)
+	}
+	if !hierarchical {
+		return
+	}
+
+	allowed := trie.New()
+	for domain := range allowlist {
+		allowed.Insert(domain)
 	}
-	sort.Strings(sorted)
+	for domain := range blocklist {
+		if allowed.ContainsHierarchical(domain) {
+			delete(blocklist, domain)
+		}
+	}
+}
+
+// removeAllowlistedTrie is removeAllowlisted for low-memory mode, where the
+// lists are already tries.
+func removeAllowlistedTrie(blocklist, allowlist *trie.Trie, hierarchical bool) {
+	for _, domain := range allowlist.GetAll() {
+		blocklist.Remove(domain)
+	}
+	if !hierarchical {
+		return
+	}
+
+	for _, domain := range blocklist.GetAll() {
+		if allowlist.ContainsHierarchical(domain) {
+			blocklist.Remove(domain)
+		}
+	}
+}
+
+// insertValid normalizes domain and inserts it into t if it is valid.
+func insertValid(t *trie.Trie, domain string) {
+	if domain = normalizeDomain(domain); domain != "" && isValidDomain(domain) {
+		t.Insert(domain)
+	}
+}
+
+// addCounted adds the valid domains of one source to counts, counting each
+// domain at most once.
+func addCounted(counts map[string]int, domains []string) {
+	seen := make(map[string]struct{}, len(domains))
+	for _, domain := range domains {
+		domain = normalizeDomain(domain)
+		if domain == "" || !isValidDomain(domain) {
+			continue
+		}
+		if _, ok := seen[domain]; ok {
+			continue
+		}
+		seen[domain] = struct{}{}
+		counts[domain]++
+	}
+}
+
+func writeTextList(path string, domains []string) error {
+	sorted := slices.Sorted(slices.Values(domains))
 
 	f, err := os.Create(path)
 	if err != nil {
@@ -1,9 +1,20 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,6 +29,10 @@ func TestNormalizeDomain(t *testing.T) {
 		{"EXAMPLE.COM", "example.com"},
 		{"  example.com  ", "example.com"},
 		{"Example.Com", "example.com"},
+		{"*.example.com", "example.com"},
+		{"*.Sub.Example.com", "sub.example.com"},
+		{"München.de", "xn--mnchen-3ya.de"},
+		{"*.пример.рф", "xn--e1afmkfd.xn--p1ai"},
 		{"", ""},
 	}
 
@@ -40,6 +55,9 @@ func TestIsValidDomain(t *testing.T) {
 		{"test_domain.com", true},
 		{"123.com", true},
 		{"a.b.c.d.com", true},
+		{"a.b.c.d.e.f.g.h.i.com", true},
+		{"a.b.c.d.e.f.g.h.i.j.com", false}, // Deeper than lookups inspect
+		{"xn--e1afmkfd.xn--p1ai", true},
 		{"example", false},         // No TLD
 		{"", false},                // Empty
 		{".com", false},            // Starts with dot
@@ -101,6 +119,42 @@ domain4.com
 	}
 }
 
+func TestParseLinesWithWarnings(t *testing.T) {
+	input := `# Comment line
+domain1.com
+0.0.0.0 domain2.com
+localhost
+
+*.Domain3.com
+bad..com
+exa$mple.com
+пример.рф
+`
+	lines, warnings, err := parseLinesWithWarnings(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseLinesWithWarnings error: %v", err)
+	}
+
+	expected := []string{"domain1.com", "*.Domain3.com", "пример.рф"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("lines = %v, want %v", lines, expected)
+	}
+
+	expectedWarnings := []LineWarning{
+		{Line: 3, Text: "0.0.0.0 domain2.com", Reason: "contains whitespace"},
+		{Line: 4, Text: "localhost", Reason: "no dot"},
+		{Line: 7, Text: "bad..com", Reason: "empty label"},
+		{Line: 8, Text: "exa$mple.com", Reason: "invalid character '$'"},
+	}
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Errorf("warnings = %v, want %v", warnings, expectedWarnings)
+	}
+
+	if got, want := warnings[0].String(), `line 3: contains whitespace: "0.0.0.0 domain2.com"`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
 func TestLoadSourcesFromFile(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "sources-test-*")
 	if err != nil {
@@ -226,6 +280,13 @@ func TestUpdateStatsSummary(t *testing.T) {
 	}
 }
 
+func TestRunInvalidLevel(t *testing.T) {
+	err := run(options{OutputDir: t.TempDir(), SourcesFile: "unused.txt", Level: 42})
+	if err == nil || !strings.Contains(err.Error(), "compression level") {
+		t.Errorf("run() error = %v, want invalid compression level error", err)
+	}
+}
+
 func TestRunWithSourcesFile(t *testing.T) {
 	// Create a temporary directory for test
 	tmpDir, err := os.MkdirTemp("", "disposable-update-test-*")
@@ -249,33 +310,649 @@ allowlist|disposable-email-domains|https://raw.githubusercontent.com/disposable-
 	outputDir := filepath.Join(tmpDir, "output")
 
 	// Run the update
-	err = run(outputDir, sourcesPath, "", true, 60*time.Second, "")
+	err = run(options{
+		OutputDir:   outputDir,
+		SourcesFile: sourcesPath,
+		Verbose:     true,
+		Timeout:     60 * time.Second,
+	})
 	if err != nil {
 		t.Fatalf("run() error: %v", err)
 	}
 
 	// Verify output file exists
-	dataPath := filepath.Join(outputDir, "data.bin")
+	dataPath := filepath.Join(outputDir, "data.v2.bin")
 	if _, err := os.Stat(dataPath); os.IsNotExist(err) {
-		t.Error("Expected data.bin to be created")
+		t.Error("Expected data.v2.bin to be created")
+	}
+}
+
+func TestRunWithWildcardSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("*.wildcard-example.com\ntempmail.com\n*bad.com\n"))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	sourcesPath := filepath.Join(tmpDir, "sources.txt")
+	if err := os.WriteFile(sourcesPath, []byte("blocklist|Test|"+server.URL+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sources.txt: %v", err)
+	}
+
+	outputDir := filepath.Join(tmpDir, "output")
+	if err := run(options{OutputDir: outputDir, SourcesFile: sourcesPath, Timeout: 10 * time.Second}); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "data.v2.bin"))
+	if err != nil {
+		t.Fatalf("Failed to read data.v2.bin: %v", err)
+	}
+
+	blocklist, _, _, err := trie.Deserialize(data)
+	if err != nil {
+		t.Fatalf("Failed to deserialize data.v2.bin: %v", err)
+	}
+
+	if !blocklist.Contains("wildcard-example.com") {
+		t.Error("Expected wildcard entry to be stored as its base domain")
+	}
+	if !blocklist.Contains("tempmail.com") {
+		t.Error("Expected tempmail.com in blocklist")
+	}
+	if blocklist.Size() != 2 {
+		t.Errorf("Expected 2 blocklist domains, got %d", blocklist.Size())
+	}
+}
+
+func TestRunWithIDNSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tëmpmail.com\nПОЧТА.рф\nxn--80a1acny.xn--p1ai\n*.例子.广告\n"))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	sourcesPath := filepath.Join(tmpDir, "sources.txt")
+	if err := os.WriteFile(sourcesPath, []byte("blocklist|Test|"+server.URL+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sources.txt: %v", err)
+	}
+
+	for _, lowMem := range []bool{false, true} {
+		t.Run(fmt.Sprintf("lowMem=%v", lowMem), func(t *testing.T) {
+			outputDir := t.TempDir()
+			opts := options{OutputDir: outputDir, SourcesFile: sourcesPath, Timeout: 10 * time.Second, LowMem: lowMem}
+			if err := run(opts); err != nil {
+				t.Fatalf("run() error: %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(outputDir, "data.v2.bin"))
+			if err != nil {
+				t.Fatalf("Failed to read data.v2.bin: %v", err)
+			}
+			blocklist, _, _, err := trie.Deserialize(data)
+			if err != nil {
+				t.Fatalf("Failed to deserialize data.v2.bin: %v", err)
+			}
+
+			// Unicode and punycode entries end up as the same punycode entry
+			for _, domain := range []string{"xn--tmpmail-rya.com", "xn--80a1acny.xn--p1ai", "xn--fsqu00a.xn--4rr70v"} {
+				if !blocklist.Contains(domain) {
+					t.Errorf("Expected %s in blocklist", domain)
+				}
+			}
+			if blocklist.Size() != 3 {
+				t.Errorf("Expected 3 blocklist domains, got %d", blocklist.Size())
+			}
+		})
+	}
+}
+
+func TestRunWithProviderData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tempmail.com\n"))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	sourcesPath := filepath.Join(tmpDir, "sources.txt")
+	if err := os.WriteFile(sourcesPath, []byte("blocklist|Test|"+server.URL+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sources.txt: %v", err)
+	}
+	providers := "# Providers\nGmail.com\nyahoo.com\nnot a domain\ngmail.com\nhotmail.com\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "providers.txt"), []byte(providers), 0644); err != nil {
+		t.Fatalf("Failed to write providers.txt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "provider-rules.txt"), []byte("gmail.com,googlemail.com|+|ignore-dots\n"), 0644); err != nil {
+		t.Fatalf("Failed to write provider-rules.txt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "patterns.txt"), []byte("*.Temp-Mail.*\n!temp-mail.gov*\n"), 0644); err != nil {
+		t.Fatalf("Failed to write patterns.txt: %v", err)
+	}
+
+	if err := run(options{OutputDir: tmpDir, SourcesFile: sourcesPath, Timeout: 10 * time.Second}); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "data.v2.bin"))
+	if err != nil {
+		t.Fatalf("Failed to read data.v2.bin: %v", err)
+	}
+	_, _, df, err := trie.Deserialize(data)
+	if err != nil {
+		t.Fatalf("Failed to deserialize data.v2.bin: %v", err)
+	}
+
+	// The digest is published next to data.v2.bin
+	checksum, err := os.ReadFile(filepath.Join(tmpDir, "data.v2.bin.sha256"))
+	if err != nil {
+		t.Fatalf("Failed to read data.v2.bin.sha256: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	if want := hex.EncodeToString(sum[:]) + "  data.v2.bin\n"; string(checksum) != want {
+		t.Errorf("data.v2.bin.sha256 = %q, want %q", checksum, want)
+	}
+
+	// The same data is published in format 1 for older releases
+	legacy, err := os.ReadFile(filepath.Join(tmpDir, "data.bin"))
+	if err != nil {
+		t.Fatalf("Failed to read data.bin: %v", err)
+	}
+	_, _, legacyFile, err := trie.Deserialize(legacy)
+	if err != nil {
+		t.Fatalf("Failed to deserialize data.bin: %v", err)
+	}
+	if legacyFile.Version != trie.LegacyFormatVersion || !reflect.DeepEqual(legacyFile.Blocklist, df.Blocklist) || !reflect.DeepEqual(legacyFile.Providers, df.Providers) {
+		t.Errorf("data.bin = %+v, want %+v in format %s", legacyFile, df, trie.LegacyFormatVersion)
+	}
+	checksum, err = os.ReadFile(filepath.Join(tmpDir, "data.bin.sha256"))
+	if err != nil {
+		t.Fatalf("Failed to read data.bin.sha256: %v", err)
+	}
+	sum = sha256.Sum256(legacy)
+	if want := hex.EncodeToString(sum[:]) + "  data.bin\n"; string(checksum) != want {
+		t.Errorf("data.bin.sha256 = %q, want %q", checksum, want)
+	}
+
+	// Normalized, deduplicated and in file order
+	expected := []string{"gmail.com", "yahoo.com", "hotmail.com"}
+	if !reflect.DeepEqual(df.Providers, expected) {
+		t.Errorf("Providers = %v, want %v", df.Providers, expected)
+	}
+	expectedRules := []trie.ProviderRule{{Domains: []string{"gmail.com", "googlemail.com"}, Separators: "+", IgnoreDots: true}}
+	if !reflect.DeepEqual(df.ProviderRules, expectedRules) {
+		t.Errorf("ProviderRules = %+v, want %+v", df.ProviderRules, expectedRules)
+	}
+	if !reflect.DeepEqual(df.BlockPatterns, []string{"temp-mail.*"}) || !reflect.DeepEqual(df.AllowPatterns, []string{"temp-mail.gov*"}) {
+		t.Errorf("patterns = %v and %v, want [temp-mail.*] and [temp-mail.gov*]", df.BlockPatterns, df.AllowPatterns)
+	}
+}
+
+func TestWriteReleaseDelta(t *testing.T) {
+	list := "tempmail.com\nold.test\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(list))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	sourcesPath := filepath.Join(tmpDir, "sources.txt")
+	if err := os.WriteFile(sourcesPath, []byte("blocklist|Test|"+server.URL+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sources.txt: %v", err)
+	}
+	dataPath := filepath.Join(tmpDir, "data.v2.bin")
+	deltaPath := dataPath + ".delta"
+	opts := options{OutputDir: tmpDir, SourcesFile: sourcesPath, Timeout: 10 * time.Second}
+
+	// The data of the previous release, kept apart from the output like a
+	// downloaded release asset
+	if err := run(opts); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+	base, err := os.ReadFile(dataPath)
+	if err != nil {
+		t.Fatalf("Failed to read data.v2.bin: %v", err)
+	}
+	basePath := filepath.Join(t.TempDir(), "data.v2.bin")
+	if err := os.WriteFile(basePath, base, 0644); err != nil {
+		t.Fatalf("Failed to write base data: %v", err)
+	}
+	_, _, baseFile, err := trie.Deserialize(base)
+	if err != nil {
+		t.Fatalf("Failed to deserialize data.v2.bin: %v", err)
+	}
+
+	// Daily updates in between don't write deltas
+	list = "tempmail.com\nbetween.test\n"
+	if err := run(opts); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+	list = "tempmail.com\nnew.test\n"
+	if err := run(opts); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+	if _, err := os.Stat(deltaPath); !os.IsNotExist(err) {
+		t.Errorf("Expected run() not to write data.v2.bin.delta, got %v", err)
+	}
+
+	if err := writeReleaseDelta(basePath, dataPath, trie.DefaultCompressionLevel); err != nil {
+		t.Fatalf("writeReleaseDelta() error: %v", err)
+	}
+
+	deltaData, err := os.ReadFile(deltaPath)
+	if err != nil {
+		t.Fatalf("Failed to read data.v2.bin.delta: %v", err)
+	}
+	checksum, err := os.ReadFile(deltaPath + ".sha256")
+	if err != nil {
+		t.Fatalf("Failed to read data.v2.bin.delta.sha256: %v", err)
+	}
+	sum := sha256.Sum256(deltaData)
+	if want := hex.EncodeToString(sum[:]) + "  data.v2.bin.delta\n"; string(checksum) != want {
+		t.Errorf("data.v2.bin.delta.sha256 = %q, want %q", checksum, want)
+	}
+
+	// The delta leads from the previous release, not from the last update
+	delta, err := trie.DeserializeDelta(deltaData)
+	if err != nil {
+		t.Fatalf("Failed to deserialize data.v2.bin.delta: %v", err)
+	}
+	if !reflect.DeepEqual(delta.AddedBlocklist, []string{"new.test"}) || !reflect.DeepEqual(delta.RemovedBlocklist, []string{"old.test"}) {
+		t.Errorf("delta added %v and removed %v, want [new.test] and [old.test]", delta.AddedBlocklist, delta.RemovedBlocklist)
+	}
+
+	// Applied to the previous release's data, the delta gives the new data
+	patched, err := delta.Apply(baseFile)
+	if err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	data, err := os.ReadFile(dataPath)
+	if err != nil {
+		t.Fatalf("Failed to read data.v2.bin: %v", err)
+	}
+	_, _, df, err := trie.Deserialize(data)
+	if err != nil {
+		t.Fatalf("Failed to deserialize data.v2.bin: %v", err)
+	}
+	if !reflect.DeepEqual(patched.Blocklist, df.Blocklist) || !patched.CreatedAt.Equal(df.CreatedAt) {
+		t.Errorf("Apply() = %+v, want %+v", patched, df)
+	}
+
+	// The previous release may only have the data in format 1
+	legacyPath := filepath.Join(t.TempDir(), "data.bin")
+	legacy, err := trie.SerializeDataFileV1(baseFile, trie.DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDataFileV1() error: %v", err)
+	}
+	if err := os.WriteFile(legacyPath, legacy, 0644); err != nil {
+		t.Fatalf("Failed to write data.bin: %v", err)
+	}
+	if err := writeReleaseDelta(legacyPath, dataPath, trie.DefaultCompressionLevel); err != nil {
+		t.Fatalf("writeReleaseDelta(format 1 base) error: %v", err)
+	}
+	fromLegacy, err := os.ReadFile(deltaPath)
+	if err != nil {
+		t.Fatalf("Failed to read data.v2.bin.delta: %v", err)
+	}
+	if !bytes.Equal(fromLegacy, deltaData) {
+		t.Error("Expected the delta from a format 1 base to match the one from the same data in format 2")
+	}
+
+	if err := writeReleaseDelta(filepath.Join(tmpDir, "missing.bin"), dataPath, trie.DefaultCompressionLevel); err == nil {
+		t.Error("writeReleaseDelta() with a missing base succeeded, want error")
+	}
+}
+
+func TestLoadPatternsFromFile(t *testing.T) {
+	content := `# Patterns
+*.temp-mail.*
+mail-temp-*.com
+
+! temp-mail.gov*
+temp-mail.*
+`
+	path := filepath.Join(t.TempDir(), "patterns.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write patterns.txt: %v", err)
+	}
+
+	block, allow, err := LoadPatternsFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadPatternsFromFile error: %v", err)
+	}
+
+	// The leading "*." is dropped, so the last line is a duplicate
+	if expected := []string{"temp-mail.*", "mail-temp-*.com"}; !reflect.DeepEqual(block, expected) {
+		t.Errorf("block = %v, want %v", block, expected)
+	}
+	if expected := []string{"temp-mail.gov*"}; !reflect.DeepEqual(allow, expected) {
+		t.Errorf("allow = %v, want %v", allow, expected)
+	}
+}
+
+func TestLoadPatternsFromFileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no wildcard", "tempmail.com\n", "invalid pattern at line 1"},
+		{"matches everything", "# all\n*.*\n", "invalid pattern at line 2"},
+		{"invalid character", "!temp mail.*\n", "invalid pattern at line 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "patterns.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write patterns.txt: %v", err)
+			}
+			_, _, err := LoadPatternsFromFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadPatternsFromFile() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProviderRulesFromFile(t *testing.T) {
+	content := `# Provider rules
+Gmail.com, googlemail.com|+|ignore-dots
+
+yahoo.com|-|
+ya.ru,yandex.ru||
+`
+	path := filepath.Join(t.TempDir(), "provider-rules.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write provider-rules.txt: %v", err)
+	}
+
+	rules, err := LoadProviderRulesFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadProviderRulesFromFile error: %v", err)
+	}
+
+	expected := []trie.ProviderRule{
+		{Domains: []string{"gmail.com", "googlemail.com"}, Separators: "+", IgnoreDots: true},
+		{Domains: []string{"yahoo.com"}, Separators: "-"},
+		{Domains: []string{"ya.ru", "yandex.ru"}},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("rules = %+v, want %+v", rules, expected)
+	}
+}
+
+func TestLoadProviderRulesFromFileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"missing fields", "gmail.com|+\n", "invalid format at line 1"},
+		{"invalid domain", "gmail.com,bad..com|+|\n", "invalid domain at line 1"},
+		{"duplicate domain", "gmail.com|+|\n\ngmail.com|-|\n", "already listed at line 1"},
+		{"invalid separator", "gmail.com|.|\n", "invalid separators at line 1"},
+		{"unknown flag", "gmail.com|+|ignore-case\n", "unknown flag at line 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "provider-rules.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write provider-rules.txt: %v", err)
+			}
+			_, err := LoadProviderRulesFromFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadProviderRulesFromFile() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSourceCounts(t *testing.T) {
+	serve := func(body string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	first := serve("tempmail.com\nonly-first.com\ntempmail.com\n")
+	second := serve("TEMPMAIL.COM\nonly-second.com\n")
+	third := serve("tempmail.com\nonly-second.com\n")
+
+	tmpDir := t.TempDir()
+	sourcesPath := filepath.Join(tmpDir, "sources.txt")
+	sources := "blocklist|First|" + first.URL + "\n" +
+		"blocklist|Second|" + second.URL + "\n" +
+		"blocklist|Third|" + third.URL + "\n"
+	if err := os.WriteFile(sourcesPath, []byte(sources), 0644); err != nil {
+		t.Fatalf("Failed to write sources.txt: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		counts bool
+		want   map[string]int
+	}{
+		{"with counts", true, map[string]int{"tempmail.com": 3, "only-first.com": 1, "only-second.com": 2}},
+		{"without counts", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := t.TempDir()
+			opts := options{OutputDir: outputDir, SourcesFile: sourcesPath, Timeout: 10 * time.Second,
+				Level: trie.DefaultCompressionLevel, Counts: tt.counts}
+			if err := run(opts); err != nil {
+				t.Fatalf("run() error: %v", err)
+			}
+
+			blocklist, _, dataFile, err := loadDataFile(filepath.Join(outputDir, "data.v2.bin"), nil)
+			if err != nil {
+				t.Fatalf("loadDataFile() error: %v", err)
+			}
+			if blocklist.Size() != 3 {
+				t.Errorf("Expected 3 blocklist domains, got %d", blocklist.Size())
+			}
+			if !reflect.DeepEqual(dataFile.SourceCounts, tt.want) {
+				t.Errorf("SourceCounts = %v, want %v", dataFile.SourceCounts, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveAllowlisted(t *testing.T) {
+	allowlist := map[string]struct{}{"example.com": {}, "mail.other.org": {}}
+
+	tests := []struct {
+		name         string
+		hierarchical bool
+		want         []string
+	}{
+		{"exact", false, []string{"deep.promo.example.com", "other.org", "promo.example.com", "x.mail.other.org", "xexample.com"}},
+		{"hierarchical", true, []string{"other.org", "xexample.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocklist := map[string]int{
+				"example.com":            1,
+				"promo.example.com":      1,
+				"deep.promo.example.com": 1,
+				"xexample.com":           1,
+				"other.org":              1,
+				"x.mail.other.org":       1,
+			}
+			removeAllowlisted(blocklist, allowlist, tt.hierarchical)
+
+			var got []string
+			for domain := range blocklist {
+				got = append(got, domain)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("removeAllowlisted(hierarchical=%v) left %v, want %v", tt.hierarchical, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunHierarchicalAllowlist(t *testing.T) {
+	block := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("example.com\npromo.example.com\ntempmail.com\n"))
+	}))
+	defer block.Close()
+	allow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("example.com\n"))
+	}))
+	defer allow.Close()
+
+	sourcesPath := filepath.Join(t.TempDir(), "sources.txt")
+	sources := "blocklist|Block|" + block.URL + "\nallowlist|Allow|" + allow.URL + "\n"
+	if err := os.WriteFile(sourcesPath, []byte(sources), 0644); err != nil {
+		t.Fatalf("Failed to write sources.txt: %v", err)
+	}
+
+	for _, hierarchical := range []bool{false, true} {
+		outputDir := t.TempDir()
+		opts := options{OutputDir: outputDir, SourcesFile: sourcesPath, Timeout: 10 * time.Second,
+			Level: trie.DefaultCompressionLevel, HierarchicalAllowlist: hierarchical}
+		if err := run(opts); err != nil {
+			t.Fatalf("run() error: %v", err)
+		}
+
+		blocklist, _, _, err := loadDataFile(filepath.Join(outputDir, "data.v2.bin"), nil)
+		if err != nil {
+			t.Fatalf("loadDataFile() error: %v", err)
+		}
+		if blocklist.Contains("example.com") {
+			t.Errorf("hierarchical=%v: expected allowlisted example.com to be removed", hierarchical)
+		}
+		if got := blocklist.Contains("promo.example.com"); got == hierarchical {
+			t.Errorf("hierarchical=%v: promo.example.com in blocklist = %v", hierarchical, got)
+		}
+		if !blocklist.Contains("tempmail.com") {
+			t.Errorf("hierarchical=%v: expected tempmail.com to stay blocked", hierarchical)
+		}
+	}
+}
+
+func TestRunReusesConnections(t *testing.T) {
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s.example\n", strings.Trim(r.URL.Path, "/"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	sourcesPath := filepath.Join(t.TempDir(), "sources.txt")
+	sources := "blocklist|A|" + server.URL + "/a\n" +
+		"blocklist|B|" + server.URL + "/b\n" +
+		"allowlist|C|" + server.URL + "/c\n"
+	if err := os.WriteFile(sourcesPath, []byte(sources), 0644); err != nil {
+		t.Fatalf("Failed to write sources.txt: %v", err)
+	}
+
+	opts := options{OutputDir: t.TempDir(), SourcesFile: sourcesPath, Timeout: 10 * time.Second, Level: trie.DefaultCompressionLevel}
+	if err := run(opts); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+
+	if got := conns.Load(); got != 1 {
+		t.Errorf("connections = %d, want 1 reused for all sources on the host", got)
+	}
+}
+
+func TestRunLowMem(t *testing.T) {
+	serve := func(body string) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return server.URL
+	}
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	sourcesDir := t.TempDir()
+	sourcesPath := filepath.Join(sourcesDir, "sources.txt")
+	sources := "blocklist|First|" + serve("# comment\ntempmail.com\nTempMail.com\n*.wild.com\nnot a domain\nexample.com\npromo.example.com\n") + "\n" +
+		"blocklist|Second|" + serve("tempmail.com\nyopmail.com\n") + "\n" +
+		"blocklist|Empty|" + serve("# nothing here\n") + "\n" +
+		"blocklist|Failing|" + failing.URL + "\n" +
+		"allowlist|Allow|" + serve("example.com\n") + "\n"
+	if err := os.WriteFile(sourcesPath, []byte(sources), 0644); err != nil {
+		t.Fatalf("Failed to write sources.txt: %v", err)
+	}
+
+	build := func(t *testing.T, opts options) (block, allow []string) {
+		t.Helper()
+		opts.OutputDir = t.TempDir()
+		opts.SourcesFile = sourcesPath
+		opts.Timeout = 10 * time.Second
+		opts.Level = trie.DefaultCompressionLevel
+		if err := os.WriteFile(filepath.Join(opts.OutputDir, "manual.txt"), []byte("manual.com\ntempmail.com\n"), 0644); err != nil {
+			t.Fatalf("Failed to write manual.txt: %v", err)
+		}
+		if err := run(opts); err != nil {
+			t.Fatalf("run() error: %v", err)
+		}
+
+		blocklist, allowlist, _, err := loadDataFile(filepath.Join(opts.OutputDir, "data.v2.bin"), nil)
+		if err != nil {
+			t.Fatalf("loadDataFile() error: %v", err)
+		}
+		block, allow = blocklist.GetAll(), allowlist.GetAll()
+		sort.Strings(block)
+		sort.Strings(allow)
+		return block, allow
+	}
+
+	for _, hierarchical := range []bool{false, true} {
+		t.Run(fmt.Sprintf("hierarchical=%v", hierarchical), func(t *testing.T) {
+			wantBlock, wantAllow := build(t, options{HierarchicalAllowlist: hierarchical})
+			gotBlock, gotAllow := build(t, options{HierarchicalAllowlist: hierarchical, LowMem: true})
+
+			if !reflect.DeepEqual(gotBlock, wantBlock) {
+				t.Errorf("low-mem blocklist = %v, want %v", gotBlock, wantBlock)
+			}
+			if !reflect.DeepEqual(gotAllow, wantAllow) {
+				t.Errorf("low-mem allowlist = %v, want %v", gotAllow, wantAllow)
+			}
+			if slices.Contains(gotBlock, "example.com") || !slices.Contains(gotBlock, "manual.com") {
+				t.Errorf("low-mem blocklist = %v, want example.com removed and manual.com added", gotBlock)
+			}
+		})
+	}
+
+	err := run(options{OutputDir: t.TempDir(), SourcesFile: sourcesPath, Level: trie.DefaultCompressionLevel, LowMem: true, Counts: true})
+	if err == nil {
+		t.Error("Expected error for -low-mem with -counts")
 	}
 }
 
 func TestGeneratedDataBinIsReadable(t *testing.T) {
-	// This test verifies that data/data.bin (if it exists) is readable
-	dataPath := filepath.Join("..", "..", "data", "data.bin")
+	// This test verifies that data/data.v2.bin (if it exists) is readable
+	dataPath := filepath.Join("..", "..", "data", "data.v2.bin")
 	if _, err := os.Stat(dataPath); os.IsNotExist(err) {
-		t.Skip("data/data.bin not found")
+		t.Skip("data/data.v2.bin not found")
 	}
 
 	data, err := os.ReadFile(dataPath)
 	if err != nil {
-		t.Fatalf("Failed to read data.bin: %v", err)
+		t.Fatalf("Failed to read data.v2.bin: %v", err)
 	}
 
 	blocklist, allowlist, dataFile, err := trie.Deserialize(data)
 	if err != nil {
-		t.Fatalf("Failed to deserialize data.bin: %v", err)
+		t.Fatalf("Failed to deserialize data.v2.bin: %v", err)
 	}
 
 	// Verify data is valid
@@ -287,7 +964,7 @@ func TestGeneratedDataBinIsReadable(t *testing.T) {
 		t.Error("Version should not be empty")
 	}
 
-	t.Logf("data.bin stats: blocklist=%d, allowlist=%d, version=%s",
+	t.Logf("data.v2.bin stats: blocklist=%d, allowlist=%d, version=%s",
 		blocklist.Size(), allowlist.Size(), dataFile.Version)
 
 	// Verify known disposable domains are present
@@ -324,11 +1001,7 @@ func TestWriteTextList(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	domains := map[string]struct{}{
-		"alpha.com": {},
-		"beta.com":  {},
-		"gamma.com": {},
-	}
+	domains := []string{"gamma.com", "alpha.com", "beta.com"}
 
 	outPath := filepath.Join(tmpDir, "test.txt")
 	if err := writeTextList(outPath, domains); err != nil {
@@ -349,7 +1022,7 @@ func TestWriteTextList(t *testing.T) {
 	}
 
 	// Should have all domains
-	for domain := range domains {
+	for _, domain := range domains {
 		if !strings.Contains(contentStr, domain) {
 			t.Errorf("Expected %s in output", domain)
 		}
@@ -378,3 +1051,69 @@ func TestSourcesFileLocation(t *testing.T) {
 		t.Logf("  - %s (%v)", src.Name, src.Type)
 	}
 }
+
+func TestLoadDataFileFromStdin(t *testing.T) {
+	blocklist := trie.New()
+	blocklist.Insert("tempmail.com")
+	allowlist := trie.New()
+	allowlist.Insert("gmail.com")
+
+	data, err := trie.Serialize(blocklist, allowlist, trie.DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := validateData("-", bytes.NewReader(data), &out); err != nil {
+		t.Fatalf("validateData(-) error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Blocklist domains: 1") {
+		t.Errorf("Unexpected validate output: %q", out.String())
+	}
+
+	out.Reset()
+	err = checkDomains("-", []string{"user@mail.tempmail.com", "gmail.com"}, bytes.NewReader(data), &out)
+	if err != nil {
+		t.Fatalf("checkDomains(-) error: %v", err)
+	}
+
+	expected := "mail.tempmail.com: disposable\ngmail.com: ok\n"
+	if out.String() != expected {
+		t.Errorf("checkDomains output = %q, want %q", out.String(), expected)
+	}
+
+	// Invalid data on stdin should be reported
+	if err := validateData("-", strings.NewReader("not a data file"), &out); err == nil {
+		t.Error("Expected error for invalid data on stdin")
+	}
+}
+
+func TestWriteFlat(t *testing.T) {
+	blocklist := trie.New()
+	blocklist.Insert("tempmail.com")
+	allowlist := trie.New()
+	allowlist.Insert("gmail.com")
+
+	data, err := trie.Serialize(blocklist, allowlist, trie.DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "data.flat")
+	if err := writeFlat("-", path, bytes.NewReader(data)); err != nil {
+		t.Fatalf("writeFlat(-) error: %v", err)
+	}
+
+	ff, err := trie.OpenFlat(path)
+	if err != nil {
+		t.Fatalf("OpenFlat() error: %v", err)
+	}
+	flatBlock, flatAllow := ff.NewTries()
+	if !flatBlock.ContainsHierarchical("mail.tempmail.com") || !flatAllow.Contains("gmail.com") {
+		t.Errorf("Flat file lists = %v, %v", flatBlock.GetAll(), flatAllow.GetAll())
+	}
+
+	if err := writeFlat("-", path, strings.NewReader("not a data file")); err == nil {
+		t.Error("Expected error for invalid data on stdin")
+	}
+}
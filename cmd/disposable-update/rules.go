@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
+	"github.com/rezmoss/go-is-disposable-email/internal/wildcard"
+)
+
+// LoadProviderRulesFromFile reads provider canonicalization rules from a
+// text file.
+// Format: domains|separators|flags
+// domains is a comma-separated list whose first entry is the main domain,
+// and flags a comma-separated list that may contain "ignore-dots".
+// Lines starting with # are comments, empty lines are ignored.
+func LoadProviderRulesFromFile(path string) ([]trie.ProviderRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open provider rules file: %w", err)
+	}
+	defer f.Close()
+
+	var rules []trie.ProviderRule
+	seen := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, "|")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid format at line %d: expected 'domains|separators|flags', got %q", lineNum, line)
+		}
+
+		var rule trie.ProviderRule
+		for domain := range strings.SplitSeq(parts[0], ",") {
+			domain = normalizeDomain(domain)
+			if !isValidDomain(domain) {
+				return nil, fmt.Errorf("invalid domain at line %d: %q", lineNum, domain)
+			}
+			if prev, ok := seen[domain]; ok {
+				return nil, fmt.Errorf("duplicate domain at line %d: %s is already listed at line %d", lineNum, domain, prev)
+			}
+			seen[domain] = lineNum
+			rule.Domains = append(rule.Domains, domain)
+		}
+
+		rule.Separators = strings.TrimSpace(parts[1])
+		if strings.ContainsAny(rule.Separators, "@\". ") {
+			return nil, fmt.Errorf("invalid separators at line %d: %q", lineNum, rule.Separators)
+		}
+
+		for flag := range strings.SplitSeq(parts[2], ",") {
+			switch flag = strings.TrimSpace(strings.ToLower(flag)); flag {
+			case "":
+			case "ignore-dots":
+				rule.IgnoreDots = true
+			default:
+				return nil, fmt.Errorf("unknown flag at line %d: %q", lineNum, flag)
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading provider rules file: %w", err)
+	}
+
+	return rules, nil
+}
+
+// LoadPatternsFromFile reads wildcard patterns such as "*.temp-mail.*" from
+// a text file, one per line. Patterns prefixed with "!" are allowlist
+// patterns, the others blocklist patterns.
+// Lines starting with # are comments, empty lines are ignored.
+func LoadPatternsFromFile(path string) (block, allow []string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open patterns file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		target := &block
+		if pattern, ok := strings.CutPrefix(line, "!"); ok {
+			line, target = strings.TrimSpace(pattern), &allow
+		}
+
+		p, err := wildcard.Compile(strings.ToLower(line))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pattern at line %d: %q", lineNum, line)
+		}
+		if !slices.Contains(*target, p.String()) {
+			*target = append(*target, p.String())
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error reading patterns file: %w", err)
+	}
+
+	return block, allow, nil
+}
@@ -1,20 +1,38 @@
 package disposable
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/rezmoss/go-is-disposable-email/data"
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
 )
 
+// DataFile is the decoded contents of a data.bin file, as passed to a
+// validator set with WithDownloadValidator.
+type DataFile = trie.DataFile
+
+// ProviderRule describes how a mail provider canonicalizes addresses, as
+// applied by NormalizeEmail. Data files carry a table of them.
+type ProviderRule = trie.ProviderRule
+
 // Mode determines how the checker operates regarding network access.
 type Mode int
 
 const (
 	// ModeOnline auto-downloads fresh data on first use, caches locally.
-	// This is the only supported mode - data.bin is always external.
 	ModeOnline Mode = iota
+	// ModeEmbedded starts from the data.bin snapshot compiled into the
+	// library, without any network or filesystem access. Refresh and
+	// WithAutoRefresh still download newer data, which is kept in memory.
+	ModeEmbedded
 )
 
 // String returns the string representation of the Mode.
@@ -22,11 +40,42 @@ func (m Mode) String() string {
 	switch m {
 	case ModeOnline:
 		return "online"
+	case ModeEmbedded:
+		return "embedded"
 	default:
 		return "unknown"
 	}
 }
 
+// AllowlistMode determines how custom allowlist entries combine with the
+// allowlist from the data.
+type AllowlistMode int
+
+const (
+	// AllowlistMerge adds custom allowlist entries to the data's allowlist.
+	AllowlistMerge AllowlistMode = iota
+	// AllowlistReplace ignores the data's allowlist; only custom allowlist
+	// entries, overrides and runtime additions are allowed.
+	AllowlistReplace
+)
+
+// String returns the string representation of the AllowlistMode.
+func (m AllowlistMode) String() string {
+	switch m {
+	case AllowlistMerge:
+		return "merge"
+	case AllowlistReplace:
+		return "replace"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON encodes the Mode as its string representation.
+func (m Mode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
 // Logger is the interface for logging operations.
 type Logger interface {
 	Printf(format string, v ...any)
@@ -43,25 +92,181 @@ type Config struct {
 	// RefreshInterval sets how often to auto-refresh. Default: 24h
 	RefreshInterval time.Duration
 
-	// CacheDir specifies where to cache downloaded data.
-	// Default: os.UserCacheDir()/disposable
+	// RefreshJitter is the maximum random delay added to each auto-refresh
+	// interval, so that many instances don't download at the same time.
+	// Default: 0
+	RefreshJitter time.Duration
+
+	// RandSource is the source of randomness, such as refresh jitter.
+	// Default: seeded from the current time
+	RandSource rand.Source
+
+	// CacheDir specifies where to cache downloaded data. If the default
+	// isn't writable, data is kept in memory only, as with NoCacheWrite.
+	// Default: os.UserCacheDir()/disposable-email, or os.TempDir()/disposable-email
 	CacheDir string
 
+	// CacheFileName is the name of the data file within CacheDir.
+	// Default: data.bin
+	CacheFileName string
+
 	// HTTPTimeout for download operations. Default: 30s
 	HTTPTimeout time.Duration
 
-	// CustomBlocklist adds extra domains to block at initialization.
+	// HTTPTransport performs all downloads of a Checker, reusing its
+	// connections. Default: http.DefaultTransport
+	HTTPTransport http.RoundTripper
+
+	// CustomBlocklist adds extra domains or wildcard patterns such as
+	// "*.temp-mail.*" to block at initialization.
 	CustomBlocklist []string
 
-	// CustomAllowlist adds extra domains to allow at initialization.
+	// CustomAllowlist adds extra domains or wildcard patterns to allow at
+	// initialization.
 	CustomAllowlist []string
 
+	// OverridesFile is a file of block/allow directives applied after every
+	// load and refresh. Each line is a domain or wildcard pattern to block,
+	// or one prefixed with "!" to allow. Default: <CacheDir>/overrides.txt
+	OverridesFile string
+
+	// ConcurrentInit makes New return as soon as cached data is loaded and
+	// refresh it in the background. Default: false
+	ConcurrentInit bool
+
+	// SubaddressSeparators are the characters that start a subaddress tag in
+	// the local part, e.g. "+" in "user+tag@example.com". Used by
+	// CanonicalEmail only. Default: '+'
+	SubaddressSeparators []rune
+
+	// RoleAccounts are the lowercase local parts IsRoleAddress reports as
+	// role accounts. Default: a built-in list such as "admin" and "noreply"
+	RoleAccounts []string
+
+	// ShutdownTimeout limits how long Close waits for background work to
+	// stop. Zero means wait indefinitely. Default: 0
+	ShutdownTimeout time.Duration
+
+	// SoftTTL is the data age, measured from its creation time, after which
+	// a lookup starts a background refresh while the current data keeps
+	// being served. Zero disables it. Default: 0
+	SoftTTL time.Duration
+
+	// HardTTL is the data age after which Healthy and CheckDomain report
+	// ErrDataExpired. Zero disables it. Default: 0
+	HardTTL time.Duration
+
+	// HeuristicPatterns are lowercase substrings that mark a domain as
+	// disposable even when it isn't listed. Default: none
+	HeuristicPatterns []string
+
+	// BlockPatterns are regular expressions that mark a domain as
+	// disposable when it isn't listed, at most MaxBlockPatterns.
+	// Default: none
+	BlockPatterns []string
+
+	// BlockIPLiterals reports IP addresses in place of a domain, such as
+	// "user@[192.168.0.1]", as disposable. Default: false
+	BlockIPLiterals bool
+
+	// BlockReservedDomains reports domains reserved for testing, such as
+	// "example.com" or "localhost", as disposable. Default: false
+	BlockReservedDomains bool
+
+	// AllowlistMode controls whether the data's allowlist is used alongside
+	// custom allowlist entries. Default: AllowlistMerge
+	AllowlistMode AllowlistMode
+
+	// DNSCheck makes Check also report whether the domain resolves.
+	// Default: false
+	DNSCheck bool
+
+	// DNSResolver is used for DNS checks. Default: net.DefaultResolver
+	DNSResolver DNSResolver
+
+	// SMTPProbe makes Check also ask the domain's mail servers whether the
+	// mailbox exists. Default: false
+	SMTPProbe bool
+
+	// SMTPFrom is the sender used for SMTP probes; empty sends the null
+	// sender "<>". Default: ""
+	SMTPFrom string
+
+	// SMTPTimeout limits each connection to a mail server. Default: 10s
+	SMTPTimeout time.Duration
+
+	// OnDisposable is called after IsDisposable reports a domain as
+	// disposable, with the normalized domain and the entry that matched.
+	// Default: nil
+	OnDisposable func(domain, matched string)
+
+	// DownloadValidator is called with every downloaded or LoadBytes data
+	// file before it replaces the current data. A non-nil error rejects it.
+	DownloadValidator func(*DataFile) error
+
+	// NoCacheWrite disables all writes to the cache directory. Default: false
+	NoCacheWrite bool
+
+	// LazyTLDLoading splits the data by TLD on disk and loads each TLD only
+	// when a domain under it is first looked up. Default: false
+	LazyTLDLoading bool
+
+	// StrictValidation verifies the internal consistency of the tries after
+	// every load, rejecting data that fails the check. Default: false
+	StrictValidation bool
+
 	// Logger for diagnostic output. Default: discards logs
 	Logger Logger
 
+	// EmbeddedData is the contents of a data.bin file shipped with the
+	// application. It is used instead of older cached data and when there is
+	// no cache and the download fails. Default: nil
+	EmbeddedData []byte
+
+	// MmapData is the path of a data file in the flat format, see
+	// ConvertToFlat, that is memory-mapped and queried in place instead of
+	// being decoded into tries. Default: none
+	MmapData string
+
 	// DataURL is the URL to download data.bin from for updates.
 	// Default: GitHub releases URL
 	DataURL string
+
+	// ChecksumURL is the URL of the SHA-256 digest of the data at DataURL,
+	// in sha256sum format. Downloads that don't match it are rejected.
+	// Default: DataURL plus ".sha256" for the default DataURL, none for
+	// custom ones
+	ChecksumURL string
+
+	// AllowMissingChecksum accepts downloads unverified when ChecksumURL
+	// returns 404 Not Found, instead of rejecting them. Default: false
+	AllowMissingChecksum bool
+
+	// DeltaURL is the URL of the delta that updates the previous release of
+	// the data at DataURL to the current one, tried by Refresh before
+	// downloading the full data. Verified against DeltaURL plus ".sha256"
+	// when ChecksumURL is set. Default: DataURL plus ".delta" for the
+	// default DataURL, none for custom ones
+	DeltaURL string
+
+	// TimeSource returns the current time for all time-dependent logic,
+	// such as staleness checks and the creation time of data built by
+	// BuildDataFile. Default: time.Now
+	TimeSource func() time.Time
+}
+
+// defaultSubaddressSeparators is the default for Config.SubaddressSeparators.
+var defaultSubaddressSeparators = []rune{'+'}
+
+// defaultRoleAccounts is the default for Config.RoleAccounts: local parts
+// that usually reach a team, a list or nobody rather than one person.
+var defaultRoleAccounts = []string{
+	"abuse", "admin", "administrator", "billing", "careers", "contact",
+	"do-not-reply", "donotreply", "help", "hostmaster", "hr", "info",
+	"jobs", "mail", "mailer-daemon", "marketing", "media", "news",
+	"newsletter", "no-reply", "noc", "noreply", "office", "postmaster",
+	"press", "privacy", "root", "sales", "security", "support", "sysadmin",
+	"team", "webmaster",
 }
 
 // DefaultConfig returns the default configuration.
@@ -71,12 +276,67 @@ func DefaultConfig() *Config {
 		AutoRefresh:     false,
 		RefreshInterval: 24 * time.Hour,
 		CacheDir:        "",
+		CacheFileName:   data.DataFileName,
 		HTTPTimeout:     30 * time.Second,
 		CustomBlocklist: nil,
 		CustomAllowlist: nil,
 		Logger:          log.New(io.Discard, "", 0),
 		DataURL:         data.DefaultDataURL,
+		TimeSource:      time.Now,
+		RandSource:      rand.NewPCG(uint64(time.Now().UnixNano()), 0),
+
+		SubaddressSeparators: defaultSubaddressSeparators,
+		RoleAccounts:         defaultRoleAccounts,
+	}
+}
+
+// validate reports options that conflict with each other or are out of
+// range, all at once. The rules are:
+//   - Mode and AllowlistMode must be known modes.
+//   - HTTPTimeout, SoftTTL, HardTTL, RefreshJitter and SMTPTimeout must not
+//     be negative.
+//   - HardTTL must be longer than SoftTTL, or the data expires before a
+//     background refresh is even attempted.
+//   - With AutoRefresh, HardTTL must be longer than RefreshInterval plus
+//     RefreshJitter, or the data expires between scheduled refreshes.
+func (c *Config) validate() error {
+	var errs []error
+
+	if c.Mode.String() == "unknown" {
+		errs = append(errs, fmt.Errorf("unknown mode %d", int(c.Mode)))
+	}
+	if c.AllowlistMode.String() == "unknown" {
+		errs = append(errs, fmt.Errorf("unknown allowlist mode %d", int(c.AllowlistMode)))
+	}
+
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HTTP timeout", c.HTTPTimeout},
+		{"soft TTL", c.SoftTTL},
+		{"hard TTL", c.HardTTL},
+		{"refresh jitter", c.RefreshJitter},
+		{"SMTP timeout", c.SMTPTimeout},
+	} {
+		if d.value < 0 {
+			errs = append(errs, fmt.Errorf("%s %v is negative", d.name, d.value))
+		}
 	}
+
+	if len(c.BlockPatterns) > MaxBlockPatterns {
+		errs = append(errs, fmt.Errorf("%d block patterns exceed the limit of %d", len(c.BlockPatterns), MaxBlockPatterns))
+	}
+
+	if c.HardTTL > 0 && c.SoftTTL > 0 && c.HardTTL <= c.SoftTTL {
+		errs = append(errs, fmt.Errorf("hard TTL %v must be longer than soft TTL %v", c.HardTTL, c.SoftTTL))
+	}
+	if c.HardTTL > 0 && c.AutoRefresh && c.HardTTL <= c.RefreshInterval+c.RefreshJitter {
+		errs = append(errs, fmt.Errorf("hard TTL %v must be longer than the refresh interval %v plus jitter %v",
+			c.HardTTL, c.RefreshInterval, c.RefreshJitter))
+	}
+
+	return errors.Join(errs...)
 }
 
 // Option configures a Checker.
@@ -89,6 +349,26 @@ func WithMode(mode Mode) Option {
 	}
 }
 
+// WithRefreshJitter adds a random delay of up to max to each auto-refresh
+// interval, spreading the downloads of many instances started together.
+func WithRefreshJitter(max time.Duration) Option {
+	return func(c *Config) {
+		c.RefreshJitter = max
+	}
+}
+
+// WithRandSource sets the source of randomness used for refresh jitter and
+// any other randomized behavior. A fixed seed, e.g. rand.NewPCG(1, 2), makes
+// that behavior reproducible in tests and deployments. A nil source keeps the
+// default, which is seeded from the current time.
+func WithRandSource(src rand.Source) Option {
+	return func(c *Config) {
+		if src != nil {
+			c.RandSource = src
+		}
+	}
+}
+
 // WithAutoRefresh enables automatic background updates at the specified interval.
 //
 // IMPORTANT: When auto-refresh is enabled, you MUST call Close() on the Checker
@@ -113,13 +393,38 @@ func WithAutoRefresh(interval time.Duration) Option {
 	}
 }
 
-// WithCacheDir sets the cache directory for downloaded data.
+// WithCacheDir sets the cache directory for downloaded data. New fails if dir
+// can't be created; only the default directory falls back to keeping data in
+// memory when it isn't writable.
 func WithCacheDir(dir string) Option {
 	return func(c *Config) {
 		c.CacheDir = dir
 	}
 }
 
+// WithCacheFileName sets the name of the data file within the cache directory.
+// Checkers using different file names can share a cache directory without
+// overwriting each other's data.
+func WithCacheFileName(name string) Option {
+	return func(c *Config) {
+		if name != "" {
+			c.CacheFileName = name
+		}
+	}
+}
+
+// WithHTTPTransport sets the transport used for all downloads, e.g. an
+// *http.Transport with custom proxy or TLS settings. A Checker sends every
+// download through the same client, so connections and TLS sessions are
+// reused between refreshes; HTTPTimeout still limits each download. A nil
+// transport keeps the default, http.DefaultTransport, which uses the proxy
+// from the environment and HTTP/2 where the server supports it.
+func WithHTTPTransport(rt http.RoundTripper) Option {
+	return func(c *Config) {
+		c.HTTPTransport = rt
+	}
+}
+
 // WithHTTPTimeout sets the timeout for HTTP operations.
 func WithHTTPTimeout(timeout time.Duration) Option {
 	return func(c *Config) {
@@ -127,20 +432,330 @@ func WithHTTPTimeout(timeout time.Duration) Option {
 	}
 }
 
-// WithCustomBlocklist adds custom domains to block.
+// WithCustomBlocklist adds custom domains to block. Entries may be wildcard
+// patterns, where each "*" matches any run of characters including dots:
+// "*.temp-mail.*" blocks temp-mail under every TLD and "mail-temp-*.com"
+// every numbered variant. Like domains, patterns also match subdomains.
+// Patterns that could match almost anything, such as "*.*", are ignored.
 func WithCustomBlocklist(domains ...string) Option {
 	return func(c *Config) {
 		c.CustomBlocklist = append(c.CustomBlocklist, domains...)
 	}
 }
 
-// WithCustomAllowlist adds custom domains to allow.
+// WithCustomAllowlist adds custom domains to allow. Entries may be wildcard
+// patterns as described for WithCustomBlocklist.
 func WithCustomAllowlist(domains ...string) Option {
 	return func(c *Config) {
 		c.CustomAllowlist = append(c.CustomAllowlist, domains...)
 	}
 }
 
+// WithOverridesFile sets the path of the overrides file. The file is re-read
+// on every refresh, so edits take effect on the next refresh.
+func WithOverridesFile(path string) Option {
+	return func(c *Config) {
+		c.OverridesFile = path
+	}
+}
+
+// WithConcurrentInit makes New return as soon as the cached data is loaded,
+// and download fresh data in the background instead of only when no cache
+// exists. When the download completes, the new data replaces the cached data
+// atomically; until then, lookups use the cached data. A failed background
+// download is logged and the cached data stays in use.
+//
+// If no cache exists, New still downloads synchronously. The background
+// download is cancelled by Close, so Close should be called when using this
+// option.
+func WithConcurrentInit() Option {
+	return func(c *Config) {
+		c.ConcurrentInit = true
+	}
+}
+
+// WithNoCacheWrite keeps downloaded data in memory only: nothing is written
+// to the cache directory, which isn't even created. An existing cache file is
+// still read at startup. Use Checker.PersistCache to write the data on demand.
+// WithLazyTLDLoading has no effect with this option, since it needs to write
+// shards to the cache directory.
+func WithNoCacheWrite() Option {
+	return func(c *Config) {
+		c.NoCacheWrite = true
+	}
+}
+
+// WithLazyTLDLoading reduces memory use by loading the data one top-level
+// domain at a time. Loaded data is split into one shard per TLD in the cache
+// directory, next to the data file, and a TLD's shard is read only when a
+// domain under it is first looked up. Later runs start from the shards without
+// decoding the full data file.
+//
+// The first lookup of each TLD pays the cost of reading its shard from disk
+// and blocks other lookups meanwhile; use Prime to load common TLDs up front.
+// Stats counts the domains of all TLDs, but until a TLD is loaded,
+// GetBlocklist and other listing functions don't include its domains. A newly
+// downloaded data file is decoded in full once to split it, without building
+// the lookup structures for it.
+//
+// Since the shards are written to the cache directory, this option has no
+// effect without cache writes: with WithNoCacheWrite or in ModeEmbedded,
+// including WithStaticData and WithMmapData, all data is loaded up front.
+func WithLazyTLDLoading() Option {
+	return func(c *Config) {
+		c.LazyTLDLoading = true
+	}
+}
+
+// WithStrictValidation enables consistency checks of the loaded tries after
+// every load. Data that fails the checks is rejected with a DeserializationError.
+// This costs a full traversal of the data on each load.
+func WithStrictValidation() Option {
+	return func(c *Config) {
+		c.StrictValidation = true
+	}
+}
+
+// WithDownloadValidator sets a function that checks downloaded data before
+// it is accepted, e.g. that a known domain is present or that the domain
+// count is within an expected range. It runs after the data is decoded and
+// before it is cached or swapped in; a non-nil error aborts the load with a
+// DeserializationError wrapping it, and the current data is kept.
+//
+// The validator also runs for LoadBytes, but not for data loaded from the
+// cache, which was validated when it was downloaded.
+func WithDownloadValidator(validate func(*DataFile) error) Option {
+	return func(c *Config) {
+		c.DownloadValidator = validate
+	}
+}
+
+// WithSubaddressSeparators sets the characters CanonicalEmail treats as the
+// start of a subaddress tag, replacing the default '+'. For example, with
+// '+' and '-', both "user+news@example.com" and "user-news@example.com"
+// canonicalize to "user@example.com". Calling it with no separators disables
+// subaddress stripping. Domain matching is not affected.
+func WithSubaddressSeparators(seps ...rune) Option {
+	return func(c *Config) {
+		c.SubaddressSeparators = seps
+	}
+}
+
+// WithRoleAccounts sets the local parts IsRoleAddress reports as role
+// accounts, replacing the built-in list of names like "admin", "info",
+// "sales" and "noreply". Names are matched case insensitively. Calling it
+// with no names disables role detection.
+func WithRoleAccounts(roles ...string) Option {
+	return func(c *Config) {
+		c.RoleAccounts = []string{}
+		for _, role := range roles {
+			if role = strings.ToLower(strings.TrimSpace(role)); role != "" {
+				c.RoleAccounts = append(c.RoleAccounts, role)
+			}
+		}
+	}
+}
+
+// WithShutdownTimeout limits how long Close waits for the auto-refresh or
+// background refresh goroutine to stop after cancelling it. If it doesn't
+// stop in time, Close returns ErrShutdownTimeout and the goroutine finishes
+// on its own later. A timeout of zero or less waits indefinitely.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.ShutdownTimeout = d
+	}
+}
+
+// WithSoftTTL sets the data age after which lookups trigger a background
+// refresh. Until the refresh succeeds, the current data keeps being served,
+// and failed refreshes are retried at most once a minute. The age is
+// measured from the creation time of the data, as reported by
+// Statistics.LastUpdated.
+func WithSoftTTL(d time.Duration) Option {
+	return func(c *Config) {
+		c.SoftTTL = d
+	}
+}
+
+// WithHardTTL sets the data age after which the data is considered expired:
+// Healthy and CheckDomain return ErrDataExpired until fresh data is loaded.
+// IsDisposable and the other lookups that can't report errors keep
+// answering from the expired data. Combine it with WithSoftTTL or
+// WithAutoRefresh so that data is refreshed before it expires.
+func WithHardTTL(d time.Duration) Option {
+	return func(c *Config) {
+		c.HardTTL = d
+	}
+}
+
+// defaultHeuristicPatterns are used by WithHeuristicPatterns when no
+// patterns are given. They are names of well-known disposable services that
+// look-alike domains tend to reuse.
+var defaultHeuristicPatterns = []string{
+	"10minutemail",
+	"guerrillamail",
+	"mailinator",
+	"temp-mail",
+	"tempmail",
+	"throwaway",
+	"trashmail",
+	"yopmail",
+}
+
+// WithHeuristicPatterns flags domains containing any of the given substrings
+// as disposable, even if they aren't in the data, to catch look-alike domains
+// such as "my-tempmail-xyz.com". Without arguments, a built-in list of
+// well-known disposable service names is used. Patterns are matched case
+// insensitively against the whole domain.
+//
+// Heuristics can cause false positives, so they are off by default. Allowlist
+// entries take precedence, and Classify reports heuristic matches with
+// RuleHeuristic and the matching pattern.
+func WithHeuristicPatterns(patterns ...string) Option {
+	return func(c *Config) {
+		if len(patterns) == 0 {
+			patterns = defaultHeuristicPatterns
+		}
+		c.HeuristicPatterns = nil
+		for _, p := range patterns {
+			if p = NormalizeDomain(p); p != "" {
+				c.HeuristicPatterns = append(c.HeuristicPatterns, p)
+			}
+		}
+	}
+}
+
+// MaxBlockPatterns is the largest number of patterns WithBlockPatterns
+// accepts. Every pattern is tried on each lookup the lists don't decide, so
+// large rule sets belong in the lists instead.
+const MaxBlockPatterns = 100
+
+// WithBlockPatterns flags domains matching any of the given regular
+// expressions as disposable, to catch algorithmically generated domains
+// such as "^[a-z]{2}[0-9]{6}\.(com|net)$" that no list can keep up with.
+// Patterns use RE2 syntax and are matched against the normalized domain,
+// which is lowercase and in punycode, so anchor them with ^ and $ to match
+// whole domains. They are tried in order, only when neither list nor a
+// wildcard pattern matched, before heuristics.
+//
+// Patterns are compiled by New, which fails if any is invalid or if there
+// are more than MaxBlockPatterns. Classify reports matches with
+// RuleBlockPattern and the matching pattern.
+func WithBlockPatterns(patterns ...string) Option {
+	return func(c *Config) {
+		c.BlockPatterns = append(c.BlockPatterns, patterns...)
+	}
+}
+
+// WithBlockIPLiterals makes IsDisposable report addresses whose domain is an
+// IP address, like "user@[192.168.0.1]", "user@[IPv6:2001:db8::1]" or
+// "user@127.0.0.1", as disposable, since such addresses are rarely real
+// mailboxes. Allowlist entries still take precedence.
+func WithBlockIPLiterals() Option {
+	return func(c *Config) {
+		c.BlockIPLiterals = true
+	}
+}
+
+// WithBlockReservedDomains makes IsDisposable report addresses at domains
+// reserved by RFC 2606, like "user@example.com" or "user@localhost", as
+// disposable, since they are placeholders rather than real mailboxes. See
+// IsReservedDomain. Allowlist entries still take precedence.
+func WithBlockReservedDomains() Option {
+	return func(c *Config) {
+		c.BlockReservedDomains = true
+	}
+}
+
+// WithAllowlistMode sets how custom allowlist entries from WithCustomAllowlist,
+// the overrides file and AddAllowlist combine with the data's allowlist.
+// AllowlistMerge, the default, uses both; AllowlistReplace ignores the data's
+// allowlist, so only custom entries are allowed.
+func WithAllowlistMode(mode AllowlistMode) Option {
+	return func(c *Config) {
+		c.AllowlistMode = mode
+	}
+}
+
+// WithDNSCheck makes Check and CheckWithContext also look up whether the
+// domain resolves, reporting it in Result.DNS separately from the list
+// verdict; see LookupDNS. This helps reject misspelled or nonexistent domains,
+// at the cost of a DNS round trip per check. A nil resolver uses
+// net.DefaultResolver.
+func WithDNSCheck(resolver DNSResolver) Option {
+	return func(c *Config) {
+		c.DNSCheck = true
+		c.DNSResolver = resolver
+	}
+}
+
+// WithSMTPProbe makes Check and CheckWithContext also verify that the mailbox
+// of an email address exists by connecting to its mail servers, reporting it
+// in Result.Mailbox separately from the list verdict; see VerifyMailbox. The
+// probe is sent with from as the sender, or the null sender if from is empty,
+// and each connection is limited to timeout, or 10 seconds if it is
+// zero. Probes are slow and often blocked, so this is off by default.
+func WithSMTPProbe(from string, timeout time.Duration) Option {
+	return func(c *Config) {
+		c.SMTPProbe = true
+		c.SMTPFrom = from
+		c.SMTPTimeout = timeout
+	}
+}
+
+// WithOnDisposable sets a callback invoked whenever IsDisposable returns true,
+// e.g. for metrics or audit logging. It receives the normalized domain and the
+// entry that matched it, as reported by Classification.Matched; matched is
+// empty for IP literals and reserved domains.
+//
+// The callback runs synchronously on the calling goroutine without holding
+// any checker lock, so it may call back into the checker. Keep it fast, since
+// it delays the IsDisposable result.
+func WithOnDisposable(fn func(domain, matched string)) Option {
+	return func(c *Config) {
+		c.OnDisposable = fn
+	}
+}
+
+// WithEmbeddedData sets a data.bin snapshot shipped with the application,
+// typically with go:embed. On startup, it replaces cached data with an older
+// CreatedAt, so a newly released binary doesn't start from a stale cache, and
+// it is used if there is no cache and the download fails. Like downloaded
+// data, it is validated and saved to the cache when used.
+func WithEmbeddedData(data []byte) Option {
+	return func(c *Config) {
+		c.EmbeddedData = data
+	}
+}
+
+// WithStaticData initializes the Checker from b, the contents of a data.bin
+// file obtained by the caller, e.g. from an artifact pipeline. The cache and
+// the network are not used, as in ModeEmbedded, which this option selects
+// with b in place of the compiled-in snapshot. New fails if b is not valid
+// data. Refresh and WithAutoRefresh still download from the data URL.
+func WithStaticData(b []byte) Option {
+	return func(c *Config) {
+		c.Mode = ModeEmbedded
+		// Non-nil, so that missing data fails instead of falling back to
+		// the compiled-in snapshot
+		c.EmbeddedData = append([]byte{}, b...)
+	}
+}
+
+// WithMmapData initializes the Checker from the flat data file at path, as
+// written by ConvertToFlat, without the cache or the network, like
+// WithStaticData. The file is memory-mapped and searched in place, so startup
+// neither decodes the lists nor builds tries, and processes on the same host
+// share its pages. Custom domains and domains added at runtime are kept
+// apart in memory. Refresh and WithAutoRefresh still download from the data
+// URL and replace the mapped data with decoded data.
+func WithMmapData(path string) Option {
+	return func(c *Config) {
+		c.Mode = ModeEmbedded
+		c.MmapData = path
+	}
+}
+
 // WithLogger sets a custom logger.
 func WithLogger(logger Logger) Option {
 	return func(c *Config) {
@@ -148,18 +763,93 @@ func WithLogger(logger Logger) Option {
 	}
 }
 
+// WithChecksumURL verifies downloads against the SHA-256 digest published at
+// url, a file holding the hex digest, optionally followed by the file name as
+// written by sha256sum. Data that doesn't match is rejected with a
+// ChecksumError before it is decoded, and the current data is kept, as it is
+// when url can't be fetched, including when it returns 404 Not Found; see
+// WithAllowMissingChecksum.
+//
+// The default data URL is verified against the digest published with each
+// release; use this option to verify data from a custom URL.
+func WithChecksumURL(url string) Option {
+	return func(c *Config) {
+		c.ChecksumURL = url
+	}
+}
+
+// WithAllowMissingChecksum accepts downloads whose checksum URL returns 404
+// Not Found, using the data unverified and logging a warning. Use it for a
+// data URL that publishes its digest only some of the time; data that doesn't
+// match a published digest is still rejected.
+func WithAllowMissingChecksum() Option {
+	return func(c *Config) {
+		c.AllowMissingChecksum = true
+	}
+}
+
+// WithDeltaURL makes Refresh try the delta published at url before
+// downloading the full data file. A delta lists the domains added and removed
+// since the previous release, and applies only to data of that release; in
+// any other case, including when url returns 404 Not Found, the full data
+// file is downloaded as before. When downloads are verified, see
+// WithChecksumURL, the delta must also match the SHA-256 digest published at
+// url plus ".sha256".
+//
+// The default data URL has a delta published with each release; use this
+// option for a custom URL whose publisher runs disposable-update, which
+// writes the delta next to data.bin.
+func WithDeltaURL(url string) Option {
+	return func(c *Config) {
+		c.DeltaURL = url
+	}
+}
+
 // WithDataURL sets a custom URL for downloading data.bin updates.
+// Data cached from a different URL is not used; it is downloaded again from
+// url on startup.
 func WithDataURL(url string) Option {
 	return func(c *Config) {
 		c.DataURL = url
 	}
 }
 
+// WithTimeSource sets the function used to get the current time.
+// This is mainly useful in tests to make time-dependent behavior deterministic.
+func WithTimeSource(now func() time.Time) Option {
+	return func(c *Config) {
+		if now != nil {
+			c.TimeSource = now
+		}
+	}
+}
+
 // Statistics contains information about the current database state.
 type Statistics struct {
-	BlocklistCount int       // Number of blocked domains
-	AllowlistCount int       // Number of allowlisted domains
-	LastUpdated    time.Time // When the database was last updated
-	Mode           Mode      // Current operating mode
-	Version        string    // Version of the data
+	BlocklistCount          int       // Number of blocked domains
+	EffectiveBlocklistCount int       // Number of blocked domains not covered by a blocked parent
+	AllowlistCount          int       // Number of allowlisted domains
+	LastUpdated             time.Time // When the database was last updated
+	Mode                    Mode      // Current operating mode
+	Version                 string    // Version of the data
+}
+
+// MarshalJSON encodes the Statistics with snake_case keys, the Mode as a
+// string and LastUpdated in RFC 3339 format.
+func (s Statistics) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		BlocklistCount          int    `json:"blocklist_count"`
+		EffectiveBlocklistCount int    `json:"effective_blocklist_count"`
+		AllowlistCount          int    `json:"allowlist_count"`
+		LastUpdated             string `json:"last_updated"`
+		Mode                    Mode   `json:"mode"`
+		Version                 string `json:"version"`
+	}{
+		BlocklistCount:          s.BlocklistCount,
+		EffectiveBlocklistCount: s.EffectiveBlocklistCount,
+		AllowlistCount:          s.AllowlistCount,
+		LastUpdated:             s.LastUpdated.UTC().Format(time.RFC3339),
+		Mode:                    s.Mode,
+		Version:                 s.Version,
+	})
 }
@@ -0,0 +1,108 @@
+package disposable
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModeMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(ModeOnline)
+	if err != nil {
+		t.Fatalf("json.Marshal(ModeOnline) error: %v", err)
+	}
+	if string(data) != `"online"` {
+		t.Errorf("json.Marshal(ModeOnline) = %s, want \"online\"", data)
+	}
+
+	data, err = json.Marshal(ModeEmbedded)
+	if err != nil {
+		t.Fatalf("json.Marshal(ModeEmbedded) error: %v", err)
+	}
+	if string(data) != `"embedded"` {
+		t.Errorf("json.Marshal(ModeEmbedded) = %s, want \"embedded\"", data)
+	}
+}
+
+func TestStatisticsMarshalJSON(t *testing.T) {
+	stats := Statistics{
+		BlocklistCount:          72000,
+		EffectiveBlocklistCount: 71000,
+		AllowlistCount:          150,
+		LastUpdated:             time.Date(2024, 5, 6, 7, 8, 9, 123, time.FixedZone("CEST", 2*60*60)),
+		Mode:                    ModeOnline,
+		Version:                 "1.0",
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("json.Marshal(stats) error: %v", err)
+	}
+
+	expected := `{"blocklist_count":72000,"effective_blocklist_count":71000,"allowlist_count":150,"last_updated":"2024-05-06T05:08:09Z","mode":"online","version":"1.0"}`
+	if string(data) != expected {
+		t.Errorf("json.Marshal(stats) = %s, want %s", data, expected)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []string // substrings of the error, none if valid
+	}{
+		{"defaults", nil, nil},
+		{"soft and hard TTL", []Option{WithSoftTTL(time.Hour), WithHardTTL(2 * time.Hour)}, nil},
+		{"hard TTL not longer than soft TTL", []Option{WithSoftTTL(time.Hour), WithHardTTL(time.Hour)},
+			[]string{"longer than soft TTL"}},
+		{"hard TTL shorter than refresh interval", []Option{WithAutoRefresh(time.Hour), WithRefreshJitter(time.Minute), WithHardTTL(time.Hour)},
+			[]string{"longer than the refresh interval"}},
+		{"hard TTL longer than refresh interval", []Option{WithAutoRefresh(time.Hour), WithHardTTL(2 * time.Hour)}, nil},
+		{"negative durations", []Option{WithHTTPTimeout(-time.Second), WithRefreshJitter(-time.Second)},
+			[]string{"HTTP timeout -1s is negative", "refresh jitter -1s is negative"}},
+		{"negative SMTP timeout", []Option{WithSMTPProbe("", -time.Second)}, []string{"SMTP timeout -1s is negative"}},
+		{"unknown mode", []Option{WithMode(Mode(7))}, []string{"unknown mode 7"}},
+		{"too many block patterns", []Option{WithBlockPatterns(make([]string, MaxBlockPatterns+1)...)},
+			[]string{"block patterns exceed the limit"}},
+		{"unknown allowlist mode", []Option{WithAllowlistMode(AllowlistMode(3))}, []string{"unknown allowlist mode 3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			for _, opt := range tt.opts {
+				opt(config)
+			}
+
+			err := config.validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validate() error = nil, want %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("validate() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	_, err := New(WithCacheDir(t.TempDir()), WithSoftTTL(2*time.Hour), WithHardTTL(time.Hour))
+
+	var initErr *InitializationError
+	if !errors.As(err, &initErr) {
+		t.Fatalf("New() error = %v, want an InitializationError", err)
+	}
+	if initErr.Reason != "invalid configuration" {
+		t.Errorf("Reason = %q, want %q", initErr.Reason, "invalid configuration")
+	}
+}
@@ -2,9 +2,18 @@
 package data
 
 const (
-	// DefaultDataURL is the URL to download data.bin from.
-	DefaultDataURL = "https://github.com/rezmoss/go-is-disposable-email/releases/latest/download/data.bin"
+	// DefaultDataURL is the URL to download data.v2.bin from.
+	DefaultDataURL = "https://github.com/rezmoss/go-is-disposable-email/releases/latest/download/" + AssetName
 
 	// DataFileName is the name of the data file.
 	DataFileName = "data.bin"
+
+	// AssetName is the name of the release asset holding the data in the
+	// current format. Releases before format 2 download LegacyAssetName
+	// instead, so it changed with the format.
+	AssetName = "data.v2.bin"
+
+	// LegacyAssetName is the name of the release asset holding the data in
+	// format 1, still published for releases that can't read AssetName.
+	LegacyAssetName = "data.bin"
 )
@@ -0,0 +1,10 @@
+package data
+
+import _ "embed"
+
+// Snapshot is the data.v2.bin file as of the library's release, compiled in
+// for use without network or filesystem access. It is older than the data
+// published daily at DefaultDataURL.
+//
+//go:embed data.v2.bin
+var Snapshot []byte
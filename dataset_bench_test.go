@@ -0,0 +1,125 @@
+package disposable
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
+)
+
+// Benchmarks against the full data/data.v2.bin dataset. Run them on a baseline
+// and a change with -count and compare with benchstat to catch regressions in
+// load time, lookup throughput and memory:
+//
+//	go test -run '^$' -bench Dataset -count 10 . > new.txt
+
+// datasetLookups is a mix of disposable and legitimate lookups.
+var datasetLookups = []string{
+	"user@gmail.com",
+	"user@10minutemail.com",
+	"user@company.co.uk",
+	"user@mail.guerrillamail.com",
+	"user@outlook.com",
+	"user@yopmail.com",
+	"user@startup.io",
+	"user@mailinator.com",
+}
+
+// loadDatasetBytes returns the contents of data/data.v2.bin, skipping if absent.
+func loadDatasetBytes(b *testing.B) []byte {
+	b.Helper()
+
+	data, err := os.ReadFile(filepath.Join("data", "data.v2.bin"))
+	if err != nil {
+		b.Skipf("data/data.v2.bin not available: %v", err)
+	}
+	return data
+}
+
+// newDatasetChecker returns a Checker loaded from data/data.v2.bin.
+func newDatasetChecker(b *testing.B) *Checker {
+	b.Helper()
+
+	dir := b.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), loadDatasetBytes(b), 0644); err != nil {
+		b.Fatalf("Failed to write data.bin: %v", err)
+	}
+
+	checker, err := New(WithCacheDir(dir))
+	if err != nil {
+		b.Fatalf("New() error = %v", err)
+	}
+	b.Cleanup(func() { checker.Close() })
+	return checker
+}
+
+// BenchmarkDatasetLoad measures cold-load time: decompressing, decoding and
+// building the tries from the full dataset.
+func BenchmarkDatasetLoad(b *testing.B) {
+	data := loadDatasetBytes(b)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, err := trie.Deserialize(data); err != nil {
+			b.Fatalf("Deserialize failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkDatasetIsDisposable measures steady-state lookup throughput.
+func BenchmarkDatasetIsDisposable(b *testing.B) {
+	checker := newDatasetChecker(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		checker.IsDisposable(datasetLookups[i%len(datasetLookups)])
+	}
+}
+
+// BenchmarkDatasetIsDisposable_Parallel measures lookup throughput under contention.
+func BenchmarkDatasetIsDisposable_Parallel(b *testing.B) {
+	checker := newDatasetChecker(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			checker.IsDisposable(datasetLookups[i%len(datasetLookups)])
+			i++
+		}
+	})
+}
+
+// BenchmarkDatasetMemory reports the heap retained by the loaded tries.
+func BenchmarkDatasetMemory(b *testing.B) {
+	data := loadDatasetBytes(b)
+
+	var retained int64
+	var domains int
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		blocklist, allowlist, _, err := trie.Deserialize(data)
+		if err != nil {
+			b.Fatalf("Deserialize failed: %v", err)
+		}
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		retained = int64(after.HeapAlloc) - int64(before.HeapAlloc)
+		domains = blocklist.Size() + allowlist.Size()
+		runtime.KeepAlive(blocklist)
+		runtime.KeepAlive(allowlist)
+	}
+
+	b.ReportMetric(float64(retained), "heap-bytes")
+	b.ReportMetric(float64(retained)/float64(domains), "bytes/domain")
+}
@@ -0,0 +1,88 @@
+package disposable
+
+import (
+	"context"
+	"os"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
+)
+
+// DeltaSuffix is appended to the name or URL of a data file to get that of
+// the delta updating the previous release to it, e.g. "data.v2.bin.delta".
+const DeltaSuffix = ".delta"
+
+// refreshData updates the data from the delta at Config.DeltaURL when one
+// applies to the loaded data, and downloads the full data file otherwise.
+func (c *Checker) refreshData(ctx context.Context) error {
+	if c.config.DeltaURL != "" {
+		err := c.loadDelta(ctx)
+		if err == nil {
+			return nil
+		}
+		c.config.Logger.Printf("Delta update not used, downloading full data: %v", err)
+	}
+	return c.downloadAndLoad(ctx)
+}
+
+// loadDelta downloads the delta at Config.DeltaURL, applies it to the loaded
+// data and loads the result. It does nothing if the loaded data is already
+// the delta's result, and fails if the delta was made for other data.
+//
+// When downloads are verified, so is the delta, against the digest published
+// next to it; one that doesn't match fails like any other delta that can't
+// be used.
+func (c *Checker) loadDelta(ctx context.Context) error {
+	c.mu.RLock()
+	raw, current := c.rawData, c.lastUpdated
+	c.mu.RUnlock()
+
+	deltaData, err := c.download(ctx, c.config.DeltaURL)
+	if err != nil {
+		return err
+	}
+	if c.config.ChecksumURL != "" {
+		checksumURL := c.config.DeltaURL + ChecksumSuffix
+		if err := c.verifyDownload(ctx, checksumURL, c.config.DeltaURL, deltaData); err != nil {
+			return err
+		}
+	}
+	delta, err := trie.DeserializeDelta(deltaData)
+	if err != nil {
+		return &DeserializationError{Source: "delta", Err: err}
+	}
+
+	if delta.CreatedAt.Equal(current) {
+		return nil
+	}
+	if !delta.BaseCreatedAt.Equal(current) {
+		return &DeserializationError{Source: "delta", Err: &trie.DeltaBaseError{Want: delta.BaseCreatedAt, Have: current}}
+	}
+
+	// Data loaded from TLD shards is still in the cache file
+	if raw == nil {
+		dataPath := c.getDataFilePath()
+		if raw, err = os.ReadFile(dataPath); err != nil {
+			return &CacheError{Path: dataPath, Operation: "read", Err: err}
+		}
+		if err := c.verifyCacheChecksum(raw); err != nil {
+			return &CacheError{Path: dataPath, Operation: "verify", Err: err}
+		}
+	}
+	base, err := trie.DecodeDataFile(raw)
+	if err != nil {
+		return &DeserializationError{Source: "cache", Err: err}
+	}
+
+	target, err := delta.Apply(base)
+	if err != nil {
+		return &DeserializationError{Source: "delta", Err: err}
+	}
+	fileData, err := trie.SerializeDataFile(target, trie.DefaultCompressionLevel)
+	if err != nil {
+		return &DeserializationError{Source: "delta", Err: err}
+	}
+
+	c.config.Logger.Printf("Applied delta: %d blocklist domains added, %d removed",
+		len(delta.AddedBlocklist), len(delta.RemovedBlocklist))
+	return c.loadData(fileData, "delta")
+}
@@ -0,0 +1,198 @@
+package disposable
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rezmoss/go-is-disposable-email/data"
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
+)
+
+// newTestDeltaServer serves full as data.bin and the delta from base to full
+// as data.bin.delta, both with their digests, counting the full downloads.
+// It also serves the delta as corrupt.delta, with the digest of other data.
+func newTestDeltaServer(t *testing.T, base, full []byte) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	_, _, baseFile, err := trie.Deserialize(base)
+	if err != nil {
+		t.Fatalf("Deserialize() error = %v", err)
+	}
+	_, _, fullFile, err := trie.Deserialize(full)
+	if err != nil {
+		t.Fatalf("Deserialize() error = %v", err)
+	}
+	delta, err := trie.SerializeDelta(trie.NewDelta(baseFile, fullFile), trie.DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDelta() error = %v", err)
+	}
+
+	var fullHits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data.bin":
+			fullHits.Add(1)
+			w.Write(full)
+		case "/data.bin.sha256":
+			w.Write([]byte(checksum(full) + "  data.bin\n"))
+		case "/data.bin.delta", "/corrupt.delta":
+			w.Write(delta)
+		case "/data.bin.delta.sha256":
+			w.Write([]byte(checksum(delta) + "  data.bin.delta\n"))
+		case "/corrupt.delta.sha256":
+			w.Write([]byte(checksum(base) + "  corrupt.delta\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &fullHits
+}
+
+func TestCheckerRefreshDelta(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	base := buildTestDataFile(t, created, "kept.test", "removed.test")
+	full := buildTestDataFile(t, created.Add(time.Hour), "kept.test", "added.test")
+
+	for _, lazy := range []bool{false, true} {
+		server, fullHits := newTestDeltaServer(t, base, full)
+
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "data.bin"), base, 0644); err != nil {
+			t.Fatalf("Failed to write data.bin: %v", err)
+		}
+		opts := []Option{WithCacheDir(dir), WithDataURL(server.URL + "/data.bin"),
+			WithChecksumURL(server.URL + "/data.bin.sha256"), WithDeltaURL(server.URL + "/data.bin.delta")}
+		if lazy {
+			opts = append(opts, WithLazyTLDLoading())
+		}
+		checker, err := New(opts...)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer checker.Close()
+
+		if err := checker.Refresh(); err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+		if n := fullHits.Load(); n != 0 {
+			t.Errorf("lazy=%v: full data downloaded %d times, want 0", lazy, n)
+		}
+
+		for domain, want := range map[string]bool{"kept.test": true, "added.test": true, "removed.test": false} {
+			if got := checker.IsDisposable(domain); got != want {
+				t.Errorf("lazy=%v: IsDisposable(%q) = %v, want %v", lazy, domain, got, want)
+			}
+		}
+		if got := checker.Stats().LastUpdated; !got.Equal(created.Add(time.Hour)) {
+			t.Errorf("lazy=%v: LastUpdated = %v, want %v", lazy, got, created.Add(time.Hour))
+		}
+
+		// The result is cached like a download from the data URL
+		reloaded, err := New(opts...)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer reloaded.Close()
+		if !reloaded.IsDisposable("added.test") {
+			t.Errorf("lazy=%v: expected the patched data to be cached", lazy)
+		}
+
+		// Once up to date, nothing more is downloaded
+		if err := checker.Refresh(); err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+		if n := fullHits.Load(); n != 0 {
+			t.Errorf("lazy=%v: full data downloaded %d times when up to date, want 0", lazy, n)
+		}
+	}
+}
+
+func TestCheckerRefreshDeltaFallback(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	base := buildTestDataFile(t, created, "kept.test")
+	other := buildTestDataFile(t, created.Add(-time.Hour), "other.test")
+	full := buildTestDataFile(t, created.Add(time.Hour), "kept.test", "added.test")
+
+	tests := []struct {
+		name     string
+		deltaURL string // path on the test server
+	}{
+		{"delta for other data", "/data.bin.delta"},
+		{"no delta published", "/missing.delta"},
+		{"delta not matching its digest", "/corrupt.delta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The delta is made for other data than the cached data
+			server, fullHits := newTestDeltaServer(t, other, full)
+			if tt.deltaURL == "/corrupt.delta" {
+				// A delta that applies, so only its digest stops it
+				server, fullHits = newTestDeltaServer(t, base, full)
+			}
+
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "data.bin"), base, 0644); err != nil {
+				t.Fatalf("Failed to write data.bin: %v", err)
+			}
+			var logs bytes.Buffer
+			checker, err := New(WithCacheDir(dir), WithDataURL(server.URL+"/data.bin"),
+				WithChecksumURL(server.URL+"/data.bin.sha256"), WithDeltaURL(server.URL+tt.deltaURL),
+				WithLogger(log.New(&logs, "", 0)))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer checker.Close()
+
+			if err := checker.Refresh(); err != nil {
+				t.Fatalf("Refresh() error = %v", err)
+			}
+			if n := fullHits.Load(); n != 1 {
+				t.Errorf("full data downloaded %d times, want 1", n)
+			}
+			if !checker.IsDisposable("added.test") {
+				t.Error("Expected the full data to be loaded")
+			}
+			if !strings.Contains(logs.String(), "Delta update not used") {
+				t.Errorf("Expected the fallback to be logged, got %q", logs.String())
+			}
+			if tt.deltaURL == "/corrupt.delta" && !strings.Contains(logs.String(), "checksum mismatch") {
+				t.Errorf("Expected the delta to fail verification, got %q", logs.String())
+			}
+		})
+	}
+}
+
+func TestCheckerDefaultDeltaURL(t *testing.T) {
+	dir := newTestCacheDir(t)
+
+	checker, err := New(WithCacheDir(dir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	if want := data.DefaultDataURL + DeltaSuffix; checker.config.DeltaURL != want {
+		t.Errorf("DeltaURL = %q, want %q", checker.config.DeltaURL, want)
+	}
+
+	custom, err := New(WithCacheDir(dir), WithDataURL("http://127.0.0.1:0/data.bin"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer custom.Close()
+
+	if custom.config.DeltaURL != "" {
+		t.Errorf("DeltaURL = %q for a custom data URL, want none", custom.config.DeltaURL)
+	}
+}
@@ -30,17 +30,30 @@
 //	}
 //	defer checker.Close()
 //	result := checker.IsDisposable("user@example.com")
+//
+// The package-level functions share a single default Checker. Domains added
+// with AddDomains or AddAllowlist are therefore visible to every caller in the
+// process, including unrelated libraries. Use ClearCustom to undo such
+// additions, or create a dedicated Checker with New to keep them isolated.
 package disposable
 
 import (
 	"context"
+	"io"
+	"regexp"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
 	defaultChecker     *Checker
 	defaultCheckerOnce sync.Once
 	defaultCheckerErr  error
+
+	// defaultCheckerReady holds the default checker once it initialized
+	// successfully, so it can be inspected without triggering initialization.
+	defaultCheckerReady atomic.Pointer[Checker]
 )
 
 // getDefaultChecker returns the default checker, initializing it if needed.
@@ -49,6 +62,9 @@ func getDefaultChecker() (*Checker, error) {
 	defaultCheckerOnce.Do(func() {
 		// Download data on first use, cache locally
 		defaultChecker, defaultCheckerErr = New()
+		if defaultCheckerErr == nil {
+			defaultCheckerReady.Store(defaultChecker)
+		}
 	})
 	return defaultChecker, defaultCheckerErr
 }
@@ -71,6 +87,91 @@ func IsDisposable(emailOrDomain string) bool {
 	return checker.IsDisposable(emailOrDomain)
 }
 
+// IsDisposableHost reports whether the host of a URL is a disposable domain.
+// See Checker.IsDisposableHost.
+//
+// Returns false if initialization fails.
+func IsDisposableHost(rawURL string) bool {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return false
+	}
+	return checker.IsDisposableHost(rawURL)
+}
+
+// IsDisposableBatch checks many emails or domains, returning one result per
+// input. If ctx is cancelled, it returns the results computed so far, which
+// are fewer than the inputs, together with ctx.Err().
+func IsDisposableBatch(ctx context.Context, inputs []string) ([]bool, error) {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return nil, err
+	}
+	return checker.IsDisposableBatch(ctx, inputs)
+}
+
+// CanonicalEmail returns a normalized form of an email address for
+// deduplication, with the subaddress tag after '+' removed. It doesn't need
+// the default checker to be initialized. See Checker.CanonicalEmail.
+func CanonicalEmail(email string) string {
+	return stripSubaddress(canonicalEmail(email), defaultSubaddressSeparators)
+}
+
+// NormalizeEmail returns a normalized form of an email address for
+// deduplicating signups, with the subaddress tag after '+' removed and the
+// built-in provider rules applied, e.g. ignoring dots for Gmail. It doesn't
+// need the default checker to be initialized, so it doesn't use provider
+// rules from the data file. See Checker.NormalizeEmail.
+func NormalizeEmail(email string) string {
+	return normalizeEmail(email, defaultSubaddressSeparators, defaultProviderRules)
+}
+
+// IsRoleAddress reports whether email's local part names a role, such as
+// "admin@" or "noreply@", using the built-in role list. It doesn't need the
+// default checker to be initialized. See Checker.IsRoleAddress.
+func IsRoleAddress(email string) bool {
+	return isRoleAddress(stripSubaddress(canonicalEmail(email), defaultSubaddressSeparators), defaultRoleAccounts)
+}
+
+// Suggest reports whether the domain of emailOrDomain looks like a typo of a
+// well-known mail provider and returns the corrected input, e.g.
+// "user@gmail.com" for "user@gmial.com". It uses the default checker's
+// providers, or the built-in list if the checker cannot be initialized.
+// See Checker.Suggest.
+func Suggest(emailOrDomain string) (string, bool) {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return suggest(emailOrDomain, defaultProviders, func(string) bool { return false })
+	}
+	return checker.Suggest(emailOrDomain)
+}
+
+// IsDisposableWith is like IsDisposable but also applies extraBlock and
+// extraAllow for this call only, without modifying the default checker.
+// A match in extraAllow wins over extraBlock, and both win over the dataset.
+//
+// Returns false if initialization fails.
+func IsDisposableWith(emailOrDomain string, extraBlock, extraAllow []string) bool {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return false
+	}
+	return checker.IsDisposableWith(emailOrDomain, extraBlock, extraAllow)
+}
+
+// TryIsDisposable is like IsDisposable but never initializes the default
+// checker, so it never causes network or disk I/O. If the default checker
+// hasn't been successfully initialized yet, it returns ready=false and the
+// result should be ignored. Call IsReady or any other package-level function
+// to initialize it explicitly.
+func TryIsDisposable(emailOrDomain string) (result bool, ready bool) {
+	checker := defaultCheckerReady.Load()
+	if checker == nil {
+		return false, false
+	}
+	return checker.IsDisposable(emailOrDomain), true
+}
+
 // IsDisposableWithContext is like IsDisposable but accepts a context for cancellation.
 //
 // Note: Returns false on initialization errors. Use CheckEmailWithContext for error handling.
@@ -97,6 +198,34 @@ func CheckEmail(emailOrDomain string) (bool, error) {
 	return checker.IsDisposable(emailOrDomain), nil
 }
 
+// EmailCheck is the detailed result of CheckEmailResult.
+type EmailCheck struct {
+	Disposable    bool   // Whether the email or domain is disposable
+	Domain        string // Normalized domain that was checked, empty if none was found
+	MatchedSuffix string // Entry that decided the result, empty if nothing matched
+	FromCustom    bool   // Whether MatchedSuffix came from custom domains, overrides or runtime additions
+}
+
+// CheckEmailResult is like CheckEmail but also reports which entry decided the
+// result and where it came from. It is the package-level counterpart of
+// Checker.Classify; see there for how overlapping entries are resolved.
+//
+// Returns an error only if the checker failed to initialize.
+func CheckEmailResult(emailOrDomain string) (EmailCheck, error) {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return EmailCheck{}, err
+	}
+
+	cl := checker.Classify(emailOrDomain)
+	return EmailCheck{
+		Disposable:    cl.Disposable(),
+		Domain:        cl.Domain,
+		MatchedSuffix: cl.Matched,
+		FromCustom:    cl.Custom,
+	}, nil
+}
+
 // CheckEmailWithContext is like CheckEmail but accepts a context for cancellation.
 func CheckEmailWithContext(ctx context.Context, emailOrDomain string) (bool, error) {
 	checker, err := getDefaultChecker()
@@ -106,6 +235,22 @@ func CheckEmailWithContext(ctx context.Context, emailOrDomain string) (bool, err
 	return checker.IsDisposableWithContext(ctx, emailOrDomain), nil
 }
 
+// CheckDomain is a strict variant of CheckEmail that validates its input.
+//
+// Returns:
+//   - ErrEmptyInput if the input is empty
+//   - ErrInvalidDomain if no valid domain can be extracted
+//   - an initialization error if the checker failed to initialize
+//
+// Use errors.Is to distinguish these cases.
+func CheckDomain(emailOrDomain string) (bool, error) {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return false, err
+	}
+	return checker.CheckDomain(emailOrDomain)
+}
+
 // Refresh updates the domain database by downloading fresh data from the source.
 func Refresh() error {
 	checker, err := getDefaultChecker()
@@ -127,6 +272,10 @@ func RefreshWithContext(ctx context.Context) error {
 // AddDomains adds custom domains to the blocklist at runtime.
 // These additions are not persisted and will be lost on program restart.
 //
+// The additions apply to the shared default checker and therefore affect every
+// caller of the package-level functions. Use ClearCustom to undo them, or New
+// for a Checker whose additions stay private.
+//
 // Note: Silently fails if the checker is not initialized. Use IsReady() to check status.
 func AddDomains(domains ...string) {
 	checker, err := getDefaultChecker()
@@ -136,9 +285,23 @@ func AddDomains(domains ...string) {
 	checker.AddDomains(domains...)
 }
 
+// AddDomainsTTL adds domains to the blocklist at runtime until ttl elapses.
+// Like AddDomains, this mutates the shared default checker.
+//
+// Note: Silently fails if the checker is not initialized. Use IsReady() to check status.
+func AddDomainsTTL(ttl time.Duration, domains ...string) {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return
+	}
+	checker.AddDomainsTTL(ttl, domains...)
+}
+
 // AddAllowlist adds domains to the allowlist at runtime.
 // Allowlisted domains will never be reported as disposable.
 //
+// Like AddDomains, this mutates the shared default checker.
+//
 // Note: Silently fails if the checker is not initialized. Use IsReady() to check status.
 func AddAllowlist(domains ...string) {
 	checker, err := getDefaultChecker()
@@ -148,6 +311,54 @@ func AddAllowlist(domains ...string) {
 	checker.AddAllowlist(domains...)
 }
 
+// AddBlockedEmail blocks specific full addresses at runtime, even on domains
+// that are otherwise not disposable.
+//
+// Note: Silently fails if the checker is not initialized. Use IsReady() to check status.
+func AddBlockedEmail(emails ...string) {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return
+	}
+	checker.AddBlockedEmail(emails...)
+}
+
+// AddAllowedEmail allows specific full addresses at runtime, even on
+// disposable domains.
+//
+// Note: Silently fails if the checker is not initialized. Use IsReady() to check status.
+func AddAllowedEmail(emails ...string) {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return
+	}
+	checker.AddAllowedEmail(emails...)
+}
+
+// OverlaySize reports how many domains were added at runtime to the default
+// checker's blocklist and allowlist, beyond the loaded data.
+//
+// Note: Returns zeros if the checker is not initialized. Use IsReady() to check status.
+func OverlaySize() (block int, allow int) {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return 0, 0
+	}
+	return checker.OverlaySize()
+}
+
+// ClearCustom removes all domains and addresses added at runtime from the
+// default checker.
+//
+// Note: Silently fails if the checker is not initialized. Use IsReady() to check status.
+func ClearCustom() {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return
+	}
+	checker.ClearCustom()
+}
+
 // GetBlocklist returns a copy of all blocked domains.
 //
 // Note: Returns nil if the checker is not initialized. Use IsReady() to check status.
@@ -159,6 +370,28 @@ func GetBlocklist() []string {
 	return checker.GetBlocklist()
 }
 
+// FindBlocked returns the blocked domains matching pattern, sorted.
+// It walks the whole blocklist and is meant for occasional administrative use.
+//
+// Note: Returns nil if the checker is not initialized. Use IsReady() to check status.
+func FindBlocked(pattern *regexp.Regexp) []string {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return nil
+	}
+	return checker.FindBlocked(pattern)
+}
+
+// PersistCache writes the data loaded by the default checker to path.
+// See Checker.PersistCache.
+func PersistCache(path string) error {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return err
+	}
+	return checker.PersistCache(path)
+}
+
 // GetAllowlist returns a copy of all allowlisted domains.
 //
 // Note: Returns nil if the checker is not initialized. Use IsReady() to check status.
@@ -170,6 +403,26 @@ func GetAllowlist() []string {
 	return checker.GetAllowlist()
 }
 
+// ExportMinimal writes the smallest equivalent blocklist to w, one domain per line.
+// Subdomains of other blocked domains are omitted.
+func ExportMinimal(w io.Writer) error {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return err
+	}
+	return checker.ExportMinimal(w)
+}
+
+// ExportBlocklist writes the blocklist to w in the given format, such as
+// hosts file or dnsmasq lines. See Checker.ExportBlocklist.
+func ExportBlocklist(w io.Writer, format ExportFormat) error {
+	checker, err := getDefaultChecker()
+	if err != nil {
+		return err
+	}
+	return checker.ExportBlocklist(w, format)
+}
+
 // Stats returns statistics about the current database.
 //
 // Note: Returns empty Statistics if the checker is not initialized. Use IsReady() to check status.
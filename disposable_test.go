@@ -2,6 +2,7 @@ package disposable
 
 import (
 	"context"
+	"errors"
 	"testing"
 )
 
@@ -51,6 +52,19 @@ func TestAddDomains(t *testing.T) {
 	}
 }
 
+func TestClearCustom(t *testing.T) {
+	AddDomains("clear-custom-test-domain.com")
+	if !IsDisposable("clear-custom-test-domain.com") {
+		t.Fatal("Custom domain should be detected as disposable after AddDomains")
+	}
+
+	ClearCustom()
+
+	if IsDisposable("clear-custom-test-domain.com") {
+		t.Error("Custom domain should not be detected as disposable after ClearCustom")
+	}
+}
+
 func TestAddAllowlist(t *testing.T) {
 	// First verify a known disposable domain
 	if !IsDisposable("10minutemail.com") {
@@ -129,6 +143,24 @@ func BenchmarkIsDisposable_Parallel(b *testing.B) {
 	})
 }
 
+func TestIsRoleAddress(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"postmaster@example.com", true},
+		{"Sales+Q3@example.com", true},
+		{"user@example.com", false},
+		{"example.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRoleAddress(tt.input); got != tt.expected {
+			t.Errorf("IsRoleAddress(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
 func TestCheckEmail(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -153,6 +185,29 @@ func TestCheckEmail(t *testing.T) {
 	}
 }
 
+func TestCheckEmailResult(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected EmailCheck
+	}{
+		{"user@sub.guerrillamail.com", EmailCheck{Disposable: true, Domain: "sub.guerrillamail.com", MatchedSuffix: "guerrillamail.com"}},
+		{"user@gmail.com", EmailCheck{Domain: "gmail.com"}},
+		{"", EmailCheck{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := CheckEmailResult(tt.input)
+			if err != nil {
+				t.Fatalf("CheckEmailResult(%q) returned error: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("CheckEmailResult(%q) = %+v, want %+v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestCheckEmailWithContext(t *testing.T) {
 	ctx := context.Background()
 
@@ -186,6 +241,46 @@ func TestCheckEmailWithContext(t *testing.T) {
 	}
 }
 
+func TestCheckDomain(t *testing.T) {
+	result, err := CheckDomain("user@guerrillamail.com")
+	if err != nil || !result {
+		t.Errorf("CheckDomain(disposable) = (%v, %v), want (true, nil)", result, err)
+	}
+
+	if _, err := CheckDomain(""); !errors.Is(err, ErrEmptyInput) {
+		t.Errorf("CheckDomain(\"\") error = %v, want ErrEmptyInput", err)
+	}
+
+	if _, err := CheckDomain("user@"); !errors.Is(err, ErrInvalidDomain) {
+		t.Errorf("CheckDomain(\"user@\") error = %v, want ErrInvalidDomain", err)
+	}
+}
+
+func TestTryIsDisposable(t *testing.T) {
+	if !IsReady() {
+		t.Skip("default checker failed to initialize")
+	}
+
+	result, ready := TryIsDisposable("user@mailinator.com")
+	if !ready || !result {
+		t.Errorf("TryIsDisposable(disposable) = (%v, %v), want (true, true)", result, ready)
+	}
+
+	result, ready = TryIsDisposable("user@gmail.com")
+	if !ready || result {
+		t.Errorf("TryIsDisposable(legitimate) = (%v, %v), want (false, true)", result, ready)
+	}
+
+	// Simulate a default checker that hasn't been initialized yet
+	checker := defaultCheckerReady.Swap(nil)
+	defer defaultCheckerReady.Store(checker)
+
+	result, ready = TryIsDisposable("user@mailinator.com")
+	if ready || result {
+		t.Errorf("TryIsDisposable before init = (%v, %v), want (false, false)", result, ready)
+	}
+}
+
 func TestIsReady(t *testing.T) {
 	// Since the checker is initialized by other tests, it should be ready
 	if !IsReady() {
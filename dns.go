@@ -0,0 +1,96 @@
+package disposable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// DNSResolver looks up the DNS records used by WithDNSCheck. *net.Resolver
+// implements it.
+type DNSResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// DNSStatus reports whether DNS says a domain can receive mail.
+type DNSStatus int
+
+const (
+	// DNSUnknown means DNS was not checked or the lookup failed, e.g. on a
+	// timeout.
+	DNSUnknown DNSStatus = iota
+	// DNSMX means the domain has MX records.
+	DNSMX
+	// DNSAddress means the domain has no MX records but A or AAAA records,
+	// which mail servers fall back to.
+	DNSAddress
+	// DNSNotFound means the domain has neither MX nor address records, e.g.
+	// because it is misspelled or doesn't exist.
+	DNSNotFound
+)
+
+// String returns the string representation of the DNSStatus.
+func (s DNSStatus) String() string {
+	switch s {
+	case DNSUnknown:
+		return "unknown"
+	case DNSMX:
+		return "mx"
+	case DNSAddress:
+		return "address"
+	case DNSNotFound:
+		return "not-found"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON encodes the DNSStatus as its string representation.
+func (s DNSStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// LookupDNS checks whether the domain of emailOrDomain resolves: it looks up
+// MX records and, if there are none, A and AAAA records. This is independent
+// of the lists, so a domain can be disposable and resolve, or neither.
+//
+// It uses the resolver set with WithDNSCheck, or net.DefaultResolver, and
+// returns ErrInvalidDomain if no valid domain can be extracted. Lookup errors
+// other than the domain not being found are returned with DNSUnknown.
+func (c *Checker) LookupDNS(ctx context.Context, emailOrDomain string) (DNSStatus, error) {
+	domain := NormalizeDomain(ExtractDomain(emailOrDomain))
+	if !IsValidDomain(domain) {
+		return DNSUnknown, fmt.Errorf("%w: %q", ErrInvalidDomain, emailOrDomain)
+	}
+
+	var resolver DNSResolver = net.DefaultResolver
+	if c.config.DNSResolver != nil {
+		resolver = c.config.DNSResolver
+	}
+
+	mx, err := resolver.LookupMX(ctx, domain)
+	if err != nil && !isDNSNotFound(err) {
+		return DNSUnknown, err
+	}
+	if len(mx) > 0 {
+		return DNSMX, nil
+	}
+
+	addrs, err := resolver.LookupHost(ctx, domain)
+	if err != nil && !isDNSNotFound(err) {
+		return DNSUnknown, err
+	}
+	if len(addrs) > 0 {
+		return DNSAddress, nil
+	}
+	return DNSNotFound, nil
+}
+
+// isDNSNotFound reports whether err means the looked up records don't exist.
+func isDNSNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,108 @@
+package disposable
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+// fakeResolver answers DNS lookups from maps; missing names are not found.
+type fakeResolver struct {
+	mx    map[string][]*net.MX
+	hosts map[string][]string
+	err   error // Returned by every lookup if set
+}
+
+func (r *fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if mx, ok := r.mx[name]; ok {
+		return mx, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestCheckerLookupDNS(t *testing.T) {
+	resolver := &fakeResolver{
+		mx:    map[string][]*net.MX{"gmail.com": {{Host: "mx.gmail.com.", Pref: 10}}},
+		hosts: map[string][]string{"a-only.example": {"192.0.2.1"}},
+	}
+	checker, err := New(WithCacheDir(newTestCacheDir(t)), WithDNSCheck(resolver))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	tests := []struct {
+		input    string
+		expected DNSStatus
+		err      error
+	}{
+		{"user@gmail.com", DNSMX, nil},
+		{"user@a-only.example", DNSAddress, nil},
+		{"user@typo-domain.example", DNSNotFound, nil},
+		{"user@localhost", DNSUnknown, ErrInvalidDomain},
+	}
+
+	for _, tt := range tests {
+		status, err := checker.LookupDNS(context.Background(), tt.input)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("LookupDNS(%q) error = %v, want %v", tt.input, err, tt.err)
+		}
+		if status != tt.expected {
+			t.Errorf("LookupDNS(%q) = %v, want %v", tt.input, status, tt.expected)
+		}
+	}
+
+	// Check reports DNS separately from the list verdict
+	result, err := checker.Check("user@typo-domain.example")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if result.Disposable || result.DNS != DNSNotFound {
+		t.Errorf("Check(%q) = %+v, want not disposable and DNS %v", "user@typo-domain.example", result, DNSNotFound)
+	}
+
+	// Lookup failures are reported by LookupDNS but don't fail Check
+	resolver.err = &net.DNSError{Err: "i/o timeout", Name: "mailinator.com", IsTimeout: true}
+	if status, err := checker.LookupDNS(context.Background(), "mailinator.com"); err == nil || status != DNSUnknown {
+		t.Errorf("LookupDNS() = (%v, %v), want DNSUnknown and an error", status, err)
+	}
+	result, err = checker.Check("user@mailinator.com")
+	if err != nil || !result.Disposable || result.DNS != DNSUnknown {
+		t.Errorf("Check() = (%+v, %v), want disposable with DNS %v", result, err, DNSUnknown)
+	}
+}
+
+func TestCheckerCheckWithoutDNS(t *testing.T) {
+	checker, err := New(WithCacheDir(newTestCacheDir(t)), WithDNSCheck(&fakeResolver{}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	plain, err := New(WithCacheDir(newTestCacheDir(t)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer plain.Close()
+
+	if result, _ := plain.Check("user@gmail.com"); result.DNS != DNSUnknown {
+		t.Errorf("Check().DNS = %v without WithDNSCheck, want %v", result.DNS, DNSUnknown)
+	}
+	if result, _ := checker.Check("user@gmail.com"); result.DNS != DNSNotFound {
+		t.Errorf("Check().DNS = %v, want %v", result.DNS, DNSNotFound)
+	}
+}
@@ -1,11 +1,26 @@
 package disposable
 
 import (
+	"net/netip"
 	"strings"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/idna"
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
 )
 
 // ExtractDomain extracts the domain from an email address or returns the input
 // if it's already a domain. Returns empty string for invalid input.
+//
+// Quoted local parts may contain "@" (e.g. "\"weird@name\"@example.com").
+// Addresses with more than one unquoted "@", such as "a@b@example.com", are
+// ambiguous and yield an empty string.
+//
+// Address literals such as "[192.168.0.1]" or "[IPv6:2001:db8::1]" yield the
+// bare IP address; malformed literals yield an empty string. Use IsIPLiteral
+// to tell them apart from domain names.
+//
+// Internationalized domains are converted to their ASCII (punycode) form, the
+// form the data stores, so "user@münchen.de" yields "xn--mnchen-3ya.de".
 func ExtractDomain(emailOrDomain string) string {
 	emailOrDomain = strings.TrimSpace(emailOrDomain)
 	emailOrDomain = strings.ToLower(emailOrDomain)
@@ -14,22 +29,142 @@ func ExtractDomain(emailOrDomain string) string {
 		return ""
 	}
 
-	// Check if it's an email address
-	if idx := strings.LastIndex(emailOrDomain, "@"); idx != -1 {
-		domain := emailOrDomain[idx+1:]
-		if domain == "" {
+	// Assume it's already a domain
+	domain := emailOrDomain
+	if strings.Contains(emailOrDomain, "@") {
+		var ok bool
+		if _, domain, ok = splitAddress(emailOrDomain); !ok {
 			return ""
 		}
-		return domain
 	}
 
-	// Assume it's already a domain
-	return emailOrDomain
+	if strings.HasPrefix(domain, "[") {
+		return parseAddressLiteral(domain)
+	}
+
+	domain, err := idna.ToASCII(domain)
+	if err != nil {
+		return ""
+	}
+	return domain
+}
+
+// parseAddressLiteral returns the IP address of a lowercased RFC 5321 address
+// literal, e.g. "192.168.0.1" for "[192.168.0.1]" and "::1" for "[ipv6:::1]",
+// or empty string if it is malformed. The "IPv6:" tag is optional.
+func parseAddressLiteral(literal string) string {
+	inner, ok := strings.CutSuffix(literal[1:], "]")
+	if !ok {
+		return ""
+	}
+
+	inner, isV6 := strings.CutPrefix(inner, "ipv6:")
+	addr, err := netip.ParseAddr(inner)
+	if err != nil || addr.Zone() != "" || (isV6 && !addr.Is6()) {
+		return ""
+	}
+	return addr.String()
+}
+
+// IsIPLiteral reports whether domain is an IP address rather than a domain
+// name, as returned by ExtractDomain for "user@127.0.0.1" or
+// "user@[192.168.0.1]".
+func IsIPLiteral(domain string) bool {
+	_, err := netip.ParseAddr(domain)
+	return err == nil
+}
+
+// reservedTLDs are the top-level domains reserved by RFC 2606 for testing
+// and documentation.
+var reservedTLDs = map[string]bool{
+	"test":      true,
+	"example":   true,
+	"invalid":   true,
+	"localhost": true,
+}
+
+// reservedDomains are the second-level example domains reserved by RFC 2606.
+var reservedDomains = map[string]bool{
+	"example.com": true,
+	"example.net": true,
+	"example.org": true,
+}
+
+// IsReservedDomain reports whether domain is reserved by RFC 2606 for testing
+// and documentation, so it can't receive real email: the "test", "example",
+// "invalid" and "localhost" TLDs, "localhost" itself, and example.com,
+// example.net and example.org, along with their subdomains. Such domains
+// commonly appear in placeholder addresses like "user@example.com".
+func IsReservedDomain(domain string) bool {
+	domain = NormalizeDomain(domain)
+	if domain == "" {
+		return false
+	}
+
+	labels := strings.Split(domain, ".")
+	if reservedTLDs[labels[len(labels)-1]] {
+		return true
+	}
+	return len(labels) >= 2 && reservedDomains[strings.Join(labels[len(labels)-2:], ".")]
+}
+
+// ExtractDomainStrict is like ExtractDomain but additionally requires a
+// non-empty local part, returning empty string for inputs such as
+// "@example.com". Input without "@" is treated as a domain, as in
+// ExtractDomain.
+func ExtractDomainStrict(emailOrDomain string) string {
+	emailOrDomain = strings.TrimSpace(emailOrDomain)
+
+	if strings.Contains(emailOrDomain, "@") {
+		local, _, ok := splitAddress(emailOrDomain)
+		if !ok || local == "" {
+			return ""
+		}
+	}
+
+	return ExtractDomain(emailOrDomain)
+}
+
+// splitAddress splits an email address at its single unquoted "@".
+// It reports false if the address has no such "@", more than one, an
+// unterminated quoted string, or an empty domain.
+func splitAddress(address string) (local, domain string, ok bool) {
+	at := -1
+	inQuotes := false
+
+	for i := 0; i < len(address); i++ {
+		switch address[i] {
+		case '\\':
+			if inQuotes {
+				i++ // skip the escaped character
+			}
+		case '"':
+			inQuotes = !inQuotes
+		case '@':
+			if inQuotes {
+				continue
+			}
+			if at != -1 {
+				return "", "", false
+			}
+			at = i
+		}
+	}
+
+	if inQuotes || at == -1 || at == len(address)-1 {
+		return "", "", false
+	}
+
+	return address[:at], address[at+1:], true
 }
 
 // GetDomainHierarchy returns all domain levels to check.
-// For "mail.tempmail.com", it returns ["mail.tempmail.com", "tempmail.com", "com"]
-// We skip single-part TLDs (like "com") as they're not useful for checking.
+// For "mail.tempmail.com", it returns ["mail.tempmail.com", "tempmail.com"].
+// Public suffixes (like "com" or "co.uk") are skipped, so for
+// "mail.example.co.uk" it returns ["mail.example.co.uk", "example.co.uk"],
+// and it returns nil for a bare public suffix.
+// Only the last labels lookups inspect are considered, ten at most, so that
+// pathological inputs with hundreds of labels can't cause excess work.
 func GetDomainHierarchy(domain string) []string {
 	if domain == "" {
 		return nil
@@ -39,19 +174,25 @@ func GetDomainHierarchy(domain string) []string {
 	if len(parts) < 2 {
 		return nil
 	}
+	if len(parts) > trie.MaxLabels {
+		parts = parts[len(parts)-trie.MaxLabels:]
+	}
 
+	suffix := publicSuffix(domain)
 	var hierarchy []string
 	for i := 0; i < len(parts)-1; i++ {
 		subdomain := strings.Join(parts[i:], ".")
-		if subdomain != "" {
-			hierarchy = append(hierarchy, subdomain)
+		if len(subdomain) <= len(suffix) {
+			break
 		}
+		hierarchy = append(hierarchy, subdomain)
 	}
 
 	return hierarchy
 }
 
-// IsValidDomain performs basic domain validation.
+// IsValidDomain performs basic domain validation. Internationalized labels,
+// such as in "münchen.de", are valid if their punycode form is.
 func IsValidDomain(domain string) bool {
 	if domain == "" {
 		return false
@@ -68,6 +209,12 @@ func IsValidDomain(domain string) bool {
 		if part == "" {
 			return false
 		}
+		if !idna.IsASCII(part) {
+			var err error
+			if part, err = idna.ToASCII(part); err != nil {
+				return false
+			}
+		}
 		// Check for invalid characters (basic check)
 		for _, c := range part {
 			if !isValidDomainChar(c) {
@@ -79,6 +226,32 @@ func IsValidDomain(domain string) bool {
 	return true
 }
 
+// NormalizeDomains cleans a list of domains, e.g. before importing it: each
+// entry is normalized with NormalizeDomain and checked with IsValidDomain.
+// valid holds the normalized domains without duplicates, and invalid the
+// entries that were rejected, as given. Both keep the input order. Blank
+// entries are skipped.
+func NormalizeDomains(domains []string) (valid []string, invalid []string) {
+	seen := make(map[string]struct{}, len(domains))
+	for _, entry := range domains {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		domain := NormalizeDomain(entry)
+		if !IsValidDomain(domain) {
+			invalid = append(invalid, entry)
+			continue
+		}
+		if _, ok := seen[domain]; ok {
+			continue
+		}
+		seen[domain] = struct{}{}
+		valid = append(valid, domain)
+	}
+	return valid, invalid
+}
+
 // isValidDomainChar checks if a character is valid in a domain name.
 func isValidDomainChar(c rune) bool {
 	return (c >= 'a' && c <= 'z') ||
@@ -88,6 +261,19 @@ func isValidDomainChar(c rune) bool {
 }
 
 // NormalizeDomain normalizes a domain for consistent storage and lookup.
+// It removes the trailing dot of a fully qualified name, so "example.com."
+// becomes "example.com", and converts internationalized domains to punycode,
+// so "tëmpmail.com" becomes "xn--tmpmail-rya.com". Domains with empty labels,
+// such as "example..com" or ".example.com", and domains that can't be
+// converted are malformed and yield an empty string.
 func NormalizeDomain(domain string) string {
-	return strings.ToLower(strings.TrimSpace(domain))
+	domain, err := idna.ToASCII(strings.TrimSpace(domain))
+	if err != nil {
+		return ""
+	}
+	domain = strings.TrimSuffix(domain, ".")
+	if strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") || strings.Contains(domain, "..") {
+		return ""
+	}
+	return domain
 }
@@ -2,7 +2,10 @@ package disposable
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
 )
 
 func TestExtractDomain(t *testing.T) {
@@ -19,6 +22,31 @@ func TestExtractDomain(t *testing.T) {
 		{"", ""},
 		{"user@", ""},
 		{"@example.com", "example.com"},
+		{`"weird@name"@example.com`, "example.com"},
+		{`"a\"@b"@example.com`, "example.com"},
+		{`"unterminated@example.com`, ""},
+		{"a@b@example.com", ""},
+		{"user@@example.com", ""},
+		// Internationalized domains
+		{"user@münchen.de", "xn--mnchen-3ya.de"},
+		{"user@ПРИМЕР.РФ", "xn--e1afmkfd.xn--p1ai"},
+		{"tëmpmail.com", "xn--tmpmail-rya.com"},
+		{"user@xn--mnchen-3ya.de", "xn--mnchen-3ya.de"},
+		{"user@ｍａｉｌｉｎａｔｏｒ.com", "mailinator.com"},
+		{"user@mu\u0308nchen.de", "xn--mnchen-3ya.de"},
+		{"user@\xff.com", ""},
+		// Address literals
+		{"user@[192.168.0.1]", "192.168.0.1"},
+		{"user@[IPv6:2001:DB8::1]", "2001:db8::1"},
+		{"user@[::1]", "::1"},
+		{"user@127.0.0.1", "127.0.0.1"},
+		{"[10.0.0.1]", "10.0.0.1"},
+		{"user@[192.168.0.1", ""},
+		{"user@[192.168.0.1]x", ""},
+		{"user@[]", ""},
+		{"user@[example.com]", ""},
+		{"user@[IPv6:192.168.0.1]", ""},
+		{"user@[fe80::1%eth0]", ""},
 	}
 
 	for _, tt := range tests {
@@ -31,6 +59,82 @@ func TestExtractDomain(t *testing.T) {
 	}
 }
 
+func TestIsIPLiteral(t *testing.T) {
+	tests := []struct {
+		domain   string
+		expected bool
+	}{
+		{"192.168.0.1", true},
+		{"2001:db8::1", true},
+		{"::1", true},
+		{"example.com", false},
+		{"1.2.3", false},
+		{"[192.168.0.1]", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if result := IsIPLiteral(tt.domain); result != tt.expected {
+			t.Errorf("IsIPLiteral(%q) = %v, want %v", tt.domain, result, tt.expected)
+		}
+	}
+}
+
+func TestIsReservedDomain(t *testing.T) {
+	tests := []struct {
+		domain   string
+		expected bool
+	}{
+		{"example.com", true},
+		{"Example.Org", true},
+		{"mail.example.net", true},
+		{"example", true},
+		{"foo.test", true},
+		{"foo.invalid", true},
+		{"localhost", true},
+		{"mail.localhost", true},
+		{"example.co.uk", false},
+		{"myexample.com", false},
+		{"test.com", false},
+		{"gmail.com", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if result := IsReservedDomain(tt.domain); result != tt.expected {
+			t.Errorf("IsReservedDomain(%q) = %v, want %v", tt.domain, result, tt.expected)
+		}
+	}
+}
+
+func TestExtractDomainStrict(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"user@example.com", "example.com"},
+		{"USER@EXAMPLE.COM", "example.com"},
+		{"  user@example.com  ", "example.com"},
+		{"example.com", "example.com"},
+		{"", ""},
+		{"@x.com", ""},
+		{"  @x.com", ""},
+		{"user@", ""},
+		{"a@b@c.com", ""},
+		{`"weird@name"@example.com`, "example.com"},
+		{`""@example.com`, "example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := ExtractDomainStrict(tt.input)
+			if result != tt.expected {
+				t.Errorf("ExtractDomainStrict(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGetDomainHierarchy(t *testing.T) {
 	tests := []struct {
 		domain   string
@@ -40,6 +144,9 @@ func TestGetDomainHierarchy(t *testing.T) {
 		{"sub.mail.tempmail.com", []string{"sub.mail.tempmail.com", "mail.tempmail.com", "tempmail.com"}},
 		{"example.com", []string{"example.com"}},
 		{"com", nil},
+		{"mail.example.co.uk", []string{"mail.example.co.uk", "example.co.uk"}},
+		{"co.uk", nil},
+		{"user.github.io", []string{"user.github.io"}},
 		{"", nil},
 	}
 
@@ -53,6 +160,21 @@ func TestGetDomainHierarchy(t *testing.T) {
 	}
 }
 
+func TestGetDomainHierarchyMaxLabels(t *testing.T) {
+	domain := strings.Repeat("a.", 198) + "tempmail.com"
+
+	result := GetDomainHierarchy(domain)
+	if len(result) != trie.MaxLabels-1 {
+		t.Fatalf("GetDomainHierarchy returned %d entries, want %d", len(result), trie.MaxLabels-1)
+	}
+	if labels := strings.Count(result[0], ".") + 1; labels != trie.MaxLabels {
+		t.Errorf("Deepest entry has %d labels, want %d", labels, trie.MaxLabels)
+	}
+	if last := result[len(result)-1]; last != "tempmail.com" {
+		t.Errorf("Last entry = %q, want tempmail.com", last)
+	}
+}
+
 func TestIsValidDomain(t *testing.T) {
 	tests := []struct {
 		domain   string
@@ -68,6 +190,11 @@ func TestIsValidDomain(t *testing.T) {
 		{".com", false},
 		{"example.", false},
 		{"exam ple.com", false},
+		{"münchen.de", true},
+		{"пример.рф", true},
+		{"xn--mnchen-3ya.de", true},
+		{"mün chen.de", false},
+		{"\ufffd.com", false},
 	}
 
 	for _, tt := range tests {
@@ -80,6 +207,39 @@ func TestIsValidDomain(t *testing.T) {
 	}
 }
 
+func TestNormalizeDomains(t *testing.T) {
+	input := []string{
+		"Mailinator.COM",
+		"mailinator.com",
+		" yopmail.com. ",
+		"",
+		"  ",
+		"localhost",
+		"exam ple.com",
+		"bad..com",
+		"YOPMAIL.com",
+		"sub.guerrillamail.com",
+		"localhost",
+		"Tëmpmail.com",
+		"xn--tmpmail-rya.com",
+	}
+
+	valid, invalid := NormalizeDomains(input)
+
+	wantValid := []string{"mailinator.com", "yopmail.com", "sub.guerrillamail.com", "xn--tmpmail-rya.com"}
+	wantInvalid := []string{"localhost", "exam ple.com", "bad..com", "localhost"}
+	if !reflect.DeepEqual(valid, wantValid) {
+		t.Errorf("NormalizeDomains() valid = %q, want %q", valid, wantValid)
+	}
+	if !reflect.DeepEqual(invalid, wantInvalid) {
+		t.Errorf("NormalizeDomains() invalid = %q, want %q", invalid, wantInvalid)
+	}
+
+	if valid, invalid := NormalizeDomains(nil); valid != nil || invalid != nil {
+		t.Errorf("NormalizeDomains(nil) = (%q, %q), want (nil, nil)", valid, invalid)
+	}
+}
+
 func TestNormalizeDomain(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -89,6 +249,15 @@ func TestNormalizeDomain(t *testing.T) {
 		{"  example.com  ", "example.com"},
 		{"Example.Com", "example.com"},
 		{"", ""},
+		{"example.com.", "example.com"},
+		{"example..com", ""},
+		{".example.com", ""},
+		{"example.com..", ""},
+		{".", ""},
+		{"Tëmpmail.com", "xn--tmpmail-rya.com"},
+		{"пример.рф.", "xn--e1afmkfd.xn--p1ai"},
+		{"例子。广告", "xn--fsqu00a.xn--4rr70v"},
+		{"\xff.com", ""},
 	}
 
 	for _, tt := range tests {
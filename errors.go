@@ -3,6 +3,8 @@ package disposable
 import (
 	"errors"
 	"fmt"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
 )
 
 // Error types for programmatic error handling.
@@ -11,6 +13,30 @@ import (
 // ErrNotInitialized is returned when operations are attempted before initialization.
 var ErrNotInitialized = errors.New("checker not initialized")
 
+// ErrEmptyInput is returned by strict lookups when the input is empty or
+// contains only whitespace.
+var ErrEmptyInput = errors.New("empty input")
+
+// ErrInvalidDomain is returned by strict lookups when the input doesn't
+// contain a valid domain.
+var ErrInvalidDomain = errors.New("invalid domain")
+
+// ErrInvalidSyntax is returned by ValidateSyntax and Check when an email
+// address is malformed, e.g. "user name@example.com".
+var ErrInvalidSyntax = errors.New("invalid email syntax")
+
+// ErrShutdownTimeout is returned by Close when background work didn't stop
+// within the timeout set with WithShutdownTimeout.
+var ErrShutdownTimeout = errors.New("timed out waiting for background work to stop")
+
+// ErrDataExpired is returned by Healthy and CheckDomain when the data is
+// older than the hard TTL set with WithHardTTL.
+var ErrDataExpired = errors.New("data expired")
+
+// ErrNotAnAddress is returned by VerifyMailbox when the input is a domain or
+// otherwise lacks a mailbox to probe.
+var ErrNotAnAddress = errors.New("not an email address")
+
 // DownloadError represents an error that occurred while downloading data.
 type DownloadError struct {
 	URL        string
@@ -32,7 +58,7 @@ func (e *DownloadError) Unwrap() error {
 // CacheError represents an error related to cache operations.
 type CacheError struct {
 	Path      string
-	Operation string // "read", "write", "create"
+	Operation string // "read", "verify", "write", "create"
 	Err       error
 }
 
@@ -46,7 +72,7 @@ func (e *CacheError) Unwrap() error {
 
 // DeserializationError represents an error deserializing the data file.
 type DeserializationError struct {
-	Source string // "cache" or "download"
+	Source string // "cache", "download", "delta", "bytes" or "mmap"
 	Err    error
 }
 
@@ -58,6 +84,27 @@ func (e *DeserializationError) Unwrap() error {
 	return e.Err
 }
 
+// ChecksumError is returned, wrapped in a DownloadError or CacheError, when
+// a data file doesn't match its published or recorded SHA-256 digest, e.g.
+// because a download was truncated.
+type ChecksumError struct {
+	Source   string // "cache" or "download"
+	Expected string // hex-encoded digest from the checksum file
+	Actual   string // hex-encoded digest of the data
+	Size     int    // size of the data in bytes
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s data (%d bytes): expected sha256 %s, got %s",
+		e.Source, e.Size, e.Expected, e.Actual)
+}
+
+// UnsupportedFormatError is wrapped in a DeserializationError when a data file
+// was written in a newer format than this version of the package can read.
+// Its Version field holds the format version found in the file. Callers can
+// keep using cached data or switch to a URL serving a compatible format.
+type UnsupportedFormatError = trie.UnsupportedFormatError
+
 // InitializationError represents an error during checker initialization.
 type InitializationError struct {
 	Reason string
@@ -75,6 +122,42 @@ func (e *InitializationError) Unwrap() error {
 	return e.Err
 }
 
+// InputError describes one input of a batch that couldn't be checked.
+type InputError struct {
+	Index int    // position of the input in the batch
+	Input string // the input as given
+	Err   error  // underlying error, e.g. ErrInvalidDomain
+}
+
+func (e InputError) Error() string {
+	return fmt.Sprintf("input %d (%q): %v", e.Index, e.Input, e.Err)
+}
+
+func (e InputError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is returned by CheckBatch when some inputs couldn't be checked.
+// Errs lists them in input order.
+type BatchError struct {
+	Errs []InputError
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Errs) == 1 {
+		return "1 input could not be checked: " + e.Errs[0].Error()
+	}
+	return fmt.Sprintf("%d inputs could not be checked, first: %v", len(e.Errs), e.Errs[0])
+}
+
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Errs))
+	for i, err := range e.Errs {
+		errs[i] = err
+	}
+	return errs
+}
+
 // IsDownloadError returns true if the error is a download error.
 func IsDownloadError(err error) bool {
 	var downloadErr *DownloadError
@@ -93,6 +176,20 @@ func IsDeserializationError(err error) bool {
 	return errors.As(err, &deserErr)
 }
 
+// IsUnsupportedFormatError returns true if the error is caused by a data file
+// in an unsupported format.
+func IsUnsupportedFormatError(err error) bool {
+	var formatErr *UnsupportedFormatError
+	return errors.As(err, &formatErr)
+}
+
+// IsChecksumError returns true if the error is caused by data that doesn't
+// match its checksum.
+func IsChecksumError(err error) bool {
+	var checksumErr *ChecksumError
+	return errors.As(err, &checksumErr)
+}
+
 // IsInitializationError returns true if the error is an initialization error.
 func IsInitializationError(err error) bool {
 	var initErr *InitializationError
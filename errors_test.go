@@ -67,6 +67,41 @@ func TestDeserializationError(t *testing.T) {
 	}
 }
 
+func TestChecksumError(t *testing.T) {
+	err := &CacheError{Path: "/tmp/cache/data.bin", Operation: "verify",
+		Err: &ChecksumError{Source: "cache", Expected: "ab12", Actual: "cd34", Size: 42}}
+
+	if !IsChecksumError(err) {
+		t.Error("IsChecksumError should return true for a wrapped ChecksumError")
+	}
+
+	expectedMsg := "cache verify failed for /tmp/cache/data.bin: checksum mismatch for cache data (42 bytes): expected sha256 ab12, got cd34"
+	if err.Error() != expectedMsg {
+		t.Errorf("Error() = %q, want %q", err.Error(), expectedMsg)
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	err := &BatchError{Errs: []InputError{
+		{Index: 2, Input: "", Err: ErrEmptyInput},
+		{Index: 5, Input: "user@", Err: ErrInvalidDomain},
+	}}
+
+	expectedMsg := `2 inputs could not be checked, first: input 2 (""): empty input`
+	if err.Error() != expectedMsg {
+		t.Errorf("Error() = %q, want %q", err.Error(), expectedMsg)
+	}
+
+	if !errors.Is(err, ErrEmptyInput) || !errors.Is(err, ErrInvalidDomain) {
+		t.Error("errors.Is should find every input's error")
+	}
+
+	var inputErr InputError
+	if !errors.As(err, &inputErr) || inputErr.Index != 2 {
+		t.Errorf("errors.As() = %+v, want the first input error", inputErr)
+	}
+}
+
 func TestInitializationError(t *testing.T) {
 	// Test with underlying error
 	underlyingErr := errors.New("network unreachable")
@@ -109,6 +144,12 @@ func TestErrorTypeChecks(t *testing.T) {
 	if IsInitializationError(genericErr) {
 		t.Error("IsInitializationError should return false for non-initialization error")
 	}
+	if IsUnsupportedFormatError(genericErr) {
+		t.Error("IsUnsupportedFormatError should return false for other errors")
+	}
+	if IsChecksumError(genericErr) {
+		t.Error("IsChecksumError should return false for other errors")
+	}
 }
 
 func TestWrappedErrors(t *testing.T) {
@@ -1,3 +1,10 @@
 module github.com/rezmoss/go-is-disposable-email
 
 go 1.25.3
+
+require (
+	golang.org/x/net v0.57.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require golang.org/x/text v0.40.0 // indirect
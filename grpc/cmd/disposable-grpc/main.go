@@ -0,0 +1,239 @@
+// disposable-grpc runs a shared disposable email checker behind the gRPC
+// service defined in grpc/proto/disposable/v1/disposable.proto, so services
+// can use one copy of the data instead of each downloading and caching
+// data.bin. It is a module of its own, so the library doesn't depend on gRPC.
+//
+// The service has the same calls as disposable-serve's JSON API: Check,
+// CheckBatch, Stats and Refresh.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	disposable "github.com/rezmoss/go-is-disposable-email"
+	disposablev1 "github.com/rezmoss/go-is-disposable-email/grpc/proto/disposable/v1"
+)
+
+// options holds the settings for the server.
+type options struct {
+	Addr            string
+	RefreshInterval time.Duration
+	CacheDir        string // Checker cache directory, the default if empty
+	DataURL         string // Data file URL, the default if empty
+	MaxBatch        int    // Most inputs accepted by one CheckBatch call
+	Concurrency     int    // Workers per CheckBatch call, GOMAXPROCS if 0
+	DNSCheck        bool   // Report DNS status in every result
+}
+
+func main() {
+	addr := flag.String("addr", ":9090", "Address to listen on")
+	refresh := flag.Duration("refresh", 24*time.Hour, "How often to download the latest data")
+	cacheDir := flag.String("cache-dir", "", "Directory for the cached data file (default: user cache directory)")
+	dataURL := flag.String("data-url", "", "URL of the data file (default: latest release)")
+	maxBatch := flag.Int("max-batch", 10000, "Maximum number of inputs in one CheckBatch call")
+	concurrency := flag.Int("concurrency", 0, "Workers per CheckBatch call (default: number of CPUs)")
+	dnsCheck := flag.Bool("dns", false, "Report whether each domain has MX or address records")
+	flag.Parse()
+
+	opts := options{
+		Addr:            *addr,
+		RefreshInterval: *refresh,
+		CacheDir:        *cacheDir,
+		DataURL:         *dataURL,
+		MaxBatch:        *maxBatch,
+		Concurrency:     *concurrency,
+		DNSCheck:        *dnsCheck,
+	}
+
+	if err := run(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(opts options) error {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+
+	checkerOpts := []disposable.Option{
+		disposable.WithAutoRefresh(opts.RefreshInterval),
+		disposable.WithLogger(logger),
+	}
+	if opts.CacheDir != "" {
+		checkerOpts = append(checkerOpts, disposable.WithCacheDir(opts.CacheDir))
+	}
+	if opts.DataURL != "" {
+		checkerOpts = append(checkerOpts, disposable.WithDataURL(opts.DataURL))
+	}
+	if opts.DNSCheck {
+		checkerOpts = append(checkerOpts, disposable.WithDNSCheck(nil))
+	}
+
+	checker, err := disposable.New(checkerOpts...)
+	if err != nil {
+		return err
+	}
+	defer checker.Close()
+
+	lis, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return err
+	}
+
+	srv := grpc.NewServer()
+	disposablev1.RegisterDisposableServiceServer(srv, newServer(checker, opts))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		logger.Printf("Listening on %s", lis.Addr())
+		errc <- srv.Serve(lis)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Printf("Shutting down")
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		srv.Stop()
+	}
+	return nil
+}
+
+// server implements DisposableService for a checker.
+type server struct {
+	disposablev1.UnimplementedDisposableServiceServer
+
+	checker *disposable.Checker
+	opts    options
+}
+
+// newServer returns the service served for checker.
+func newServer(checker *disposable.Checker, opts options) *server {
+	return &server{checker: checker, opts: opts}
+}
+
+func (s *server) Check(ctx context.Context, req *disposablev1.CheckRequest) (*disposablev1.CheckResponse, error) {
+	result, err := s.checker.CheckWithContext(ctx, req.GetInput())
+	if err != nil {
+		return nil, checkError(err)
+	}
+	return checkResponse(result), nil
+}
+
+func (s *server) CheckBatch(ctx context.Context, req *disposablev1.CheckBatchRequest) (*disposablev1.CheckBatchResponse, error) {
+	inputs := req.GetInputs()
+	if len(inputs) > s.opts.MaxBatch {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"batch of %d inputs exceeds the limit of %d", len(inputs), s.opts.MaxBatch)
+	}
+
+	results, err := s.checker.CheckBatch(ctx, inputs, disposable.BatchOptions{
+		Concurrency: s.opts.Concurrency,
+		DNSCheck:    req.GetDnsCheck(),
+	})
+	errs := make([]string, len(inputs))
+	var batchErr *disposable.BatchError
+	switch {
+	case errors.As(err, &batchErr):
+		for _, e := range batchErr.Errs {
+			errs[e.Index] = e.Err.Error()
+		}
+	case err != nil:
+		return nil, checkError(err)
+	}
+
+	resp := &disposablev1.CheckBatchResponse{Items: make([]*disposablev1.CheckBatchResponse_Item, len(inputs))}
+	for i, result := range results {
+		item := &disposablev1.CheckBatchResponse_Item{Error: errs[i]}
+		if errs[i] == "" {
+			item.Result = checkResponse(result)
+		}
+		resp.Items[i] = item
+	}
+	return resp, nil
+}
+
+func (s *server) Stats(ctx context.Context, req *disposablev1.StatsRequest) (*disposablev1.StatsResponse, error) {
+	return statsResponse(s.checker.Stats()), nil
+}
+
+func (s *server) Refresh(ctx context.Context, req *disposablev1.RefreshRequest) (*disposablev1.RefreshResponse, error) {
+	if err := s.checker.RefreshWithContext(ctx); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &disposablev1.RefreshResponse{Stats: statsResponse(s.checker.Stats())}, nil
+}
+
+// checkError returns the gRPC status for an error from a check.
+func checkError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, disposable.ErrEmptyInput), errors.Is(err, disposable.ErrInvalidDomain),
+		errors.Is(err, disposable.ErrInvalidSyntax):
+		code = codes.InvalidArgument
+	case errors.Is(err, disposable.ErrDataExpired):
+		code = codes.FailedPrecondition
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	}
+	return status.Error(code, err.Error())
+}
+
+// checkResponse converts a Result. The enums of the service number their
+// values as the Go types do.
+func checkResponse(r disposable.Result) *disposablev1.CheckResponse {
+	return &disposablev1.CheckResponse{
+		Input:      r.Input,
+		Disposable: r.Disposable,
+		Domain:     r.Domain,
+		Rule:       disposablev1.Rule(r.Rule),
+		Matched:    r.Matched,
+		Custom:     r.Custom,
+		Version:    r.Version,
+		Dns:        disposablev1.DNSStatus(r.DNS),
+		Mailbox:    disposablev1.MailboxStatus(r.Mailbox),
+	}
+}
+
+// statsResponse converts Statistics.
+func statsResponse(s disposable.Statistics) *disposablev1.StatsResponse {
+	resp := &disposablev1.StatsResponse{
+		BlocklistCount:          int64(s.BlocklistCount),
+		EffectiveBlocklistCount: int64(s.EffectiveBlocklistCount),
+		AllowlistCount:          int64(s.AllowlistCount),
+		Mode:                    s.Mode.String(),
+		Version:                 s.Version,
+	}
+	if !s.LastUpdated.IsZero() {
+		resp.LastUpdated = timestamppb.New(s.LastUpdated)
+	}
+	return resp
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	disposable "github.com/rezmoss/go-is-disposable-email"
+	disposablev1 "github.com/rezmoss/go-is-disposable-email/grpc/proto/disposable/v1"
+)
+
+// newTestChecker returns a checker loaded from the repository's data.v2.bin,
+// refreshing from a local server. It also returns the number of refreshes
+// served.
+func newTestChecker(t *testing.T) (*disposable.Checker, *atomic.Int32) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "data", "data.v2.bin"))
+	if err != nil {
+		t.Skipf("data/data.v2.bin not available: %v", err)
+	}
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), data, 0644); err != nil {
+		t.Fatalf("Failed to write data.bin: %v", err)
+	}
+
+	checker, err := disposable.New(disposable.WithCacheDir(dir), disposable.WithDataURL(srv.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { checker.Close() })
+	return checker, &hits
+}
+
+// newTestClient serves the service for checker over an in-memory connection
+// and returns a client for it.
+func newTestClient(t *testing.T, checker *disposable.Checker, opts options) disposablev1.DisposableServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	disposablev1.RegisterDisposableServiceServer(srv, newServer(checker, opts))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return disposablev1.NewDisposableServiceClient(conn)
+}
+
+func TestCheck(t *testing.T) {
+	checker, _ := newTestChecker(t)
+	client := newTestClient(t, checker, options{MaxBatch: 10})
+
+	tests := []struct {
+		input      string
+		code       codes.Code
+		disposable bool
+		rule       disposablev1.Rule
+	}{
+		{"user@mailinator.com", codes.OK, true, disposablev1.Rule_RULE_BLOCKLIST},
+		{"user@gmail.com", codes.OK, false, disposablev1.Rule_RULE_UNSPECIFIED},
+		{"guerrillamail.com", codes.OK, true, disposablev1.Rule_RULE_BLOCKLIST},
+		{"", codes.InvalidArgument, false, disposablev1.Rule_RULE_UNSPECIFIED},
+		{"user@", codes.InvalidArgument, false, disposablev1.Rule_RULE_UNSPECIFIED},
+		{"user name@gmail.com", codes.InvalidArgument, false, disposablev1.Rule_RULE_UNSPECIFIED},
+		{"a.@gmail.com", codes.InvalidArgument, false, disposablev1.Rule_RULE_UNSPECIFIED},
+	}
+
+	for _, tt := range tests {
+		resp, err := client.Check(context.Background(), &disposablev1.CheckRequest{Input: tt.input})
+		if code := status.Code(err); code != tt.code {
+			t.Errorf("Check(%q) code = %v, want %v", tt.input, code, tt.code)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if resp.GetDisposable() != tt.disposable || resp.GetRule() != tt.rule {
+			t.Errorf("Check(%q) = %v, %v, want %v, %v", tt.input,
+				resp.GetDisposable(), resp.GetRule(), tt.disposable, tt.rule)
+		}
+		if resp.GetInput() != tt.input || resp.GetVersion() == "" {
+			t.Errorf("Check(%q) input = %q, version = %q, want the input and a version",
+				tt.input, resp.GetInput(), resp.GetVersion())
+		}
+	}
+}
+
+func TestCheckBatch(t *testing.T) {
+	checker, _ := newTestChecker(t)
+	client := newTestClient(t, checker, options{MaxBatch: 3})
+
+	resp, err := client.CheckBatch(context.Background(), &disposablev1.CheckBatchRequest{
+		Inputs: []string{"user@mailinator.com", "", "user@gmail.com"},
+	})
+	if err != nil {
+		t.Fatalf("CheckBatch() error = %v", err)
+	}
+	items := resp.GetItems()
+	if len(items) != 3 {
+		t.Fatalf("CheckBatch() returned %d items, want 3", len(items))
+	}
+	if !items[0].GetResult().GetDisposable() || items[0].GetError() != "" {
+		t.Errorf("items[0] = %v, want a disposable result", items[0])
+	}
+	if items[1].GetResult() != nil || items[1].GetError() == "" {
+		t.Errorf("items[1] = %v, want an error and no result", items[1])
+	}
+	if items[2].GetResult() == nil || items[2].GetResult().GetDisposable() || items[2].GetError() != "" {
+		t.Errorf("items[2] = %v, want a result that isn't disposable", items[2])
+	}
+
+	tests := []struct {
+		name   string
+		inputs []string
+		code   codes.Code
+	}{
+		{"too many", []string{"a@b.com", "c@d.com", "e@f.com", "g@h.com"}, codes.InvalidArgument},
+		{"empty", nil, codes.OK},
+	}
+	for _, tt := range tests {
+		_, err := client.CheckBatch(context.Background(), &disposablev1.CheckBatchRequest{Inputs: tt.inputs})
+		if code := status.Code(err); code != tt.code {
+			t.Errorf("CheckBatch() (%s) code = %v, want %v", tt.name, code, tt.code)
+		}
+	}
+}
+
+func TestStatsAndRefresh(t *testing.T) {
+	checker, hits := newTestChecker(t)
+	client := newTestClient(t, checker, options{MaxBatch: 10})
+
+	stats, err := client.Stats(context.Background(), &disposablev1.StatsRequest{})
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	want := checker.Stats()
+	if stats.GetBlocklistCount() == 0 || stats.GetBlocklistCount() != int64(want.BlocklistCount) {
+		t.Errorf("Stats() blocklist_count = %d, want %d", stats.GetBlocklistCount(), want.BlocklistCount)
+	}
+	if got := stats.GetLastUpdated().AsTime(); !got.Equal(want.LastUpdated) {
+		t.Errorf("Stats() last_updated = %v, want %v", got, want.LastUpdated)
+	}
+	if stats.GetMode() != want.Mode.String() {
+		t.Errorf("Stats() mode = %q, want %q", stats.GetMode(), want.Mode.String())
+	}
+
+	before := hits.Load()
+	resp, err := client.Refresh(context.Background(), &disposablev1.RefreshRequest{})
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if hits.Load() != before+1 {
+		t.Errorf("Refresh() made %d downloads, want 1", hits.Load()-before)
+	}
+	if resp.GetStats().GetBlocklistCount() == 0 {
+		t.Errorf("Refresh() stats = %v, want a positive blocklist count", resp.GetStats())
+	}
+}
+
+func TestCheckError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{disposable.ErrEmptyInput, codes.InvalidArgument},
+		{disposable.ErrInvalidDomain, codes.InvalidArgument},
+		{fmt.Errorf("%w: empty local part", disposable.ErrInvalidSyntax), codes.InvalidArgument},
+		{disposable.ErrDataExpired, codes.FailedPrecondition},
+		{context.Canceled, codes.Canceled},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{os.ErrPermission, codes.Internal},
+	}
+
+	for _, tt := range tests {
+		if got := status.Code(checkError(tt.err)); got != tt.want {
+			t.Errorf("checkError(%v) code = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
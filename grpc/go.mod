@@ -0,0 +1,19 @@
+module github.com/rezmoss/go-is-disposable-email/grpc
+
+go 1.25.3
+
+require (
+	github.com/rezmoss/go-is-disposable-email v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+)
+
+// Built against the library in this repository
+replace github.com/rezmoss/go-is-disposable-email => ../
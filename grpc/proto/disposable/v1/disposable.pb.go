@@ -0,0 +1,844 @@
+// Service definition for running a shared disposable email checker, so
+// services can query one instance instead of each downloading and caching
+// data.bin. Messages mirror the Go API: CheckResponse is disposable.Result,
+// CheckBatch is Checker.CheckBatch and Stats is disposable.Statistics.
+//
+// cmd/disposable-grpc serves it. After editing, regenerate the Go code in
+// this directory with protoc-gen-go and protoc-gen-go-grpc, from grpc/:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//	    proto/disposable/v1/disposable.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: proto/disposable/v1/disposable.proto
+
+package disposablev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Rule int32
+
+const (
+	Rule_RULE_UNSPECIFIED   Rule = 0 // Nothing matched
+	Rule_RULE_BLOCKLIST     Rule = 1
+	Rule_RULE_ALLOWLIST     Rule = 2
+	Rule_RULE_BLOCKED_EMAIL Rule = 3
+	Rule_RULE_ALLOWED_EMAIL Rule = 4
+	Rule_RULE_IP_LITERAL    Rule = 5
+	Rule_RULE_HEURISTIC     Rule = 6
+	Rule_RULE_RESERVED      Rule = 7
+	Rule_RULE_BLOCK_PATTERN Rule = 8
+)
+
+// Enum value maps for Rule.
+var (
+	Rule_name = map[int32]string{
+		0: "RULE_UNSPECIFIED",
+		1: "RULE_BLOCKLIST",
+		2: "RULE_ALLOWLIST",
+		3: "RULE_BLOCKED_EMAIL",
+		4: "RULE_ALLOWED_EMAIL",
+		5: "RULE_IP_LITERAL",
+		6: "RULE_HEURISTIC",
+		7: "RULE_RESERVED",
+		8: "RULE_BLOCK_PATTERN",
+	}
+	Rule_value = map[string]int32{
+		"RULE_UNSPECIFIED":   0,
+		"RULE_BLOCKLIST":     1,
+		"RULE_ALLOWLIST":     2,
+		"RULE_BLOCKED_EMAIL": 3,
+		"RULE_ALLOWED_EMAIL": 4,
+		"RULE_IP_LITERAL":    5,
+		"RULE_HEURISTIC":     6,
+		"RULE_RESERVED":      7,
+		"RULE_BLOCK_PATTERN": 8,
+	}
+)
+
+func (x Rule) Enum() *Rule {
+	p := new(Rule)
+	*p = x
+	return p
+}
+
+func (x Rule) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Rule) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_disposable_v1_disposable_proto_enumTypes[0].Descriptor()
+}
+
+func (Rule) Type() protoreflect.EnumType {
+	return &file_proto_disposable_v1_disposable_proto_enumTypes[0]
+}
+
+func (x Rule) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Rule.Descriptor instead.
+func (Rule) EnumDescriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{0}
+}
+
+type DNSStatus int32
+
+const (
+	DNSStatus_DNS_STATUS_UNKNOWN   DNSStatus = 0 // Not looked up, or the lookup failed
+	DNSStatus_DNS_STATUS_MX        DNSStatus = 1
+	DNSStatus_DNS_STATUS_ADDRESS   DNSStatus = 2
+	DNSStatus_DNS_STATUS_NOT_FOUND DNSStatus = 3
+)
+
+// Enum value maps for DNSStatus.
+var (
+	DNSStatus_name = map[int32]string{
+		0: "DNS_STATUS_UNKNOWN",
+		1: "DNS_STATUS_MX",
+		2: "DNS_STATUS_ADDRESS",
+		3: "DNS_STATUS_NOT_FOUND",
+	}
+	DNSStatus_value = map[string]int32{
+		"DNS_STATUS_UNKNOWN":   0,
+		"DNS_STATUS_MX":        1,
+		"DNS_STATUS_ADDRESS":   2,
+		"DNS_STATUS_NOT_FOUND": 3,
+	}
+)
+
+func (x DNSStatus) Enum() *DNSStatus {
+	p := new(DNSStatus)
+	*p = x
+	return p
+}
+
+func (x DNSStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DNSStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_disposable_v1_disposable_proto_enumTypes[1].Descriptor()
+}
+
+func (DNSStatus) Type() protoreflect.EnumType {
+	return &file_proto_disposable_v1_disposable_proto_enumTypes[1]
+}
+
+func (x DNSStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DNSStatus.Descriptor instead.
+func (DNSStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{1}
+}
+
+type MailboxStatus int32
+
+const (
+	MailboxStatus_MAILBOX_STATUS_UNKNOWN   MailboxStatus = 0 // Not probed, or the probe was inconclusive
+	MailboxStatus_MAILBOX_STATUS_EXISTS    MailboxStatus = 1
+	MailboxStatus_MAILBOX_STATUS_NOT_FOUND MailboxStatus = 2
+)
+
+// Enum value maps for MailboxStatus.
+var (
+	MailboxStatus_name = map[int32]string{
+		0: "MAILBOX_STATUS_UNKNOWN",
+		1: "MAILBOX_STATUS_EXISTS",
+		2: "MAILBOX_STATUS_NOT_FOUND",
+	}
+	MailboxStatus_value = map[string]int32{
+		"MAILBOX_STATUS_UNKNOWN":   0,
+		"MAILBOX_STATUS_EXISTS":    1,
+		"MAILBOX_STATUS_NOT_FOUND": 2,
+	}
+)
+
+func (x MailboxStatus) Enum() *MailboxStatus {
+	p := new(MailboxStatus)
+	*p = x
+	return p
+}
+
+func (x MailboxStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MailboxStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_disposable_v1_disposable_proto_enumTypes[2].Descriptor()
+}
+
+func (MailboxStatus) Type() protoreflect.EnumType {
+	return &file_proto_disposable_v1_disposable_proto_enumTypes[2]
+}
+
+func (x MailboxStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MailboxStatus.Descriptor instead.
+func (MailboxStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{2}
+}
+
+type CheckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Input         string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"` // Email address or domain
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckRequest) Reset() {
+	*x = CheckRequest{}
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckRequest) ProtoMessage() {}
+
+func (x *CheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckRequest.ProtoReflect.Descriptor instead.
+func (*CheckRequest) Descriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CheckRequest) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+type CheckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Input         string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	Disposable    bool                   `protobuf:"varint,2,opt,name=disposable,proto3" json:"disposable,omitempty"`
+	Domain        string                 `protobuf:"bytes,3,opt,name=domain,proto3" json:"domain,omitempty"`                      // Normalized domain that was checked
+	Rule          Rule                   `protobuf:"varint,4,opt,name=rule,proto3,enum=disposable.v1.Rule" json:"rule,omitempty"` // List whose entry decided the result
+	Matched       string                 `protobuf:"bytes,5,opt,name=matched,proto3" json:"matched,omitempty"`                    // Entry that decided the result
+	Custom        bool                   `protobuf:"varint,6,opt,name=custom,proto3" json:"custom,omitempty"`                     // Whether matched is a custom entry
+	Version       string                 `protobuf:"bytes,7,opt,name=version,proto3" json:"version,omitempty"`                    // Version of the data the lookup used
+	Dns           DNSStatus              `protobuf:"varint,8,opt,name=dns,proto3,enum=disposable.v1.DNSStatus" json:"dns,omitempty"`
+	Mailbox       MailboxStatus          `protobuf:"varint,9,opt,name=mailbox,proto3,enum=disposable.v1.MailboxStatus" json:"mailbox,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckResponse) Reset() {
+	*x = CheckResponse{}
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckResponse) ProtoMessage() {}
+
+func (x *CheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckResponse.ProtoReflect.Descriptor instead.
+func (*CheckResponse) Descriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckResponse) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetDisposable() bool {
+	if x != nil {
+		return x.Disposable
+	}
+	return false
+}
+
+func (x *CheckResponse) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetRule() Rule {
+	if x != nil {
+		return x.Rule
+	}
+	return Rule_RULE_UNSPECIFIED
+}
+
+func (x *CheckResponse) GetMatched() string {
+	if x != nil {
+		return x.Matched
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetCustom() bool {
+	if x != nil {
+		return x.Custom
+	}
+	return false
+}
+
+func (x *CheckResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetDns() DNSStatus {
+	if x != nil {
+		return x.Dns
+	}
+	return DNSStatus_DNS_STATUS_UNKNOWN
+}
+
+func (x *CheckResponse) GetMailbox() MailboxStatus {
+	if x != nil {
+		return x.Mailbox
+	}
+	return MailboxStatus_MAILBOX_STATUS_UNKNOWN
+}
+
+type CheckBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Inputs        []string               `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	DnsCheck      bool                   `protobuf:"varint,2,opt,name=dns_check,json=dnsCheck,proto3" json:"dns_check,omitempty"` // Also look up DNS for every input
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckBatchRequest) Reset() {
+	*x = CheckBatchRequest{}
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckBatchRequest) ProtoMessage() {}
+
+func (x *CheckBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckBatchRequest.ProtoReflect.Descriptor instead.
+func (*CheckBatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CheckBatchRequest) GetInputs() []string {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+func (x *CheckBatchRequest) GetDnsCheck() bool {
+	if x != nil {
+		return x.DnsCheck
+	}
+	return false
+}
+
+type CheckBatchResponse struct {
+	state         protoimpl.MessageState     `protogen:"open.v1"`
+	Items         []*CheckBatchResponse_Item `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"` // One per input, in order
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckBatchResponse) Reset() {
+	*x = CheckBatchResponse{}
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckBatchResponse) ProtoMessage() {}
+
+func (x *CheckBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckBatchResponse.ProtoReflect.Descriptor instead.
+func (*CheckBatchResponse) Descriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CheckBatchResponse) GetItems() []*CheckBatchResponse_Item {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{4}
+}
+
+type StatsResponse struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	BlocklistCount          int64                  `protobuf:"varint,1,opt,name=blocklist_count,json=blocklistCount,proto3" json:"blocklist_count,omitempty"`
+	EffectiveBlocklistCount int64                  `protobuf:"varint,2,opt,name=effective_blocklist_count,json=effectiveBlocklistCount,proto3" json:"effective_blocklist_count,omitempty"`
+	AllowlistCount          int64                  `protobuf:"varint,3,opt,name=allowlist_count,json=allowlistCount,proto3" json:"allowlist_count,omitempty"`
+	LastUpdated             *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_updated,json=lastUpdated,proto3" json:"last_updated,omitempty"`
+	Mode                    string                 `protobuf:"bytes,5,opt,name=mode,proto3" json:"mode,omitempty"`
+	Version                 string                 `protobuf:"bytes,6,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StatsResponse) GetBlocklistCount() int64 {
+	if x != nil {
+		return x.BlocklistCount
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetEffectiveBlocklistCount() int64 {
+	if x != nil {
+		return x.EffectiveBlocklistCount
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetAllowlistCount() int64 {
+	if x != nil {
+		return x.AllowlistCount
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLastUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastUpdated
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *StatsResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type RefreshRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshRequest) Reset() {
+	*x = RefreshRequest{}
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshRequest) ProtoMessage() {}
+
+func (x *RefreshRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshRequest.ProtoReflect.Descriptor instead.
+func (*RefreshRequest) Descriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{6}
+}
+
+type RefreshResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stats         *StatsResponse         `protobuf:"bytes,1,opt,name=stats,proto3" json:"stats,omitempty"` // Stats after the refresh
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshResponse) Reset() {
+	*x = RefreshResponse{}
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshResponse) ProtoMessage() {}
+
+func (x *RefreshResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshResponse.ProtoReflect.Descriptor instead.
+func (*RefreshResponse) Descriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RefreshResponse) GetStats() *StatsResponse {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+type CheckBatchResponse_Item struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Result        *CheckResponse         `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"` // Set if the input couldn't be checked
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckBatchResponse_Item) Reset() {
+	*x = CheckBatchResponse_Item{}
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckBatchResponse_Item) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckBatchResponse_Item) ProtoMessage() {}
+
+func (x *CheckBatchResponse_Item) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disposable_v1_disposable_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckBatchResponse_Item.ProtoReflect.Descriptor instead.
+func (*CheckBatchResponse_Item) Descriptor() ([]byte, []int) {
+	return file_proto_disposable_v1_disposable_proto_rawDescGZIP(), []int{3, 0}
+}
+
+func (x *CheckBatchResponse_Item) GetResult() *CheckResponse {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *CheckBatchResponse_Item) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_proto_disposable_v1_disposable_proto protoreflect.FileDescriptor
+
+const file_proto_disposable_v1_disposable_proto_rawDesc = "" +
+	"\n" +
+	"$proto/disposable/v1/disposable.proto\x12\rdisposable.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"$\n" +
+	"\fCheckRequest\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\"\xb6\x02\n" +
+	"\rCheckResponse\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\x12\x1e\n" +
+	"\n" +
+	"disposable\x18\x02 \x01(\bR\n" +
+	"disposable\x12\x16\n" +
+	"\x06domain\x18\x03 \x01(\tR\x06domain\x12'\n" +
+	"\x04rule\x18\x04 \x01(\x0e2\x13.disposable.v1.RuleR\x04rule\x12\x18\n" +
+	"\amatched\x18\x05 \x01(\tR\amatched\x12\x16\n" +
+	"\x06custom\x18\x06 \x01(\bR\x06custom\x12\x18\n" +
+	"\aversion\x18\a \x01(\tR\aversion\x12*\n" +
+	"\x03dns\x18\b \x01(\x0e2\x18.disposable.v1.DNSStatusR\x03dns\x126\n" +
+	"\amailbox\x18\t \x01(\x0e2\x1c.disposable.v1.MailboxStatusR\amailbox\"H\n" +
+	"\x11CheckBatchRequest\x12\x16\n" +
+	"\x06inputs\x18\x01 \x03(\tR\x06inputs\x12\x1b\n" +
+	"\tdns_check\x18\x02 \x01(\bR\bdnsCheck\"\xa6\x01\n" +
+	"\x12CheckBatchResponse\x12<\n" +
+	"\x05items\x18\x01 \x03(\v2&.disposable.v1.CheckBatchResponse.ItemR\x05items\x1aR\n" +
+	"\x04Item\x124\n" +
+	"\x06result\x18\x01 \x01(\v2\x1c.disposable.v1.CheckResponseR\x06result\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\x0e\n" +
+	"\fStatsRequest\"\x8a\x02\n" +
+	"\rStatsResponse\x12'\n" +
+	"\x0fblocklist_count\x18\x01 \x01(\x03R\x0eblocklistCount\x12:\n" +
+	"\x19effective_blocklist_count\x18\x02 \x01(\x03R\x17effectiveBlocklistCount\x12'\n" +
+	"\x0fallowlist_count\x18\x03 \x01(\x03R\x0eallowlistCount\x12=\n" +
+	"\flast_updated\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vlastUpdated\x12\x12\n" +
+	"\x04mode\x18\x05 \x01(\tR\x04mode\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\tR\aversion\"\x10\n" +
+	"\x0eRefreshRequest\"E\n" +
+	"\x0fRefreshResponse\x122\n" +
+	"\x05stats\x18\x01 \x01(\v2\x1c.disposable.v1.StatsResponseR\x05stats*\xc8\x01\n" +
+	"\x04Rule\x12\x14\n" +
+	"\x10RULE_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eRULE_BLOCKLIST\x10\x01\x12\x12\n" +
+	"\x0eRULE_ALLOWLIST\x10\x02\x12\x16\n" +
+	"\x12RULE_BLOCKED_EMAIL\x10\x03\x12\x16\n" +
+	"\x12RULE_ALLOWED_EMAIL\x10\x04\x12\x13\n" +
+	"\x0fRULE_IP_LITERAL\x10\x05\x12\x12\n" +
+	"\x0eRULE_HEURISTIC\x10\x06\x12\x11\n" +
+	"\rRULE_RESERVED\x10\a\x12\x16\n" +
+	"\x12RULE_BLOCK_PATTERN\x10\b*h\n" +
+	"\tDNSStatus\x12\x16\n" +
+	"\x12DNS_STATUS_UNKNOWN\x10\x00\x12\x11\n" +
+	"\rDNS_STATUS_MX\x10\x01\x12\x16\n" +
+	"\x12DNS_STATUS_ADDRESS\x10\x02\x12\x18\n" +
+	"\x14DNS_STATUS_NOT_FOUND\x10\x03*d\n" +
+	"\rMailboxStatus\x12\x1a\n" +
+	"\x16MAILBOX_STATUS_UNKNOWN\x10\x00\x12\x19\n" +
+	"\x15MAILBOX_STATUS_EXISTS\x10\x01\x12\x1c\n" +
+	"\x18MAILBOX_STATUS_NOT_FOUND\x10\x022\xb8\x02\n" +
+	"\x11DisposableService\x12B\n" +
+	"\x05Check\x12\x1b.disposable.v1.CheckRequest\x1a\x1c.disposable.v1.CheckResponse\x12Q\n" +
+	"\n" +
+	"CheckBatch\x12 .disposable.v1.CheckBatchRequest\x1a!.disposable.v1.CheckBatchResponse\x12B\n" +
+	"\x05Stats\x12\x1b.disposable.v1.StatsRequest\x1a\x1c.disposable.v1.StatsResponse\x12H\n" +
+	"\aRefresh\x12\x1d.disposable.v1.RefreshRequest\x1a\x1e.disposable.v1.RefreshResponseBQZOgithub.com/rezmoss/go-is-disposable-email/grpc/proto/disposable/v1;disposablev1b\x06proto3"
+
+var (
+	file_proto_disposable_v1_disposable_proto_rawDescOnce sync.Once
+	file_proto_disposable_v1_disposable_proto_rawDescData []byte
+)
+
+func file_proto_disposable_v1_disposable_proto_rawDescGZIP() []byte {
+	file_proto_disposable_v1_disposable_proto_rawDescOnce.Do(func() {
+		file_proto_disposable_v1_disposable_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_disposable_v1_disposable_proto_rawDesc), len(file_proto_disposable_v1_disposable_proto_rawDesc)))
+	})
+	return file_proto_disposable_v1_disposable_proto_rawDescData
+}
+
+var file_proto_disposable_v1_disposable_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_proto_disposable_v1_disposable_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_proto_disposable_v1_disposable_proto_goTypes = []any{
+	(Rule)(0),                       // 0: disposable.v1.Rule
+	(DNSStatus)(0),                  // 1: disposable.v1.DNSStatus
+	(MailboxStatus)(0),              // 2: disposable.v1.MailboxStatus
+	(*CheckRequest)(nil),            // 3: disposable.v1.CheckRequest
+	(*CheckResponse)(nil),           // 4: disposable.v1.CheckResponse
+	(*CheckBatchRequest)(nil),       // 5: disposable.v1.CheckBatchRequest
+	(*CheckBatchResponse)(nil),      // 6: disposable.v1.CheckBatchResponse
+	(*StatsRequest)(nil),            // 7: disposable.v1.StatsRequest
+	(*StatsResponse)(nil),           // 8: disposable.v1.StatsResponse
+	(*RefreshRequest)(nil),          // 9: disposable.v1.RefreshRequest
+	(*RefreshResponse)(nil),         // 10: disposable.v1.RefreshResponse
+	(*CheckBatchResponse_Item)(nil), // 11: disposable.v1.CheckBatchResponse.Item
+	(*timestamppb.Timestamp)(nil),   // 12: google.protobuf.Timestamp
+}
+var file_proto_disposable_v1_disposable_proto_depIdxs = []int32{
+	0,  // 0: disposable.v1.CheckResponse.rule:type_name -> disposable.v1.Rule
+	1,  // 1: disposable.v1.CheckResponse.dns:type_name -> disposable.v1.DNSStatus
+	2,  // 2: disposable.v1.CheckResponse.mailbox:type_name -> disposable.v1.MailboxStatus
+	11, // 3: disposable.v1.CheckBatchResponse.items:type_name -> disposable.v1.CheckBatchResponse.Item
+	12, // 4: disposable.v1.StatsResponse.last_updated:type_name -> google.protobuf.Timestamp
+	8,  // 5: disposable.v1.RefreshResponse.stats:type_name -> disposable.v1.StatsResponse
+	4,  // 6: disposable.v1.CheckBatchResponse.Item.result:type_name -> disposable.v1.CheckResponse
+	3,  // 7: disposable.v1.DisposableService.Check:input_type -> disposable.v1.CheckRequest
+	5,  // 8: disposable.v1.DisposableService.CheckBatch:input_type -> disposable.v1.CheckBatchRequest
+	7,  // 9: disposable.v1.DisposableService.Stats:input_type -> disposable.v1.StatsRequest
+	9,  // 10: disposable.v1.DisposableService.Refresh:input_type -> disposable.v1.RefreshRequest
+	4,  // 11: disposable.v1.DisposableService.Check:output_type -> disposable.v1.CheckResponse
+	6,  // 12: disposable.v1.DisposableService.CheckBatch:output_type -> disposable.v1.CheckBatchResponse
+	8,  // 13: disposable.v1.DisposableService.Stats:output_type -> disposable.v1.StatsResponse
+	10, // 14: disposable.v1.DisposableService.Refresh:output_type -> disposable.v1.RefreshResponse
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_proto_disposable_v1_disposable_proto_init() }
+func file_proto_disposable_v1_disposable_proto_init() {
+	if File_proto_disposable_v1_disposable_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_disposable_v1_disposable_proto_rawDesc), len(file_proto_disposable_v1_disposable_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_disposable_v1_disposable_proto_goTypes,
+		DependencyIndexes: file_proto_disposable_v1_disposable_proto_depIdxs,
+		EnumInfos:         file_proto_disposable_v1_disposable_proto_enumTypes,
+		MessageInfos:      file_proto_disposable_v1_disposable_proto_msgTypes,
+	}.Build()
+	File_proto_disposable_v1_disposable_proto = out.File
+	file_proto_disposable_v1_disposable_proto_goTypes = nil
+	file_proto_disposable_v1_disposable_proto_depIdxs = nil
+}
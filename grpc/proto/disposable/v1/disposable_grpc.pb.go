@@ -0,0 +1,263 @@
+// Service definition for running a shared disposable email checker, so
+// services can query one instance instead of each downloading and caching
+// data.bin. Messages mirror the Go API: CheckResponse is disposable.Result,
+// CheckBatch is Checker.CheckBatch and Stats is disposable.Statistics.
+//
+// cmd/disposable-grpc serves it. After editing, regenerate the Go code in
+// this directory with protoc-gen-go and protoc-gen-go-grpc, from grpc/:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//	    proto/disposable/v1/disposable.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             (unknown)
+// source: proto/disposable/v1/disposable.proto
+
+package disposablev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DisposableService_Check_FullMethodName      = "/disposable.v1.DisposableService/Check"
+	DisposableService_CheckBatch_FullMethodName = "/disposable.v1.DisposableService/CheckBatch"
+	DisposableService_Stats_FullMethodName      = "/disposable.v1.DisposableService/Stats"
+	DisposableService_Refresh_FullMethodName    = "/disposable.v1.DisposableService/Refresh"
+)
+
+// DisposableServiceClient is the client API for DisposableService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DisposableServiceClient interface {
+	// Check reports whether an email or domain is disposable. Empty or
+	// malformed input fails with INVALID_ARGUMENT, expired data with
+	// FAILED_PRECONDITION.
+	Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error)
+	// CheckBatch checks many inputs, returning one result per input in the
+	// same order. Inputs that can't be checked get an error instead of
+	// failing the call.
+	CheckBatch(ctx context.Context, in *CheckBatchRequest, opts ...grpc.CallOption) (*CheckBatchResponse, error)
+	// Stats reports the size and version of the loaded data.
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	// Refresh downloads the latest data.
+	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error)
+}
+
+type disposableServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDisposableServiceClient(cc grpc.ClientConnInterface) DisposableServiceClient {
+	return &disposableServiceClient{cc}
+}
+
+func (c *disposableServiceClient) Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckResponse)
+	err := c.cc.Invoke(ctx, DisposableService_Check_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *disposableServiceClient) CheckBatch(ctx context.Context, in *CheckBatchRequest, opts ...grpc.CallOption) (*CheckBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckBatchResponse)
+	err := c.cc.Invoke(ctx, DisposableService_CheckBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *disposableServiceClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, DisposableService_Stats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *disposableServiceClient) Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RefreshResponse)
+	err := c.cc.Invoke(ctx, DisposableService_Refresh_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DisposableServiceServer is the server API for DisposableService service.
+// All implementations must embed UnimplementedDisposableServiceServer
+// for forward compatibility.
+type DisposableServiceServer interface {
+	// Check reports whether an email or domain is disposable. Empty or
+	// malformed input fails with INVALID_ARGUMENT, expired data with
+	// FAILED_PRECONDITION.
+	Check(context.Context, *CheckRequest) (*CheckResponse, error)
+	// CheckBatch checks many inputs, returning one result per input in the
+	// same order. Inputs that can't be checked get an error instead of
+	// failing the call.
+	CheckBatch(context.Context, *CheckBatchRequest) (*CheckBatchResponse, error)
+	// Stats reports the size and version of the loaded data.
+	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
+	// Refresh downloads the latest data.
+	Refresh(context.Context, *RefreshRequest) (*RefreshResponse, error)
+	mustEmbedUnimplementedDisposableServiceServer()
+}
+
+// UnimplementedDisposableServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDisposableServiceServer struct{}
+
+func (UnimplementedDisposableServiceServer) Check(context.Context, *CheckRequest) (*CheckResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Check not implemented")
+}
+func (UnimplementedDisposableServiceServer) CheckBatch(context.Context, *CheckBatchRequest) (*CheckBatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CheckBatch not implemented")
+}
+func (UnimplementedDisposableServiceServer) Stats(context.Context, *StatsRequest) (*StatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Stats not implemented")
+}
+func (UnimplementedDisposableServiceServer) Refresh(context.Context, *RefreshRequest) (*RefreshResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Refresh not implemented")
+}
+func (UnimplementedDisposableServiceServer) mustEmbedUnimplementedDisposableServiceServer() {}
+func (UnimplementedDisposableServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeDisposableServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DisposableServiceServer will
+// result in compilation errors.
+type UnsafeDisposableServiceServer interface {
+	mustEmbedUnimplementedDisposableServiceServer()
+}
+
+func RegisterDisposableServiceServer(s grpc.ServiceRegistrar, srv DisposableServiceServer) {
+	// If the following call panics, it indicates UnimplementedDisposableServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DisposableService_ServiceDesc, srv)
+}
+
+func _DisposableService_Check_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DisposableServiceServer).Check(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DisposableService_Check_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DisposableServiceServer).Check(ctx, req.(*CheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DisposableService_CheckBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DisposableServiceServer).CheckBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DisposableService_CheckBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DisposableServiceServer).CheckBatch(ctx, req.(*CheckBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DisposableService_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DisposableServiceServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DisposableService_Stats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DisposableServiceServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DisposableService_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DisposableServiceServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DisposableService_Refresh_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DisposableServiceServer).Refresh(ctx, req.(*RefreshRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DisposableService_ServiceDesc is the grpc.ServiceDesc for DisposableService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DisposableService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "disposable.v1.DisposableService",
+	HandlerType: (*DisposableServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Check",
+			Handler:    _DisposableService_Check_Handler,
+		},
+		{
+			MethodName: "CheckBatch",
+			Handler:    _DisposableService_CheckBatch_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _DisposableService_Stats_Handler,
+		},
+		{
+			MethodName: "Refresh",
+			Handler:    _DisposableService_Refresh_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/disposable/v1/disposable.proto",
+}
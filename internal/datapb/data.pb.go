@@ -0,0 +1,553 @@
+// Schema of data files in format 2, published as data.v2.bin.
+//
+// data.v2.bin is gzip-compressed. Its decompressed contents are the four
+// bytes "DEPB" followed by a DataFile message. To inspect a data file:
+//
+//   gunzip -c data.v2.bin | tail -c +5 | protoc --decode=disposable.DataFile data/data.proto
+//
+// Files in format 1 are gob-encoded and don't start with "DEPB"; only the Go
+// package reads them. Releases still publish the data in format 1 as
+// data.bin, which versions of the package before format 2 download.
+//
+// data.v2.bin.delta, which turns the data of the previous release into that
+// of the release it is published with, is compressed the same way and holds "DEDL" followed by a Delta
+// message:
+//
+//   gunzip -c data.v2.bin.delta | tail -c +5 | protoc --decode=disposable.Delta data/data.proto
+//
+// Within format 2, fields are only ever added, never renumbered or changed,
+// so readers skip fields they don't know and keep reading newer files.
+//
+// After editing, regenerate the Go code in internal/datapb with
+// protoc-gen-go, from the repository root:
+//
+//   protoc --go_out=. --go_opt=module=github.com/rezmoss/go-is-disposable-email data/data.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: data/data.proto
+
+package datapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// The start of any data file or delta, decoded to learn its format version
+// before the rest. Every format keeps the version in field 1.
+type FormatHeader struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FormatHeader) Reset() {
+	*x = FormatHeader{}
+	mi := &file_data_data_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FormatHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FormatHeader) ProtoMessage() {}
+
+func (x *FormatHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_data_data_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FormatHeader.ProtoReflect.Descriptor instead.
+func (*FormatHeader) Descriptor() ([]byte, []int) {
+	return file_data_data_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FormatHeader) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type DataFile struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Format version, "2.<minor>"
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// When the data was generated
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Number of blocklist domains
+	DomainCount int64 `protobuf:"varint,3,opt,name=domain_count,json=domainCount,proto3" json:"domain_count,omitempty"`
+	// Blocked and allowed domains, lowercase and in punycode. A domain also
+	// covers its subdomains. Sorted by reversed domain.
+	Blocklist []string `protobuf:"bytes,4,rep,name=blocklist,proto3" json:"blocklist,omitempty"`
+	Allowlist []string `protobuf:"bytes,5,rep,name=allowlist,proto3" json:"allowlist,omitempty"`
+	// Number of sources listing each blocklist domain. Optional.
+	SourceCounts map[string]int64 `protobuf:"bytes,6,rep,name=source_counts,json=sourceCounts,proto3" json:"source_counts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Domains of well-known mail providers, most popular first. Optional.
+	Providers []string `protobuf:"bytes,7,rep,name=providers,proto3" json:"providers,omitempty"`
+	// How providers canonicalize addresses. Optional.
+	ProviderRules []*ProviderRule `protobuf:"bytes,8,rep,name=provider_rules,json=providerRules,proto3" json:"provider_rules,omitempty"`
+	// Wildcard patterns such as "*.temp-mail.*", where "*" matches any run of
+	// characters including dots, matched alongside the lists. Optional.
+	BlockPatterns []string `protobuf:"bytes,9,rep,name=block_patterns,json=blockPatterns,proto3" json:"block_patterns,omitempty"`
+	AllowPatterns []string `protobuf:"bytes,10,rep,name=allow_patterns,json=allowPatterns,proto3" json:"allow_patterns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataFile) Reset() {
+	*x = DataFile{}
+	mi := &file_data_data_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataFile) ProtoMessage() {}
+
+func (x *DataFile) ProtoReflect() protoreflect.Message {
+	mi := &file_data_data_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataFile.ProtoReflect.Descriptor instead.
+func (*DataFile) Descriptor() ([]byte, []int) {
+	return file_data_data_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DataFile) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *DataFile) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *DataFile) GetDomainCount() int64 {
+	if x != nil {
+		return x.DomainCount
+	}
+	return 0
+}
+
+func (x *DataFile) GetBlocklist() []string {
+	if x != nil {
+		return x.Blocklist
+	}
+	return nil
+}
+
+func (x *DataFile) GetAllowlist() []string {
+	if x != nil {
+		return x.Allowlist
+	}
+	return nil
+}
+
+func (x *DataFile) GetSourceCounts() map[string]int64 {
+	if x != nil {
+		return x.SourceCounts
+	}
+	return nil
+}
+
+func (x *DataFile) GetProviders() []string {
+	if x != nil {
+		return x.Providers
+	}
+	return nil
+}
+
+func (x *DataFile) GetProviderRules() []*ProviderRule {
+	if x != nil {
+		return x.ProviderRules
+	}
+	return nil
+}
+
+func (x *DataFile) GetBlockPatterns() []string {
+	if x != nil {
+		return x.BlockPatterns
+	}
+	return nil
+}
+
+func (x *DataFile) GetAllowPatterns() []string {
+	if x != nil {
+		return x.AllowPatterns
+	}
+	return nil
+}
+
+type ProviderRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Domains of the provider; the first is the canonical one
+	Domains []string `protobuf:"bytes,1,rep,name=domains,proto3" json:"domains,omitempty"`
+	// Characters that start a subaddress tag, e.g. "+"
+	Separators string `protobuf:"bytes,2,opt,name=separators,proto3" json:"separators,omitempty"`
+	// Whether dots in the local part are ignored
+	IgnoreDots    bool `protobuf:"varint,3,opt,name=ignore_dots,json=ignoreDots,proto3" json:"ignore_dots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProviderRule) Reset() {
+	*x = ProviderRule{}
+	mi := &file_data_data_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProviderRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProviderRule) ProtoMessage() {}
+
+func (x *ProviderRule) ProtoReflect() protoreflect.Message {
+	mi := &file_data_data_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProviderRule.ProtoReflect.Descriptor instead.
+func (*ProviderRule) Descriptor() ([]byte, []int) {
+	return file_data_data_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ProviderRule) GetDomains() []string {
+	if x != nil {
+		return x.Domains
+	}
+	return nil
+}
+
+func (x *ProviderRule) GetSeparators() string {
+	if x != nil {
+		return x.Separators
+	}
+	return ""
+}
+
+func (x *ProviderRule) GetIgnoreDots() bool {
+	if x != nil {
+		return x.IgnoreDots
+	}
+	return false
+}
+
+// Changes from one data file to the next. Applying it to the data file
+// created at base_created_at gives the one created at created_at.
+type Delta struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Format version, as for data files
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// When the data the delta applies to and the resulting data were generated
+	BaseCreatedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=base_created_at,json=baseCreatedAt,proto3" json:"base_created_at,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Number of blocklist domains after applying the delta
+	DomainCount int64 `protobuf:"varint,4,opt,name=domain_count,json=domainCount,proto3" json:"domain_count,omitempty"`
+	// Domains added to and removed from each list
+	AddedBlocklist   []string `protobuf:"bytes,5,rep,name=added_blocklist,json=addedBlocklist,proto3" json:"added_blocklist,omitempty"`
+	RemovedBlocklist []string `protobuf:"bytes,6,rep,name=removed_blocklist,json=removedBlocklist,proto3" json:"removed_blocklist,omitempty"`
+	AddedAllowlist   []string `protobuf:"bytes,7,rep,name=added_allowlist,json=addedAllowlist,proto3" json:"added_allowlist,omitempty"`
+	RemovedAllowlist []string `protobuf:"bytes,8,rep,name=removed_allowlist,json=removedAllowlist,proto3" json:"removed_allowlist,omitempty"`
+	// Whether the resulting data has source counts, and those of its
+	// blocklist domains that are new or changed since the base
+	HasSourceCounts bool             `protobuf:"varint,9,opt,name=has_source_counts,json=hasSourceCounts,proto3" json:"has_source_counts,omitempty"`
+	SourceCounts    map[string]int64 `protobuf:"bytes,10,rep,name=source_counts,json=sourceCounts,proto3" json:"source_counts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Metadata of the resulting data, carried whole
+	Providers     []string        `protobuf:"bytes,11,rep,name=providers,proto3" json:"providers,omitempty"`
+	ProviderRules []*ProviderRule `protobuf:"bytes,12,rep,name=provider_rules,json=providerRules,proto3" json:"provider_rules,omitempty"`
+	BlockPatterns []string        `protobuf:"bytes,13,rep,name=block_patterns,json=blockPatterns,proto3" json:"block_patterns,omitempty"`
+	AllowPatterns []string        `protobuf:"bytes,14,rep,name=allow_patterns,json=allowPatterns,proto3" json:"allow_patterns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Delta) Reset() {
+	*x = Delta{}
+	mi := &file_data_data_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Delta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Delta) ProtoMessage() {}
+
+func (x *Delta) ProtoReflect() protoreflect.Message {
+	mi := &file_data_data_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Delta.ProtoReflect.Descriptor instead.
+func (*Delta) Descriptor() ([]byte, []int) {
+	return file_data_data_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Delta) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Delta) GetBaseCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.BaseCreatedAt
+	}
+	return nil
+}
+
+func (x *Delta) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Delta) GetDomainCount() int64 {
+	if x != nil {
+		return x.DomainCount
+	}
+	return 0
+}
+
+func (x *Delta) GetAddedBlocklist() []string {
+	if x != nil {
+		return x.AddedBlocklist
+	}
+	return nil
+}
+
+func (x *Delta) GetRemovedBlocklist() []string {
+	if x != nil {
+		return x.RemovedBlocklist
+	}
+	return nil
+}
+
+func (x *Delta) GetAddedAllowlist() []string {
+	if x != nil {
+		return x.AddedAllowlist
+	}
+	return nil
+}
+
+func (x *Delta) GetRemovedAllowlist() []string {
+	if x != nil {
+		return x.RemovedAllowlist
+	}
+	return nil
+}
+
+func (x *Delta) GetHasSourceCounts() bool {
+	if x != nil {
+		return x.HasSourceCounts
+	}
+	return false
+}
+
+func (x *Delta) GetSourceCounts() map[string]int64 {
+	if x != nil {
+		return x.SourceCounts
+	}
+	return nil
+}
+
+func (x *Delta) GetProviders() []string {
+	if x != nil {
+		return x.Providers
+	}
+	return nil
+}
+
+func (x *Delta) GetProviderRules() []*ProviderRule {
+	if x != nil {
+		return x.ProviderRules
+	}
+	return nil
+}
+
+func (x *Delta) GetBlockPatterns() []string {
+	if x != nil {
+		return x.BlockPatterns
+	}
+	return nil
+}
+
+func (x *Delta) GetAllowPatterns() []string {
+	if x != nil {
+		return x.AllowPatterns
+	}
+	return nil
+}
+
+var File_data_data_proto protoreflect.FileDescriptor
+
+const file_data_data_proto_rawDesc = "" +
+	"\n" +
+	"\x0fdata/data.proto\x12\n" +
+	"disposable\x1a\x1fgoogle/protobuf/timestamp.proto\"(\n" +
+	"\fFormatHeader\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\"\xf9\x03\n" +
+	"\bDataFile\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12!\n" +
+	"\fdomain_count\x18\x03 \x01(\x03R\vdomainCount\x12\x1c\n" +
+	"\tblocklist\x18\x04 \x03(\tR\tblocklist\x12\x1c\n" +
+	"\tallowlist\x18\x05 \x03(\tR\tallowlist\x12K\n" +
+	"\rsource_counts\x18\x06 \x03(\v2&.disposable.DataFile.SourceCountsEntryR\fsourceCounts\x12\x1c\n" +
+	"\tproviders\x18\a \x03(\tR\tproviders\x12?\n" +
+	"\x0eprovider_rules\x18\b \x03(\v2\x18.disposable.ProviderRuleR\rproviderRules\x12%\n" +
+	"\x0eblock_patterns\x18\t \x03(\tR\rblockPatterns\x12%\n" +
+	"\x0eallow_patterns\x18\n" +
+	" \x03(\tR\rallowPatterns\x1a?\n" +
+	"\x11SourceCountsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"i\n" +
+	"\fProviderRule\x12\x18\n" +
+	"\adomains\x18\x01 \x03(\tR\adomains\x12\x1e\n" +
+	"\n" +
+	"separators\x18\x02 \x01(\tR\n" +
+	"separators\x12\x1f\n" +
+	"\vignore_dots\x18\x03 \x01(\bR\n" +
+	"ignoreDots\"\xd3\x05\n" +
+	"\x05Delta\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12B\n" +
+	"\x0fbase_created_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\rbaseCreatedAt\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12!\n" +
+	"\fdomain_count\x18\x04 \x01(\x03R\vdomainCount\x12'\n" +
+	"\x0fadded_blocklist\x18\x05 \x03(\tR\x0eaddedBlocklist\x12+\n" +
+	"\x11removed_blocklist\x18\x06 \x03(\tR\x10removedBlocklist\x12'\n" +
+	"\x0fadded_allowlist\x18\a \x03(\tR\x0eaddedAllowlist\x12+\n" +
+	"\x11removed_allowlist\x18\b \x03(\tR\x10removedAllowlist\x12*\n" +
+	"\x11has_source_counts\x18\t \x01(\bR\x0fhasSourceCounts\x12H\n" +
+	"\rsource_counts\x18\n" +
+	" \x03(\v2#.disposable.Delta.SourceCountsEntryR\fsourceCounts\x12\x1c\n" +
+	"\tproviders\x18\v \x03(\tR\tproviders\x12?\n" +
+	"\x0eprovider_rules\x18\f \x03(\v2\x18.disposable.ProviderRuleR\rproviderRules\x12%\n" +
+	"\x0eblock_patterns\x18\r \x03(\tR\rblockPatterns\x12%\n" +
+	"\x0eallow_patterns\x18\x0e \x03(\tR\rallowPatterns\x1a?\n" +
+	"\x11SourceCountsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01B;Z9github.com/rezmoss/go-is-disposable-email/internal/datapbb\x06proto3"
+
+var (
+	file_data_data_proto_rawDescOnce sync.Once
+	file_data_data_proto_rawDescData []byte
+)
+
+func file_data_data_proto_rawDescGZIP() []byte {
+	file_data_data_proto_rawDescOnce.Do(func() {
+		file_data_data_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_data_data_proto_rawDesc), len(file_data_data_proto_rawDesc)))
+	})
+	return file_data_data_proto_rawDescData
+}
+
+var file_data_data_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_data_data_proto_goTypes = []any{
+	(*FormatHeader)(nil),          // 0: disposable.FormatHeader
+	(*DataFile)(nil),              // 1: disposable.DataFile
+	(*ProviderRule)(nil),          // 2: disposable.ProviderRule
+	(*Delta)(nil),                 // 3: disposable.Delta
+	nil,                           // 4: disposable.DataFile.SourceCountsEntry
+	nil,                           // 5: disposable.Delta.SourceCountsEntry
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_data_data_proto_depIdxs = []int32{
+	6, // 0: disposable.DataFile.created_at:type_name -> google.protobuf.Timestamp
+	4, // 1: disposable.DataFile.source_counts:type_name -> disposable.DataFile.SourceCountsEntry
+	2, // 2: disposable.DataFile.provider_rules:type_name -> disposable.ProviderRule
+	6, // 3: disposable.Delta.base_created_at:type_name -> google.protobuf.Timestamp
+	6, // 4: disposable.Delta.created_at:type_name -> google.protobuf.Timestamp
+	5, // 5: disposable.Delta.source_counts:type_name -> disposable.Delta.SourceCountsEntry
+	2, // 6: disposable.Delta.provider_rules:type_name -> disposable.ProviderRule
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_data_data_proto_init() }
+func file_data_data_proto_init() {
+	if File_data_data_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_data_data_proto_rawDesc), len(file_data_data_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_data_data_proto_goTypes,
+		DependencyIndexes: file_data_data_proto_depIdxs,
+		MessageInfos:      file_data_data_proto_msgTypes,
+	}.Build()
+	File_data_data_proto = out.File
+	file_data_data_proto_goTypes = nil
+	file_data_data_proto_depIdxs = nil
+}
@@ -0,0 +1,60 @@
+// Package idna converts internationalized domain names to their ASCII form,
+// so that "münchen.de" and "xn--mnchen-3ya.de" are looked up the same way.
+//
+// Names are mapped as for lookups by UTS #46, using golang.org/x/net/idna:
+// they are normalized to NFC and case-folded, and full-width and other
+// compatibility forms are mapped to their plain equivalents, so
+// "ｍａｉｌｉｎａｔｏｒ.com" and a decomposed "münchen.de" are looked up as
+// "mailinator.com" and "xn--mnchen-3ya.de".
+package idna
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// maxLabelLength is the longest label allowed in DNS, in octets.
+const maxLabelLength = 63
+
+// ErrInvalid is returned for names that can't be converted.
+var ErrInvalid = errors.New("idna: invalid domain name")
+
+// ToASCII returns the ASCII form of domain: it is mapped as described in the
+// package documentation, and each label with non-ASCII characters is
+// replaced by "xn--" followed by its Punycode encoding. ASCII-only domains
+// are returned lowercased and otherwise unchanged. It returns ErrInvalid if
+// domain isn't valid UTF-8, contains characters UTS #46 disallows, such as
+// U+FFFD, or has a label too long to encode.
+func ToASCII(domain string) (string, error) {
+	if !utf8.ValidString(domain) {
+		return "", ErrInvalid
+	}
+	if IsASCII(domain) {
+		return strings.ToLower(domain), nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", ErrInvalid
+	}
+	for label := range strings.SplitSeq(ascii, ".") {
+		if len(label) > maxLabelLength {
+			return "", ErrInvalid
+		}
+	}
+	return ascii, nil
+}
+
+// IsASCII reports whether s contains only ASCII characters, so ToASCII
+// wouldn't encode any of its labels.
+func IsASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
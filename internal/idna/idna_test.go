@@ -0,0 +1,61 @@
+package idna
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"example.com", "example.com"},
+		{"Example.COM", "example.com"},
+		{"münchen.de", "xn--mnchen-3ya.de"},
+		{"MÜNCHEN.de", "xn--mnchen-3ya.de"},
+		{"bücher.example", "xn--bcher-kva.example"},
+		{"tëmpmail.com", "xn--tmpmail-rya.com"},
+		{"пример.рф", "xn--e1afmkfd.xn--p1ai"},
+		{"mail.почта.рф", "mail.xn--80a1acny.xn--p1ai"},
+		{"例子.广告", "xn--fsqu00a.xn--4rr70v"},
+		{"例子。广告", "xn--fsqu00a.xn--4rr70v"},
+		{"ñ.com", "xn--ida.com"},
+		{"xn--mnchen-3ya.de", "xn--mnchen-3ya.de"},
+		// Full-width and compatibility forms map to their plain equivalents
+		{"ｍａｉｌｉｎａｔｏｒ.com", "mailinator.com"},
+		{"ＭＡＩＬＩＮＡＴＯＲ．ＣＯＭ", "mailinator.com"},
+		{"mail.ｍüｎｃｈｅｎ.de", "mail.xn--mnchen-3ya.de"},
+		// Decomposed characters are composed first
+		{"mu\u0308nchen.de", "xn--mnchen-3ya.de"},
+		{"MU\u0308NCHEN.de", "xn--mnchen-3ya.de"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := ToASCII(tt.input)
+		if err != nil {
+			t.Errorf("ToASCII(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ToASCII(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestToASCIIInvalid(t *testing.T) {
+	tests := []string{
+		"\xff.com",
+		"\ufffd.com",
+		strings.Repeat("ü", 64) + ".com",
+		"mail\u2028.com",
+	}
+
+	for _, input := range tests {
+		if _, err := ToASCII(input); !errors.Is(err, ErrInvalid) {
+			t.Errorf("ToASCII(%q) error = %v, want %v", input, err, ErrInvalid)
+		}
+	}
+}
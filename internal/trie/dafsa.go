@@ -0,0 +1,397 @@
+package trie
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// A DAFSA stores the reversed bytes of its domains as a minimized acyclic
+// automaton: like a trie, but states with the same future are shared, so the
+// TLDs and common second-level labels at the start of the keys and the
+// common first labels such as "mail." at their end are stored once. The
+// states are encoded into one byte slice. Each state is a run of edges
+// sorted by label:
+//
+//	label byte | uvarint(target<<2 | final<<1 | last)
+//
+// target is the offset of the edges of the state the edge leads to, or 0 if
+// that state has no edges; offset 0 holds a padding byte so it is never a
+// state. final marks edges into states that end a key, and last the last
+// edge of a state. States are written after the states they lead to, so
+// targets always point backwards and the automaton can't loop.
+
+// DAFSA is an immutable set of domains in a directed acyclic word graph. It
+// takes a few bytes per domain instead of the hundreds taken by trie nodes,
+// and lookups don't allocate.
+type DAFSA struct {
+	edges []byte
+	root  int // offset of the edges of the start state, 0 if it has none
+	n     int
+}
+
+// NewDAFSA builds a DAFSA holding domains, in any order. Empty domains,
+// duplicates and domains beyond the default limits of Insert are dropped.
+func NewDAFSA(domains []string) *DAFSA {
+	keys := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		if domain != "" && withinLimits(domain, DefaultMaxDomainLength, MaxLabels) {
+			keys = append(keys, string(appendReversedBytes(nil, domain)))
+		}
+	}
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	// Keys are added in order, so only the states of the previous key past
+	// its prefix shared with the next one can't change anymore; those are
+	// replaced by equivalent registered states or registered themselves
+	b := &dafsaBuilder{
+		single:   make(map[dafsaSingle]*dafsaState),
+		register: make(map[string]*dafsaState),
+	}
+	root := b.newState()
+	path := []*dafsaState{root} // path[i] is the state after i bytes of prev
+	prev := ""
+	for _, key := range keys {
+		n := 0
+		for n < len(prev) && prev[n] == key[n] {
+			n++
+		}
+		b.minimize(path, n)
+		path = path[:n+1]
+
+		s := path[n]
+		for i := n; i < len(key); i++ {
+			next := b.newState()
+			s.edges = append(s.edges, dafsaEdge{label: key[i], to: next})
+			s = next
+			path = append(path, s)
+		}
+		s.final = true
+		prev = key
+	}
+	b.minimize(path, 0)
+
+	return b.encode(root, len(keys))
+}
+
+// dafsaState is a state of a DAFSA being built.
+type dafsaState struct {
+	final bool
+	edges []dafsaEdge
+	id    int // 1-based registration order, 0 until registered
+
+	// Holds the edge of the many states with one, without allocating
+	inline [1]dafsaEdge
+}
+
+type dafsaEdge struct {
+	label byte
+	to    *dafsaState
+}
+
+// dafsaSingle is the signature of a state with at most one edge.
+type dafsaSingle struct {
+	final bool
+	label byte
+	to    int
+}
+
+type dafsaBuilder struct {
+	// Registered states by signature
+	single   map[dafsaSingle]*dafsaState
+	register map[string]*dafsaState
+	sig      []byte
+	count    int // number of registered states
+
+	slab []dafsaState // states allocated in bulk
+}
+
+// newState returns a new state without edges.
+func (b *dafsaBuilder) newState() *dafsaState {
+	if len(b.slab) == 0 {
+		b.slab = make([]dafsaState, 1024)
+	}
+	s := &b.slab[0]
+	b.slab = b.slab[1:]
+	s.edges = s.inline[:0]
+	return s
+}
+
+// minimize replaces path[depth+1:] by equivalent registered states, deepest
+// first, registering those without one. Each of them is reached through the
+// last edge of the state before it.
+func (b *dafsaBuilder) minimize(path []*dafsaState, depth int) {
+	for i := len(path) - 1; i > depth; i-- {
+		s := path[i]
+
+		// States are equivalent if they agree on finality and their edges
+		// lead to the same registered states
+		if len(s.edges) <= 1 {
+			var sig dafsaSingle
+			sig.final = s.final
+			if len(s.edges) == 1 {
+				sig.label, sig.to = s.edges[0].label, s.edges[0].to.id
+			}
+			if same, ok := b.single[sig]; ok {
+				path[i-1].edges[len(path[i-1].edges)-1].to = same
+				continue
+			}
+			b.count++
+			s.id = b.count
+			b.single[sig] = s
+			continue
+		}
+
+		b.sig = b.sig[:0]
+		if s.final {
+			b.sig = append(b.sig, 1)
+		}
+		for _, e := range s.edges {
+			b.sig = append(b.sig, e.label)
+			b.sig = binary.AppendUvarint(b.sig, uint64(e.to.id))
+		}
+
+		if same, ok := b.register[string(b.sig)]; ok {
+			path[i-1].edges[len(path[i-1].edges)-1].to = same
+			continue
+		}
+		b.count++
+		s.id = b.count
+		b.register[string(b.sig)] = s
+	}
+}
+
+// encode writes the states reachable from root into a DAFSA of n domains.
+func (b *dafsaBuilder) encode(root *dafsaState, n int) *DAFSA {
+	d := &DAFSA{edges: []byte{0}, n: n}
+	offsets := make([]int, b.count+1) // by id, 0 until written
+
+	var write func(s *dafsaState) int
+	write = func(s *dafsaState) int {
+		if len(s.edges) == 0 {
+			return 0
+		}
+		if off := offsets[s.id]; off != 0 {
+			return off
+		}
+
+		targets := make([]int, len(s.edges))
+		for i, e := range s.edges {
+			targets[i] = write(e.to)
+		}
+
+		off := len(d.edges)
+		for i, e := range s.edges {
+			v := uint64(targets[i]) << 2
+			if e.to.final {
+				v |= 2
+			}
+			if i == len(s.edges)-1 {
+				v |= 1
+			}
+			d.edges = append(d.edges, e.label)
+			d.edges = binary.AppendUvarint(d.edges, v)
+		}
+		offsets[s.id] = off
+		return off
+	}
+	d.root = write(root)
+
+	d.edges = slices.Clip(d.edges)
+	return d
+}
+
+// Len returns the number of domains in the DAFSA.
+func (d *DAFSA) Len() int {
+	return d.n
+}
+
+// next follows the edge labeled c from the state at offset s. It returns the
+// state it leads to and whether that state is final, or ok false if there is
+// no such edge.
+func (d *DAFSA) next(s int, c byte) (to int, final, ok bool) {
+	if s == 0 {
+		return 0, false, false
+	}
+	for {
+		label := d.edges[s]
+		v, size := binary.Uvarint(d.edges[s+1:])
+		if label == c {
+			return int(v >> 2), v&2 != 0, true
+		}
+		// Edges are sorted by label
+		if v&1 != 0 || label > c {
+			return 0, false, false
+		}
+		s += 1 + size
+	}
+}
+
+func (d *DAFSA) contains(domain string) bool {
+	s, final := d.root, false
+	for i := len(domain) - 1; i >= 0; i-- {
+		var ok bool
+		if s, final, ok = d.next(s, domain[i]); !ok {
+			return false
+		}
+	}
+	return final
+}
+
+func (d *DAFSA) walk(domain string, fn func(n int) bool) {
+	s := d.root
+	for i := len(domain) - 1; i >= 0; i-- {
+		next, final, ok := d.next(s, domain[i])
+		if !ok {
+			return
+		}
+		s = next
+
+		if final && (i == 0 || domain[i-1] == '.') && !fn(len(domain)-i) {
+			return
+		}
+	}
+}
+
+func (d *DAFSA) match(domain string, minLen int) int {
+	match := 0
+	d.walk(domain, func(n int) bool {
+		if n < len(domain) && n <= minLen {
+			return true
+		}
+		match = n
+		return false
+	})
+	return match
+}
+
+func (d *DAFSA) longest(domain string, proper bool) int {
+	longest := 0
+	d.walk(domain, func(n int) bool {
+		if !proper || n < len(domain) {
+			longest = n
+		}
+		return true
+	})
+	return longest
+}
+
+// collect calls fn with each key accepted from the state at offset s, in
+// order, each appended to key.
+func (d *DAFSA) collect(s int, key []byte, fn func(key []byte)) {
+	for s != 0 {
+		label := d.edges[s]
+		v, size := binary.Uvarint(d.edges[s+1:])
+		key := append(key, label)
+		if v&2 != 0 {
+			fn(key)
+		}
+		d.collect(int(v>>2), key, fn)
+		if v&1 != 0 {
+			return
+		}
+		s += 1 + size
+	}
+}
+
+func (d *DAFSA) all() []string {
+	domains := make([]string, 0, d.n)
+	d.collect(d.root, nil, func(key []byte) {
+		domains = append(domains, string(appendReversedBytes(nil, string(key))))
+	})
+	return domains
+}
+
+func (d *DAFSA) under(suffix string) []string {
+	s, final := d.root, false
+	for i := len(suffix) - 1; i >= 0; i-- {
+		var ok bool
+		if s, final, ok = d.next(s, suffix[i]); !ok {
+			return nil
+		}
+	}
+
+	var domains []string
+	if final {
+		domains = append(domains, suffix)
+	}
+	if s, final, ok := d.next(s, '.'); ok {
+		key := append(appendReversedBytes(nil, suffix), '.')
+		if final {
+			domains = append(domains, "."+suffix)
+		}
+		d.collect(s, key, func(key []byte) {
+			domains = append(domains, string(appendReversedBytes(nil, string(key))))
+		})
+	}
+	return domains
+}
+
+// validate checks that the edges are in bounds and sorted, that every target
+// points backwards and that the automaton accepts Len domains.
+func (d *DAFSA) validate() error {
+	if len(d.edges) == 0 || d.root >= len(d.edges) {
+		return errors.New("dafsa: root out of bounds")
+	}
+
+	// Number of keys accepted from each state, by offset
+	counts := make(map[int]int)
+	var count func(s int) (int, error)
+	count = func(s int) (int, error) {
+		if s == 0 {
+			return 0, nil
+		}
+		if n, ok := counts[s]; ok {
+			return n, nil
+		}
+		n := 0
+		prev := -1
+		for off := s; ; {
+			if off >= len(d.edges) {
+				return 0, fmt.Errorf("dafsa: state at %d runs out of bounds", s)
+			}
+			label := int(d.edges[off])
+			v, size := binary.Uvarint(d.edges[off+1:])
+			if size <= 0 {
+				return 0, fmt.Errorf("dafsa: invalid edge at %d", off)
+			}
+			if label <= prev {
+				return 0, fmt.Errorf("dafsa: edges of state at %d are not sorted", s)
+			}
+			prev = label
+
+			target := int(v >> 2)
+			if target >= s {
+				return 0, fmt.Errorf("dafsa: edge at %d points forward", off)
+			}
+			below, err := count(target)
+			if err != nil {
+				return 0, err
+			}
+			if v&2 != 0 {
+				n++
+			} else if below == 0 {
+				return 0, fmt.Errorf("dafsa: edge at %d leads to no domain", off)
+			}
+			n += below
+
+			if v&1 != 0 {
+				break
+			}
+			off += 1 + size
+		}
+		counts[s] = n
+		return n, nil
+	}
+
+	n, err := count(d.root)
+	if err != nil {
+		return err
+	}
+	if n != d.n {
+		return fmt.Errorf("dafsa: %d domains accepted, expected %d", n, d.n)
+	}
+	return nil
+}
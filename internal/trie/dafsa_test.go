@@ -0,0 +1,136 @@
+package trie
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDAFSA(t *testing.T) {
+	domains := []string{"tempmail.com", "mail.tempmail.com", "yopmail.com", "yopmail.net", "tempmail.net", "", "tempmail.com"}
+	d := NewDAFSA(domains)
+
+	if d.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", d.Len())
+	}
+	got := d.all()
+	slices.Sort(got)
+	want := []string{"mail.tempmail.com", "tempmail.com", "tempmail.net", "yopmail.com", "yopmail.net"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("all() = %v, want %v", got, want)
+	}
+	if err := d.validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+
+	tests := []struct {
+		domain string
+		want   bool
+	}{
+		{"tempmail.com", true},
+		{"mail.tempmail.com", true},
+		{"yopmail.net", true},
+		{"empmail.com", false},
+		{"tempmail.org", false},
+		{"x.tempmail.com", false},
+		{"com", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := d.contains(tt.domain); got != tt.want {
+			t.Errorf("contains(%q) = %v, want %v", tt.domain, got, tt.want)
+		}
+	}
+
+	if got, want := d.under("tempmail.com"), []string{"tempmail.com", "mail.tempmail.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("under(tempmail.com) = %v, want %v", got, want)
+	}
+	if got := d.under("mail.com"); got != nil {
+		t.Errorf("under(mail.com) = %v, want none", got)
+	}
+
+	empty := NewDAFSA(nil)
+	if empty.Len() != 0 || empty.contains("tempmail.com") || len(empty.all()) != 0 {
+		t.Error("Expected an empty DAFSA to hold nothing")
+	}
+	if err := empty.validate(); err != nil {
+		t.Errorf("validate() of an empty DAFSA error = %v", err)
+	}
+}
+
+func TestDAFSAMinimal(t *testing.T) {
+	// Equal sets give equal automata whatever their order, so states are
+	// fully merged
+	domains := make([]string, 0, 300)
+	for i := range 100 {
+		for _, tld := range []string{"com", "net", "org"} {
+			domains = append(domains, fmt.Sprintf("mail%d.temp.%s", i, tld))
+		}
+	}
+	sorted := NewDAFSA(domains)
+	slices.Reverse(domains)
+	if reversed := NewDAFSA(domains); !reflect.DeepEqual(reversed, sorted) {
+		t.Error("NewDAFSA() depends on the order of the domains")
+	}
+
+	// One state per distinct "mail<i>" prefix and per shared suffix
+	if len(sorted.edges) > 2000 {
+		t.Errorf("DAFSA takes %d bytes for %d domains, want shared suffixes", len(sorted.edges), sorted.Len())
+	}
+}
+
+func TestDAFSAOverlayMatchesTrie(t *testing.T) {
+	testOverlay(t, func(domains []string) Base { return NewDAFSA(domains) })
+}
+
+func TestDAFSALimits(t *testing.T) {
+	long := fmt.Sprintf("%0*d.com", DefaultMaxDomainLength, 0)
+	deep := strings.Repeat("a.", MaxLabels) + "com"
+	d := NewDAFSA([]string{long, deep, "tempmail.com"})
+	if d.Len() != 1 || d.contains(long) || d.contains(deep) {
+		t.Errorf("NewDAFSA() kept a domain beyond the default limits")
+	}
+}
+
+func TestDAFSAValidateCorrupt(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(d *DAFSA)
+	}{
+		{"wrong count", func(d *DAFSA) { d.n++ }},
+		{"root out of bounds", func(d *DAFSA) { d.root = len(d.edges) }},
+		{"forward edge", func(d *DAFSA) {
+			// The start state is written last; point its edge at itself
+			d.edges = append(d.edges[:d.root+1], binary.AppendUvarint(nil, uint64(d.root)<<2|3)...)
+		}},
+		{"unsorted edges", func(d *DAFSA) { d.edges[d.root] = 'z' }},
+	}
+
+	for _, tt := range tests {
+		d := NewDAFSA([]string{"a.test", "b.test", "c.a"})
+		tt.corrupt(d)
+		if err := d.validate(); err == nil {
+			t.Errorf("validate(%s) = nil, want an error", tt.name)
+		}
+	}
+}
+
+func BenchmarkDAFSAContains(b *testing.B) {
+	tr := NewOverlay(NewDAFSA(benchmarkDomains(10000)))
+
+	testDomains := []string{
+		"mail1.temp1.com",
+		"sub.mail2.temp2.net",
+		"gmail.com",
+		"company.co.uk",
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.ContainsHierarchical(testDomains[i%len(testDomains)])
+	}
+}
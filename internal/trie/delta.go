@@ -0,0 +1,209 @@
+package trie
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Delta describes the changes from one data file to the next, so that
+// clients holding the base data can update without downloading the full
+// file. Domain lists hold only the entries added or removed; the small
+// metadata lists of the target are carried whole.
+type Delta struct {
+	Version       string    // Format version, as for data files
+	BaseCreatedAt time.Time // Creation time of the data file the delta applies to
+	CreatedAt     time.Time // Creation time of the resulting data file
+	DomainCount   int       // Number of blocklist domains after applying
+
+	AddedBlocklist   []string
+	RemovedBlocklist []string
+	AddedAllowlist   []string
+	RemovedAllowlist []string
+
+	// Whether the target has source counts, and those of its blocklist
+	// domains that are new or changed since the base
+	HasSourceCounts bool
+	SourceCounts    map[string]int
+
+	// Metadata of the target, see DataFile
+	Providers     []string
+	ProviderRules []ProviderRule
+	BlockPatterns []string
+	AllowPatterns []string
+}
+
+// DeltaBaseError is returned by Delta.Apply when the delta was made for
+// other data than it is applied to.
+type DeltaBaseError struct {
+	Want time.Time // Creation time of the data the delta applies to
+	Have time.Time // Creation time of the data it was applied to
+}
+
+func (e *DeltaBaseError) Error() string {
+	return fmt.Sprintf("delta applies to data created at %s, not %s",
+		e.Want.Format(time.RFC3339), e.Have.Format(time.RFC3339))
+}
+
+// NewDelta returns the delta that turns base into target.
+func NewDelta(base, target *DataFile) *Delta {
+	d := &Delta{
+		Version:         FormatVersion,
+		BaseCreatedAt:   base.CreatedAt,
+		CreatedAt:       target.CreatedAt,
+		DomainCount:     len(target.Blocklist),
+		HasSourceCounts: target.SourceCounts != nil,
+		Providers:       target.Providers,
+		ProviderRules:   target.ProviderRules,
+		BlockPatterns:   target.BlockPatterns,
+		AllowPatterns:   target.AllowPatterns,
+	}
+	d.AddedBlocklist, d.RemovedBlocklist = diff(base.Blocklist, target.Blocklist)
+	d.AddedAllowlist, d.RemovedAllowlist = diff(base.Allowlist, target.Allowlist)
+
+	for domain, n := range target.SourceCounts {
+		if old, ok := base.SourceCounts[domain]; !ok || old != n {
+			if d.SourceCounts == nil {
+				d.SourceCounts = make(map[string]int)
+			}
+			d.SourceCounts[domain] = n
+		}
+	}
+	return d
+}
+
+// diff returns the entries of target missing from base and those of base
+// missing from target, each sorted by reversed domain.
+func diff(base, target []string) (added, removed []string) {
+	inBase := make(map[string]struct{}, len(base))
+	for _, domain := range base {
+		inBase[domain] = struct{}{}
+	}
+	for _, domain := range target {
+		if _, ok := inBase[domain]; ok {
+			delete(inBase, domain)
+			continue
+		}
+		added = append(added, domain)
+	}
+	for domain := range inBase {
+		removed = append(removed, domain)
+	}
+	sortReversed(added)
+	sortReversed(removed)
+	return added, removed
+}
+
+// Empty reports whether the delta changes no domains or source counts.
+func (d *Delta) Empty() bool {
+	return len(d.AddedBlocklist) == 0 && len(d.RemovedBlocklist) == 0 &&
+		len(d.AddedAllowlist) == 0 && len(d.RemovedAllowlist) == 0 && len(d.SourceCounts) == 0
+}
+
+// Apply returns the data file that results from applying d to base. base is
+// not modified. It returns a DeltaBaseError if d was made for other data, and
+// an error if the result doesn't have the expected number of domains.
+func (d *Delta) Apply(base *DataFile) (*DataFile, error) {
+	if !d.BaseCreatedAt.Equal(base.CreatedAt) {
+		return nil, &DeltaBaseError{Want: d.BaseCreatedAt, Have: base.CreatedAt}
+	}
+
+	target := &DataFile{
+		Version:       FormatVersion,
+		CreatedAt:     d.CreatedAt,
+		DomainCount:   d.DomainCount,
+		Blocklist:     patch(base.Blocklist, d.AddedBlocklist, d.RemovedBlocklist),
+		Allowlist:     patch(base.Allowlist, d.AddedAllowlist, d.RemovedAllowlist),
+		Providers:     d.Providers,
+		ProviderRules: d.ProviderRules,
+		BlockPatterns: d.BlockPatterns,
+		AllowPatterns: d.AllowPatterns,
+	}
+	if len(target.Blocklist) != d.DomainCount {
+		return nil, fmt.Errorf("delta produced %d blocklist domains, want %d", len(target.Blocklist), d.DomainCount)
+	}
+
+	if d.HasSourceCounts {
+		target.SourceCounts = maps.Clone(base.SourceCounts)
+		if target.SourceCounts == nil {
+			target.SourceCounts = make(map[string]int)
+		}
+		for _, domain := range d.RemovedBlocklist {
+			delete(target.SourceCounts, domain)
+		}
+		maps.Copy(target.SourceCounts, d.SourceCounts)
+	}
+	return target, nil
+}
+
+// patch returns list without removed and with added, sorted by reversed
+// domain so that Deserialize can build a trie from it quickly.
+func patch(list, added, removed []string) []string {
+	drop := make(map[string]struct{}, len(removed))
+	for _, domain := range removed {
+		drop[domain] = struct{}{}
+	}
+
+	result := make([]string, 0, len(list)+len(added))
+	for _, domain := range list {
+		if _, ok := drop[domain]; !ok {
+			result = append(result, domain)
+		}
+	}
+	result = append(result, added...)
+
+	// Sorting puts entries added twice next to each other
+	sortReversed(result)
+	return slices.Compact(result)
+}
+
+// SerializeDelta encodes a delta as a Delta message of data/data.proto,
+// compressed at gzip compression level level.
+func SerializeDelta(d *Delta, level int) ([]byte, error) {
+	message, err := marshalDelta(d)
+	if err != nil {
+		return nil, fmt.Errorf("protobuf encode failed: %w", err)
+	}
+	return compress(append([]byte(deltaMagic), message...), level)
+}
+
+// DeserializeDelta decodes a delta written by SerializeDelta. Deltas are only
+// read in the current major format version, since they apply to data in it;
+// it returns an UnsupportedFormatError for others.
+func DeserializeDelta(data []byte) (*Delta, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("gzip reader creation failed: %w", err)
+	}
+	defer gzipReader.Close()
+
+	decompressed, err := io.ReadAll(gzipReader)
+	if err != nil {
+		return nil, fmt.Errorf("gzip read failed: %w", err)
+	}
+
+	// Deltas published before they were encoded with Protocol Buffers
+	// were gob-encoded and lack the magic
+	payload, ok := bytes.CutPrefix(decompressed, []byte(deltaMagic))
+	if !ok {
+		return nil, errors.New("decode failed: not a delta")
+	}
+	version, err := readProtobufVersion(payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
+	}
+	if majorVersion(version) != majorVersion(FormatVersion) {
+		return nil, &UnsupportedFormatError{Version: version}
+	}
+
+	d, err := unmarshalDelta(payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
+	}
+	return d, nil
+}
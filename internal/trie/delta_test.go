@@ -0,0 +1,146 @@
+package trie
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testDataFile(created time.Time, blocked, allowed []string) *DataFile {
+	blocklist := New()
+	for _, domain := range blocked {
+		blocklist.Insert(domain)
+	}
+	allowlist := New()
+	for _, domain := range allowed {
+		allowlist.Insert(domain)
+	}
+	df := NewDataFile(blocklist, allowlist, time.Now())
+	df.CreatedAt = created
+	return df
+}
+
+func TestDeltaApply(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := testDataFile(baseTime,
+		[]string{"tempmail.com", "yopmail.com", "old.test"}, []string{"gmail.com"})
+	base.SourceCounts = map[string]int{"tempmail.com": 2, "yopmail.com": 1, "old.test": 1}
+
+	target := testDataFile(baseTime.Add(24*time.Hour),
+		[]string{"tempmail.com", "yopmail.com", "new.test"}, []string{"gmail.com", "outlook.com"})
+	target.SourceCounts = map[string]int{"tempmail.com": 3, "yopmail.com": 1, "new.test": 1}
+	target.Providers = []string{"gmail.com"}
+	target.BlockPatterns = []string{"*.temp-mail.*"}
+
+	d := NewDelta(base, target)
+
+	if !reflect.DeepEqual(d.AddedBlocklist, []string{"new.test"}) {
+		t.Errorf("AddedBlocklist = %v, want [new.test]", d.AddedBlocklist)
+	}
+	if !reflect.DeepEqual(d.RemovedBlocklist, []string{"old.test"}) {
+		t.Errorf("RemovedBlocklist = %v, want [old.test]", d.RemovedBlocklist)
+	}
+	if !reflect.DeepEqual(d.AddedAllowlist, []string{"outlook.com"}) {
+		t.Errorf("AddedAllowlist = %v, want [outlook.com]", d.AddedAllowlist)
+	}
+	if want := map[string]int{"tempmail.com": 3, "new.test": 1}; !reflect.DeepEqual(d.SourceCounts, want) {
+		t.Errorf("SourceCounts = %v, want %v", d.SourceCounts, want)
+	}
+	if d.Empty() {
+		t.Error("Empty() = true for a delta with changes")
+	}
+
+	// The delta survives serialization
+	data, err := SerializeDelta(d, DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDelta failed: %v", err)
+	}
+	restored, err := DeserializeDelta(data)
+	if err != nil {
+		t.Fatalf("DeserializeDelta failed: %v", err)
+	}
+
+	got, err := restored.Apply(base)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, target) {
+		t.Errorf("Apply() = %+v, want %+v", got, target)
+	}
+
+	// base is left alone
+	if len(base.Blocklist) != 3 || base.SourceCounts["old.test"] != 1 {
+		t.Errorf("Apply modified its base: %+v", base)
+	}
+}
+
+func TestDeltaEmpty(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := testDataFile(created, []string{"tempmail.com"}, nil)
+	target := testDataFile(created.Add(time.Hour), []string{"tempmail.com"}, nil)
+
+	d := NewDelta(base, target)
+	if !d.Empty() {
+		t.Errorf("Empty() = false for unchanged domains: %+v", d)
+	}
+
+	got, err := d.Apply(base)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if !got.CreatedAt.Equal(target.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, target.CreatedAt)
+	}
+}
+
+func TestDeltaApplyWrongBase(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := testDataFile(created, []string{"tempmail.com"}, nil)
+	target := testDataFile(created.Add(time.Hour), []string{"tempmail.com", "new.test"}, nil)
+	other := testDataFile(created.Add(-time.Hour), []string{"tempmail.com"}, nil)
+
+	d := NewDelta(base, target)
+
+	_, err := d.Apply(other)
+	var baseErr *DeltaBaseError
+	if !errors.As(err, &baseErr) {
+		t.Fatalf("Apply() error = %v, want DeltaBaseError", err)
+	}
+	if !baseErr.Want.Equal(created) || !baseErr.Have.Equal(other.CreatedAt) {
+		t.Errorf("DeltaBaseError = %+v", baseErr)
+	}
+
+	// Base data with the right time but other domains is caught by the count
+	mismatched := testDataFile(created, []string{"tempmail.com", "extra.test"}, nil)
+	if _, err := d.Apply(mismatched); err == nil {
+		t.Error("Apply() to data with other domains succeeded, want error")
+	}
+}
+
+func TestDeserializeDeltaUnsupportedFormat(t *testing.T) {
+	d := &Delta{Version: "3.0"}
+	data, err := SerializeDelta(d, DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDelta failed: %v", err)
+	}
+
+	_, err = DeserializeDelta(data)
+	var formatErr *UnsupportedFormatError
+	if !errors.As(err, &formatErr) || formatErr.Version != "3.0" {
+		t.Errorf("DeserializeDelta() error = %v, want UnsupportedFormatError for 3.0", err)
+	}
+
+	if _, err := DeserializeDelta([]byte("not a delta")); err == nil {
+		t.Error("DeserializeDelta() of invalid data succeeded, want error")
+	}
+
+	// A data file is compressed the same way, but isn't a delta
+	dataFile, err := SerializeDataFile(testDataFile(time.Now(), []string{"tempmail.com"}, nil), DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDataFile failed: %v", err)
+	}
+	if _, err := DeserializeDelta(dataFile); err == nil {
+		t.Error("DeserializeDelta() of a data file succeeded, want error")
+	}
+}
@@ -0,0 +1,341 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// The flat format stores the lists of a data file as sorted arrays that are
+// searched in place, so that a file mapped into memory can be queried without
+// decoding it or building tries. All integers are little-endian uint32:
+//
+//	magic "DEFL" | layout version | metadata size | blocklist count | allowlist count
+//	metadata: a DataFile message as in data/data.proto, without the lists
+//	blocklist: count+1 offsets into the keys, then the keys
+//	allowlist: the same
+//
+// A key is a domain with its bytes reversed, e.g. "moc.liampmet" for
+// "tempmail.com", so that the parents of a domain are the prefixes of its
+// key that end before a dot. Keys are sorted bytewise and unique.
+const (
+	flatMagic      = "DEFL"
+	flatVersion    = 1
+	flatHeaderSize = 20
+)
+
+// Flat is an immutable list of domains in the flat format. Lookups binary
+// search its keys and don't allocate.
+type Flat struct {
+	offsets []byte
+	keys    []byte
+	n       int
+
+	// Keeps the mapping of the file the list is read from alive; the
+	// methods reading offsets and keys keep f alive until they return
+	owner *mapping
+}
+
+// mapping is a memory-mapped file, unmapped once no Flat refers to it.
+type mapping struct {
+	data []byte
+}
+
+// FlatFile is a data file in the flat format.
+type FlatFile struct {
+	blocklist *Flat
+	allowlist *Flat
+	meta      *DataFile
+}
+
+// MarshalFlat encodes df in the flat format. Duplicate domains and domains
+// beyond the default limits of Insert are dropped.
+func MarshalFlat(df *DataFile) ([]byte, error) {
+	meta := *df
+	meta.Version = FormatVersion
+	meta.Blocklist, meta.Allowlist = nil, nil
+	metadata, err := marshalDataFile(&meta)
+	if err != nil {
+		return nil, fmt.Errorf("protobuf encode failed: %w", err)
+	}
+
+	blockKeys := flatKeys(df.Blocklist)
+	allowKeys := flatKeys(df.Allowlist)
+
+	b := make([]byte, 0, flatHeaderSize+len(metadata))
+	b = append(b, flatMagic...)
+	b = binary.LittleEndian.AppendUint32(b, flatVersion)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(metadata)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(blockKeys)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(allowKeys)))
+	b = append(b, metadata...)
+	b = appendFlatList(b, blockKeys)
+	return appendFlatList(b, allowKeys), nil
+}
+
+// flatKeys returns the sorted, unique keys of domains.
+func flatKeys(domains []string) []string {
+	keys := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		if domain != "" && withinLimits(domain, DefaultMaxDomainLength, MaxLabels) {
+			keys = append(keys, string(appendReversedBytes(nil, domain)))
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+func appendFlatList(b []byte, keys []string) []byte {
+	offset := 0
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	for _, key := range keys {
+		offset += len(key)
+		b = binary.LittleEndian.AppendUint32(b, uint32(offset))
+	}
+	for _, key := range keys {
+		b = append(b, key...)
+	}
+	return b
+}
+
+// appendReversedBytes appends the bytes of s to b in reverse order.
+func appendReversedBytes(b []byte, s string) []byte {
+	for i := len(s) - 1; i >= 0; i-- {
+		b = append(b, s[i])
+	}
+	return b
+}
+
+// ParseFlat reads a file in the flat format from data, which it refers to
+// instead of copying. It checks that the lists are within data, so that
+// lookups can't fail, but not that they are sorted; see Trie.Validate.
+func ParseFlat(data []byte) (*FlatFile, error) {
+	return parseFlat(data, nil)
+}
+
+func parseFlat(data []byte, owner *mapping) (*FlatFile, error) {
+	if len(data) < flatHeaderSize || string(data[:len(flatMagic)]) != flatMagic {
+		return nil, errors.New("flat: not a flat data file")
+	}
+	if v := binary.LittleEndian.Uint32(data[4:]); v != flatVersion {
+		return nil, &UnsupportedFormatError{Version: "flat/" + strconv.FormatUint(uint64(v), 10)}
+	}
+	metaSize := uint64(binary.LittleEndian.Uint32(data[8:]))
+	blockCount := uint64(binary.LittleEndian.Uint32(data[12:]))
+	allowCount := uint64(binary.LittleEndian.Uint32(data[16:]))
+
+	rest := data[flatHeaderSize:]
+	if metaSize > uint64(len(rest)) {
+		return nil, errors.New("flat: truncated metadata")
+	}
+	meta, err := unmarshalDataFile(rest[:metaSize])
+	if err != nil {
+		return nil, fmt.Errorf("flat: metadata: %w", err)
+	}
+	rest = rest[metaSize:]
+
+	ff := &FlatFile{meta: meta}
+	if ff.blocklist, rest, err = parseFlatList(rest, blockCount, owner); err != nil {
+		return nil, fmt.Errorf("flat: blocklist: %w", err)
+	}
+	if ff.allowlist, rest, err = parseFlatList(rest, allowCount, owner); err != nil {
+		return nil, fmt.Errorf("flat: allowlist: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("flat: trailing data")
+	}
+	return ff, nil
+}
+
+// parseFlatList reads a list of n keys from the start of b and returns the
+// rest of b.
+func parseFlatList(b []byte, n uint64, owner *mapping) (*Flat, []byte, error) {
+	size := (n + 1) * 4
+	if size > uint64(len(b)) {
+		return nil, nil, errors.New("truncated offsets")
+	}
+	f := &Flat{offsets: b[:size], n: int(n), owner: owner}
+
+	// Offsets must start at 0 and not decrease, so every key is in bounds
+	prev := uint32(0)
+	for i := 0; i <= f.n; i++ {
+		off := binary.LittleEndian.Uint32(f.offsets[4*i:])
+		if i == 0 && off != 0 || off < prev {
+			return nil, nil, fmt.Errorf("invalid offset %d of key %d", off, i)
+		}
+		prev = off
+	}
+	if uint64(prev) > uint64(len(b))-size {
+		return nil, nil, errors.New("truncated keys")
+	}
+	f.keys = b[size : size+uint64(prev)]
+	return f, b[size+uint64(prev):], nil
+}
+
+// OpenFlat maps the flat data file at path into memory and parses it. The
+// file isn't read up front; the operating system pages it in as lookups touch
+// it and can share it between processes. It is unmapped once the FlatFile
+// and the tries made from it are no longer used. Where memory mapping isn't
+// available, the file is read into memory instead.
+func OpenFlat(path string) (*FlatFile, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	owner := &mapping{data: data}
+	if unmap != nil {
+		runtime.AddCleanup(owner, func(data []byte) { unmap(data) }, data)
+	}
+
+	ff, err := parseFlat(data, owner)
+	runtime.KeepAlive(owner)
+	return ff, err
+}
+
+// DataFile returns the metadata of the file: everything but the lists. The
+// size of the blocklist is in DomainCount.
+func (ff *FlatFile) DataFile() *DataFile {
+	df := *ff.meta
+	return &df
+}
+
+// NewTries returns new, empty tries on top of the blocklist and allowlist of
+// the file. See NewOverlay.
+func (ff *FlatFile) NewTries() (blocklist, allowlist *Trie) {
+	return NewOverlay(ff.blocklist), NewOverlay(ff.allowlist)
+}
+
+// Len returns the number of domains in the list.
+func (f *Flat) Len() int {
+	return f.n
+}
+
+// key returns the key of the i-th domain.
+func (f *Flat) key(i int) []byte {
+	start := binary.LittleEndian.Uint32(f.offsets[4*i:])
+	end := binary.LittleEndian.Uint32(f.offsets[4*i+4:])
+	return f.keys[start:end]
+}
+
+// domain returns the i-th domain as a new string.
+func (f *Flat) domain(i int) string {
+	key := f.key(i)
+	b := make([]byte, len(key))
+	for j, c := range key {
+		b[len(key)-1-j] = c
+	}
+	return string(b)
+}
+
+// search returns the index of the first key not less than key and whether
+// it equals key.
+func (f *Flat) search(key []byte) (int, bool) {
+	return sort.Find(f.n, func(i int) int {
+		return bytes.Compare(key, f.key(i))
+	})
+}
+
+// contains reports whether domain is in the list.
+func (f *Flat) contains(domain string) bool {
+	defer runtime.KeepAlive(f)
+
+	var buf [DefaultMaxDomainLength]byte
+	_, found := f.search(appendReversedBytes(buf[:0], domain))
+	return found
+}
+
+// walk calls fn with the length of each suffix of domain at a label
+// boundary that is in the list, shortest first, until fn returns false.
+func (f *Flat) walk(domain string, fn func(n int) bool) {
+	defer runtime.KeepAlive(f)
+
+	var buf [DefaultMaxDomainLength]byte
+	key := appendReversedBytes(buf[:0], domain)
+	for n := 1; n <= len(key); n++ {
+		if n < len(key) && key[n] != '.' {
+			continue
+		}
+
+		i, found := f.search(key[:n])
+		if found {
+			if !fn(n) {
+				return
+			}
+			continue
+		}
+		// No longer suffix can be stored if no key starts with this one
+		if i == f.n || !bytes.HasPrefix(f.key(i), key[:n]) {
+			return
+		}
+	}
+}
+
+func (f *Flat) match(domain string, minLen int) int {
+	match := 0
+	f.walk(domain, func(n int) bool {
+		if n < len(domain) && n <= minLen {
+			return true
+		}
+		match = n
+		return false
+	})
+	return match
+}
+
+func (f *Flat) longest(domain string, proper bool) int {
+	longest := 0
+	f.walk(domain, func(n int) bool {
+		if !proper || n < len(domain) {
+			longest = n
+		}
+		return true
+	})
+	return longest
+}
+
+// all returns the domains of the list.
+func (f *Flat) all() []string {
+	defer runtime.KeepAlive(f)
+
+	domains := make([]string, f.n)
+	for i := range domains {
+		domains[i] = f.domain(i)
+	}
+	return domains
+}
+
+// under returns the domains of the list that equal suffix or are subdomains
+// of it.
+func (f *Flat) under(suffix string) []string {
+	defer runtime.KeepAlive(f)
+
+	key := appendReversedBytes(nil, suffix)
+	var domains []string
+	for i, _ := f.search(key); i < f.n; i++ {
+		k := f.key(i)
+		if !bytes.HasPrefix(k, key) {
+			break
+		}
+		if len(k) == len(key) || k[len(key)] == '.' {
+			domains = append(domains, f.domain(i))
+		}
+	}
+	return domains
+}
+
+// validate checks that the keys are sorted and unique.
+func (f *Flat) validate() error {
+	defer runtime.KeepAlive(f)
+
+	for i := 1; i < f.n; i++ {
+		if bytes.Compare(f.key(i-1), f.key(i)) >= 0 {
+			return fmt.Errorf("flat list is not sorted at %q", f.domain(i))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package trie
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFlatRoundTrip(t *testing.T) {
+	df := testDataFile(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		[]string{"tempmail.com", "mail.tempmail.com", "yopmail.com", "xn--80ak6aa92e.com"}, []string{"gmail.com"})
+	df.SourceCounts = map[string]int{"tempmail.com": 2}
+	df.BlockPatterns = []string{"*.temp-mail.*"}
+
+	ff, err := ParseFlat(marshalFlat(t, df))
+	if err != nil {
+		t.Fatalf("ParseFlat() error = %v", err)
+	}
+
+	meta := ff.DataFile()
+	if meta.Version != FormatVersion || !meta.CreatedAt.Equal(df.CreatedAt) || meta.DomainCount != 4 {
+		t.Errorf("DataFile() = %+v, want the metadata of the data file", meta)
+	}
+	if !reflect.DeepEqual(meta.SourceCounts, df.SourceCounts) || !reflect.DeepEqual(meta.BlockPatterns, df.BlockPatterns) {
+		t.Errorf("DataFile() = %+v, want source counts and patterns kept", meta)
+	}
+	if meta.Blocklist != nil || meta.Allowlist != nil {
+		t.Errorf("DataFile() lists = %v, %v, want none", meta.Blocklist, meta.Allowlist)
+	}
+
+	blocklist, allowlist := ff.NewTries()
+	got := blocklist.GetAll()
+	slices.Sort(got)
+	want := slices.Sorted(slices.Values(df.Blocklist))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAll() = %v, want %v", got, want)
+	}
+	if !allowlist.Contains("gmail.com") || allowlist.Size() != 1 {
+		t.Errorf("allowlist = %v, want [gmail.com]", allowlist.GetAll())
+	}
+	if err := blocklist.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestOverlayMatchesTrie(t *testing.T) {
+	testOverlay(t, func(domains []string) Base {
+		ff, err := ParseFlat(marshalFlat(t, &DataFile{Blocklist: domains}))
+		if err != nil {
+			t.Fatalf("ParseFlat() error = %v", err)
+		}
+		return ff.blocklist
+	})
+}
+
+// testOverlay checks that an overlay on a base made by newBase behaves like
+// a trie holding the same domains.
+func testOverlay(t *testing.T, newBase func(domains []string) Base) {
+	t.Helper()
+
+	base := []string{"tempmail.com", "a.b.example.org", "co.uk", "deep.x.y.z.test"}
+	inserted := []string{"mail.tempmail.com", "b.example.org", "yopmail.com"}
+
+	overlay := NewOverlay(newBase(base))
+	plain := New()
+	for _, domain := range base {
+		plain.Insert(domain)
+	}
+	for _, domain := range inserted {
+		overlay.Insert(domain)
+		plain.Insert(domain)
+	}
+
+	queries := []string{
+		"tempmail.com", "mail.tempmail.com", "x.mail.tempmail.com", "empmail.com",
+		"a.b.example.org", "c.a.b.example.org", "example.org", "b.example.org",
+		"foo.co.uk", "co.uk", "uk", "yopmail.com", "sub.yopmail.com",
+		"deep.x.y.z.test", "a.deep.x.y.z.test", "x.y.z.test", "",
+	}
+	for _, q := range queries {
+		if got, want := overlay.Contains(q), plain.Contains(q); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", q, got, want)
+		}
+		gotMatch, gotOK := overlay.HierarchicalMatch(q)
+		wantMatch, wantOK := plain.HierarchicalMatch(q)
+		if gotMatch != wantMatch || gotOK != wantOK {
+			t.Errorf("HierarchicalMatch(%q) = %q, %v, want %q, %v", q, gotMatch, gotOK, wantMatch, wantOK)
+		}
+		gotMatch, gotOK = overlay.HierarchicalMatchBelow(q, "co.uk")
+		wantMatch, wantOK = plain.HierarchicalMatchBelow(q, "co.uk")
+		if gotMatch != wantMatch || gotOK != wantOK {
+			t.Errorf("HierarchicalMatchBelow(%q) = %q, %v, want %q, %v", q, gotMatch, gotOK, wantMatch, wantOK)
+		}
+		gotMatch, gotOK = overlay.LongestHierarchicalMatch(q)
+		wantMatch, wantOK = plain.LongestHierarchicalMatch(q)
+		if gotMatch != wantMatch || gotOK != wantOK {
+			t.Errorf("LongestHierarchicalMatch(%q) = %q, %v, want %q, %v", q, gotMatch, gotOK, wantMatch, wantOK)
+		}
+		gotMatch, gotOK = overlay.NearestBlockedAncestor(q)
+		wantMatch, wantOK = plain.NearestBlockedAncestor(q)
+		if gotMatch != wantMatch || gotOK != wantOK {
+			t.Errorf("NearestBlockedAncestor(%q) = %q, %v, want %q, %v", q, gotMatch, gotOK, wantMatch, wantOK)
+		}
+		if got, want := overlay.AllSuffixMatches(q), plain.AllSuffixMatches(q); !reflect.DeepEqual(got, want) {
+			t.Errorf("AllSuffixMatches(%q) = %v, want %v", q, got, want)
+		}
+	}
+
+	if overlay.Size() != plain.Size() {
+		t.Errorf("Size() = %d, want %d", overlay.Size(), plain.Size())
+	}
+	if got, want := overlay.Minimal(), plain.Minimal(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Minimal() = %v, want %v", got, want)
+	}
+	if overlay.MinimalSize() != plain.MinimalSize() {
+		t.Errorf("MinimalSize() = %d, want %d", overlay.MinimalSize(), plain.MinimalSize())
+	}
+	got := overlay.GetUnder("example.org")
+	slices.Sort(got)
+	if want := []string{"a.b.example.org", "b.example.org"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetUnder(example.org) = %v, want %v", got, want)
+	}
+	if err := overlay.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// Base domains are reported as present but never stored twice
+	if !overlay.Insert("tempmail.com") || overlay.Size() != plain.Size() {
+		t.Errorf("Insert(tempmail.com) changed Size() to %d, want %d", overlay.Size(), plain.Size())
+	}
+	if overlay.Remove("tempmail.com") || !overlay.Contains("tempmail.com") {
+		t.Error("Remove(tempmail.com) removed a domain of the base")
+	}
+	if !overlay.Remove("yopmail.com") || overlay.Contains("yopmail.com") {
+		t.Error("Remove(yopmail.com) didn't remove an inserted domain")
+	}
+
+	overlay.Clear()
+	if overlay.Size() != 0 || overlay.Contains("tempmail.com") {
+		t.Errorf("Clear() left %v", overlay.GetAll())
+	}
+}
+
+func TestParseFlatInvalid(t *testing.T) {
+	valid := marshalFlat(t, &DataFile{Blocklist: []string{"tempmail.com"}, Allowlist: []string{"gmail.com"}})
+
+	withVersion := slices.Clone(valid)
+	binary.LittleEndian.PutUint32(withVersion[4:], 9)
+	badOffset := slices.Clone(valid)
+	binary.LittleEndian.PutUint32(badOffset[len(badOffset)-len("gmail.com")-4:], 1000)
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"empty", nil},
+		{"not flat", []byte("not a flat data file at all")},
+		{"truncated header", valid[:flatHeaderSize-1]},
+		{"truncated lists", valid[:len(valid)-1]},
+		{"trailing data", append(slices.Clone(valid), 0)},
+		{"unsupported layout", withVersion},
+		{"offset out of bounds", badOffset},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseFlat(tt.input); err == nil {
+			t.Errorf("ParseFlat(%s) error = nil, want an error", tt.name)
+		}
+	}
+
+	var unsupported *UnsupportedFormatError
+	if _, err := ParseFlat(withVersion); !errors.As(err, &unsupported) {
+		t.Errorf("ParseFlat(unsupported layout) error = %v, want UnsupportedFormatError", err)
+	}
+
+	// Unsorted keys parse, but fail validation
+	unsorted := marshalFlat(t, &DataFile{Blocklist: []string{"a.test", "b.test"}})
+	keys := unsorted[len(unsorted)-4-len("a.test")*2:]
+	copy(keys, "tset.btset.a")
+	ff, err := ParseFlat(unsorted)
+	if err != nil {
+		t.Fatalf("ParseFlat(unsorted) error = %v", err)
+	}
+	blocklist, _ := ff.NewTries()
+	if err := blocklist.Validate(); err == nil {
+		t.Error("Validate() of unsorted keys = nil, want an error")
+	}
+}
+
+func TestOpenFlat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.flat")
+	df := &DataFile{Blocklist: []string{"tempmail.com", "yopmail.com"}}
+	if err := os.WriteFile(path, marshalFlat(t, df), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	ff, err := OpenFlat(path)
+	if err != nil {
+		t.Fatalf("OpenFlat() error = %v", err)
+	}
+	blocklist, _ := ff.NewTries()
+	if !blocklist.ContainsHierarchical("mail.tempmail.com") || blocklist.Contains("gmail.com") {
+		t.Errorf("lookups in %v are wrong", blocklist.GetAll())
+	}
+
+	if _, err := OpenFlat(filepath.Join(t.TempDir(), "missing.flat")); err == nil {
+		t.Error("OpenFlat(missing) error = nil, want an error")
+	}
+	empty := filepath.Join(t.TempDir(), "empty.flat")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := OpenFlat(empty); err == nil {
+		t.Error("OpenFlat(empty) error = nil, want an error")
+	}
+}
+
+// marshalFlat returns MarshalFlat(df), failing the test on error.
+func marshalFlat(t testing.TB, df *DataFile) []byte {
+	t.Helper()
+	flat, err := MarshalFlat(df)
+	if err != nil {
+		t.Fatalf("MarshalFlat() error = %v", err)
+	}
+	return flat
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package trie
+
+import "os"
+
+// mapFile reads the file at path, on platforms without memory mapping.
+func mapFile(path string) (data []byte, unmap func([]byte) error, err error) {
+	data, err = os.ReadFile(path)
+	return data, nil, err
+}
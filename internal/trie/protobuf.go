@@ -0,0 +1,174 @@
+package trie
+
+import (
+	"time"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/datapb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// protobufMagic starts the decompressed contents of data files in format 2,
+// followed by a DataFile message as described in data/data.proto. Format 1
+// files, encoded with gob, never start with it.
+const protobufMagic = "DEPB"
+
+// deltaMagic likewise starts the decompressed contents of deltas, followed
+// by a Delta message.
+const deltaMagic = "DEDL"
+
+// marshalOptions sorts map entries, so the same data always gives the same
+// bytes and published digests only change with the data.
+var marshalOptions = proto.MarshalOptions{Deterministic: true}
+
+// marshalDataFile encodes df as a DataFile message.
+func marshalDataFile(df *DataFile) ([]byte, error) {
+	return marshalOptions.Marshal(&datapb.DataFile{
+		Version:       df.Version,
+		CreatedAt:     timestampProto(df.CreatedAt),
+		DomainCount:   int64(df.DomainCount),
+		Blocklist:     df.Blocklist,
+		Allowlist:     df.Allowlist,
+		SourceCounts:  sourceCountsProto(df.SourceCounts),
+		Providers:     df.Providers,
+		ProviderRules: providerRulesProto(df.ProviderRules),
+		BlockPatterns: df.BlockPatterns,
+		AllowPatterns: df.AllowPatterns,
+	})
+}
+
+// unmarshalDataFile decodes a DataFile message. Unknown fields are skipped.
+func unmarshalDataFile(b []byte) (*DataFile, error) {
+	var m datapb.DataFile
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return &DataFile{
+		Version:       m.Version,
+		CreatedAt:     timestampTime(m.CreatedAt),
+		DomainCount:   int(m.DomainCount),
+		Blocklist:     m.Blocklist,
+		Allowlist:     m.Allowlist,
+		SourceCounts:  sourceCountsMap(m.SourceCounts),
+		Providers:     m.Providers,
+		ProviderRules: providerRulesSlice(m.ProviderRules),
+		BlockPatterns: m.BlockPatterns,
+		AllowPatterns: m.AllowPatterns,
+	}, nil
+}
+
+// marshalDelta encodes d as a Delta message.
+func marshalDelta(d *Delta) ([]byte, error) {
+	return marshalOptions.Marshal(&datapb.Delta{
+		Version:          d.Version,
+		BaseCreatedAt:    timestampProto(d.BaseCreatedAt),
+		CreatedAt:        timestampProto(d.CreatedAt),
+		DomainCount:      int64(d.DomainCount),
+		AddedBlocklist:   d.AddedBlocklist,
+		RemovedBlocklist: d.RemovedBlocklist,
+		AddedAllowlist:   d.AddedAllowlist,
+		RemovedAllowlist: d.RemovedAllowlist,
+		HasSourceCounts:  d.HasSourceCounts,
+		SourceCounts:     sourceCountsProto(d.SourceCounts),
+		Providers:        d.Providers,
+		ProviderRules:    providerRulesProto(d.ProviderRules),
+		BlockPatterns:    d.BlockPatterns,
+		AllowPatterns:    d.AllowPatterns,
+	})
+}
+
+// unmarshalDelta decodes a Delta message. Unknown fields are skipped.
+func unmarshalDelta(b []byte) (*Delta, error) {
+	var m datapb.Delta
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return &Delta{
+		Version:          m.Version,
+		BaseCreatedAt:    timestampTime(m.BaseCreatedAt),
+		CreatedAt:        timestampTime(m.CreatedAt),
+		DomainCount:      int(m.DomainCount),
+		AddedBlocklist:   m.AddedBlocklist,
+		RemovedBlocklist: m.RemovedBlocklist,
+		AddedAllowlist:   m.AddedAllowlist,
+		RemovedAllowlist: m.RemovedAllowlist,
+		HasSourceCounts:  m.HasSourceCounts,
+		SourceCounts:     sourceCountsMap(m.SourceCounts),
+		Providers:        m.Providers,
+		ProviderRules:    providerRulesSlice(m.ProviderRules),
+		BlockPatterns:    m.BlockPatterns,
+		AllowPatterns:    m.AllowPatterns,
+	}, nil
+}
+
+// readProtobufVersion decodes only the version of a DataFile or Delta
+// message, which both keep it in field 1 as FormatHeader does. The other
+// fields are skipped, whatever a later major version encodes in them.
+func readProtobufVersion(b []byte) (string, error) {
+	var header datapb.FormatHeader
+	if err := (proto.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(b, &header); err != nil {
+		return "", err
+	}
+	return header.Version, nil
+}
+
+// timestampProto converts t to a google.protobuf.Timestamp, leaving the zero
+// time unset.
+func timestampProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// timestampTime converts ts to a UTC time, or the zero time if it is unset.
+func timestampTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func sourceCountsProto(counts map[string]int) map[string]int64 {
+	if counts == nil {
+		return nil
+	}
+	m := make(map[string]int64, len(counts))
+	for domain, n := range counts {
+		m[domain] = int64(n)
+	}
+	return m
+}
+
+func sourceCountsMap(counts map[string]int64) map[string]int {
+	if counts == nil {
+		return nil
+	}
+	m := make(map[string]int, len(counts))
+	for domain, n := range counts {
+		m[domain] = int(n)
+	}
+	return m
+}
+
+func providerRulesProto(rules []ProviderRule) []*datapb.ProviderRule {
+	if rules == nil {
+		return nil
+	}
+	m := make([]*datapb.ProviderRule, len(rules))
+	for i, rule := range rules {
+		m[i] = &datapb.ProviderRule{Domains: rule.Domains, Separators: rule.Separators, IgnoreDots: rule.IgnoreDots}
+	}
+	return m
+}
+
+func providerRulesSlice(rules []*datapb.ProviderRule) []ProviderRule {
+	if rules == nil {
+		return nil
+	}
+	s := make([]ProviderRule, len(rules))
+	for i, rule := range rules {
+		s[i] = ProviderRule{Domains: rule.Domains, Separators: rule.Separators, IgnoreDots: rule.IgnoreDots}
+	}
+	return s
+}
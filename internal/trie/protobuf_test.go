@@ -0,0 +1,130 @@
+package trie
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestMarshalDataFileWireFormat(t *testing.T) {
+	// Bytes as any Protocol Buffers library encodes them for data/data.proto
+	df := &DataFile{
+		Version:       "2.0",
+		CreatedAt:     time.Unix(1, 2),
+		DomainCount:   1,
+		Blocklist:     []string{"a.io"},
+		SourceCounts:  map[string]int{"a.io": 3},
+		ProviderRules: []ProviderRule{{Domains: []string{"g.io"}, Separators: "+", IgnoreDots: true}},
+	}
+	expected := []byte{
+		0x0a, 0x03, '2', '.', '0', // version
+		0x12, 0x04, 0x08, 0x01, 0x10, 0x02, // created_at {seconds: 1, nanos: 2}
+		0x18, 0x01, // domain_count
+		0x22, 0x04, 'a', '.', 'i', 'o', // blocklist
+		0x32, 0x08, 0x0a, 0x04, 'a', '.', 'i', 'o', 0x10, 0x03, // source_counts entry
+		0x42, 0x0b, 0x0a, 0x04, 'g', '.', 'i', 'o', 0x12, 0x01, '+', 0x18, 0x01, // provider_rules
+	}
+
+	if got := mustMarshalDataFile(t, df); !bytes.Equal(got, expected) {
+		t.Errorf("marshalDataFile() = % x, want % x", got, expected)
+	}
+}
+
+func TestMarshalDataFileRoundTrip(t *testing.T) {
+	df := &DataFile{
+		Version:       FormatVersion,
+		CreatedAt:     time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		DomainCount:   2,
+		Blocklist:     []string{"tempmail.com", "yopmail.com"},
+		Allowlist:     []string{"gmail.com"},
+		SourceCounts:  map[string]int{"tempmail.com": 3, "yopmail.com": 1},
+		Providers:     []string{"gmail.com", "outlook.com"},
+		ProviderRules: []ProviderRule{{Domains: []string{"gmail.com", "googlemail.com"}, Separators: "+", IgnoreDots: true}, {Domains: []string{"outlook.com"}}},
+		BlockPatterns: []string{"*.temp-mail.*"},
+		AllowPatterns: []string{"temp-mail.gov*"},
+	}
+
+	encoded := mustMarshalDataFile(t, df)
+	got, err := unmarshalDataFile(encoded)
+	if err != nil {
+		t.Fatalf("unmarshalDataFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, df) {
+		t.Errorf("unmarshalDataFile() = %+v, want %+v", got, df)
+	}
+
+	// Maps are written in a fixed order, so equal data gives equal files
+	for range 10 {
+		if again := mustMarshalDataFile(t, df); !bytes.Equal(again, encoded) {
+			t.Fatal("marshalDataFile() is not deterministic")
+		}
+	}
+
+	// Empty data encodes to nothing, and decodes to nil lists
+	if encoded := mustMarshalDataFile(t, &DataFile{}); len(encoded) != 0 {
+		t.Errorf("marshalDataFile(empty) = % x, want nothing", encoded)
+	}
+	if got, err := unmarshalDataFile(nil); err != nil || !reflect.DeepEqual(got, &DataFile{}) {
+		t.Errorf("unmarshalDataFile(nil) = %+v, %v, want empty data file", got, err)
+	}
+}
+
+func TestUnmarshalDataFileInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"truncated tag", []byte{0x80}},
+		{"truncated varint", []byte{0x18, 0x80}},
+		{"truncated string", []byte{0x0a, 0x05, 'a'}},
+		{"truncated fixed64", []byte{0x09, 0x01}},
+		{"field zero", []byte{0x00, 0x01}},
+		{"group wire type", []byte{0x0b}},
+		{"invalid UTF-8", []byte{0x22, 0x01, 0xff}},
+		{"invalid nested message", []byte{0x42, 0x02, 0x0a, 0x05}},
+	}
+
+	for _, tt := range tests {
+		if got, err := unmarshalDataFile(tt.input); err == nil {
+			t.Errorf("unmarshalDataFile(%s) = %+v, want error", tt.name, got)
+		}
+	}
+}
+
+func TestReadProtobufVersion(t *testing.T) {
+	encoded := mustMarshalDataFile(t, &DataFile{Version: "2.4", Blocklist: []string{"tempmail.com"}})
+	if version, err := readProtobufVersion(encoded); err != nil || version != "2.4" {
+		t.Errorf("readProtobufVersion() = %q, %v, want %q", version, err, "2.4")
+	}
+
+	// Fields after the version are still checked for framing
+	if _, err := readProtobufVersion(append(encoded, 0x22, 0x05)); err == nil {
+		t.Error("readProtobufVersion() of a truncated message succeeded, want error")
+	}
+
+	// Other fields may hold anything, as in a later major version
+	changed := protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), "3.0")
+	changed = protowire.AppendVarint(protowire.AppendTag(changed, 4, protowire.VarintType), 7)
+	if version, err := readProtobufVersion(changed); err != nil || version != "3.0" {
+		t.Errorf("readProtobufVersion() = %q, %v, want %q", version, err, "3.0")
+	}
+}
+
+func TestMarshalDataFileInvalidUTF8(t *testing.T) {
+	if _, err := marshalDataFile(&DataFile{Blocklist: []string{"\xff.com"}}); err == nil {
+		t.Error("marshalDataFile() of an invalid UTF-8 domain succeeded, want error")
+	}
+}
+
+// mustMarshalDataFile returns marshalDataFile(df), failing the test on error.
+func mustMarshalDataFile(t testing.TB, df *DataFile) []byte {
+	t.Helper()
+	b, err := marshalDataFile(df)
+	if err != nil {
+		t.Fatalf("marshalDataFile() error = %v", err)
+	}
+	return b
+}
@@ -4,11 +4,118 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 )
 
+// FormatVersion is the version written to new data files, as
+// "major.minor".
+//
+// Within a major version the format only grows: a minor version may add
+// optional fields, which readers of earlier minor versions skip and which are
+// zero when reading files of earlier minor versions. A major version may
+// change or drop existing fields. Readers decode every major version they have
+// a decoder for, and report an UnsupportedFormatError for newer ones instead
+// of misreading them.
+//
+// Format 1 encoded data files with gob, which only Go reads. Format 2 encodes
+// them with Protocol Buffers, as described in data/data.proto, so that tools
+// in other languages can read them too.
+const FormatVersion = "2.0"
+
+// UnsupportedFormatError is returned by Deserialize for data files written in
+// a newer, incompatible format, so callers can fall back to older data.
+type UnsupportedFormatError struct {
+	Version string // Format version found in the data file
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	return fmt.Sprintf("unsupported data format version %q (supported: %s)", e.Version, supportedVersions())
+}
+
+// formatHeader is decoded from a gob-encoded data file before anything else,
+// so that its version is known even when its other fields are encoded in a
+// way this reader can't decode. Every format version keeps the Version field.
+type formatHeader struct {
+	Version string
+}
+
+// decoders decodes the decompressed contents of each supported major version
+// into the current DataFile. A new major version adds its decoder here and keeps the
+// earlier ones, which migrate their files to the current DataFile, so that new
+// readers keep reading old files.
+var decoders = map[string]func(payload []byte) (*DataFile, error){
+	"1": decodeV1,
+	"2": decodeV2,
+}
+
+// decodeV1 decodes a data file in format 1, a gob-encoded DataFile with the
+// fields DataFile had when format 2 replaced it.
+func decodeV1(payload []byte) (*DataFile, error) {
+	var dataFile DataFile
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&dataFile); err != nil {
+		return nil, fmt.Errorf("gob: %w", err)
+	}
+	return &dataFile, nil
+}
+
+// decodeV2 decodes a data file in format 2, the current one.
+func decodeV2(payload []byte) (*DataFile, error) {
+	message, ok := bytes.CutPrefix(payload, []byte(protobufMagic))
+	if !ok {
+		return nil, errors.New("protobuf: missing format marker")
+	}
+	return unmarshalDataFile(message)
+}
+
+// majorVersion returns the major number of a format version. Files without
+// a version predate versioning and are in format 1.
+func majorVersion(version string) string {
+	if version == "" {
+		return "1"
+	}
+	major, _, _ := strings.Cut(version, ".")
+	return major
+}
+
+// supportedVersion reports whether a data file version can be read.
+func supportedVersion(version string) bool {
+	_, ok := decoders[majorVersion(version)]
+	return ok
+}
+
+// supportedVersions lists the readable major versions, e.g. "1.x".
+func supportedVersions() string {
+	majors := slices.Sorted(maps.Keys(decoders))
+	for i, major := range majors {
+		majors[i] = major + ".x"
+	}
+	return strings.Join(majors, ", ")
+}
+
+// readVersion decodes the format version of decompressed data, encoded with
+// Protocol Buffers from format 2 on and with gob before.
+func readVersion(payload []byte) (string, error) {
+	if message, ok := bytes.CutPrefix(payload, []byte(protobufMagic)); ok {
+		return readProtobufVersion(message)
+	}
+
+	var header formatHeader
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&header); err != nil {
+		return "", fmt.Errorf("gob: %w", err)
+	}
+	return header.Version, nil
+}
+
+// DefaultCompressionLevel is the gzip level used for published data files.
+// It favors size over speed.
+const DefaultCompressionLevel = gzip.BestCompression
+
 // DataFile represents the serialized data format.
 type DataFile struct {
 	Version     string    // Version identifier
@@ -16,33 +123,110 @@ type DataFile struct {
 	DomainCount int       // Number of domains
 	Blocklist   []string  // List of blocked domains (stored as list for smaller size)
 	Allowlist   []string  // List of allowed domains
+
+	// Number of sources listing each blocklist domain. Optional: nil in
+	// data files generated without source counts.
+	SourceCounts map[string]int
+
+	// Domains of well-known mail providers, most popular first, for typo
+	// suggestions. Optional: nil in data files generated without them.
+	Providers []string
+
+	// How providers canonicalize addresses, for email normalization.
+	// Optional: nil in data files generated without them.
+	ProviderRules []ProviderRule
+
+	// Wildcard patterns such as "*.temp-mail.*" matched alongside the
+	// lists, for providers that rotate TLDs faster than lists can follow.
+	// Optional: nil in data files generated without them.
+	BlockPatterns []string
+	AllowPatterns []string
+}
+
+// ProviderRule describes how a mail provider treats the local part of its
+// addresses, so that addresses delivered to the same mailbox can be
+// normalized to one form.
+type ProviderRule struct {
+	// Domains of the provider. The first is the canonical one that the
+	// others are aliases of, e.g. "gmail.com" for "googlemail.com".
+	Domains []string
+
+	// Characters that start a subaddress tag, e.g. "+" or "-"
+	Separators string
+
+	// Whether dots in the local part are ignored, as by Gmail
+	IgnoreDots bool
 }
 
-// Serialize serializes the blocklist and allowlist tries to a compressed binary format.
-func Serialize(blocklist, allowlist *Trie) ([]byte, error) {
-	data := DataFile{
-		Version:     "1.0",
-		CreatedAt:   time.Now().UTC(),
+// NewDataFile returns a data file in the current format holding the domains
+// of the blocklist and allowlist tries, created at createdAt. The lists are
+// sorted by reversed domain, the order in which NewDAFSA adds them when they
+// are read back, so its sort finds them already in order.
+func NewDataFile(blocklist, allowlist *Trie, createdAt time.Time) *DataFile {
+	df := &DataFile{
+		Version:     FormatVersion,
+		CreatedAt:   createdAt.UTC(),
 		DomainCount: blocklist.Size(),
 		Blocklist:   blocklist.GetAll(),
 		Allowlist:   allowlist.GetAll(),
 	}
+	sortReversed(df.Blocklist)
+	sortReversed(df.Allowlist)
+	return df
+}
+
+// Serialize serializes the blocklist and allowlist tries to a compressed binary format,
+// created at the current time; use NewDataFile and SerializeDataFile for another time.
+// level is a gzip compression level, from gzip.HuffmanOnly to gzip.BestCompression;
+// lower levels are faster but produce larger output.
+func Serialize(blocklist, allowlist *Trie, level int) ([]byte, error) {
+	return SerializeDataFile(NewDataFile(blocklist, allowlist, time.Now()), level)
+}
+
+// SerializeDataFile encodes a data file in the same compressed binary format
+// as Serialize, keeping its metadata as is except for the version, which is
+// always FormatVersion, the format it is written in.
+func SerializeDataFile(data *DataFile, level int) ([]byte, error) {
+	// Encode with Protocol Buffers
+	current := *data
+	current.Version = FormatVersion
+	message, err := marshalDataFile(&current)
+	if err != nil {
+		return nil, fmt.Errorf("protobuf encode failed: %w", err)
+	}
+
+	return compress(append([]byte(protobufMagic), message...), level)
+}
+
+// LegacyFormatVersion is the version of data files written by
+// SerializeDataFileV1.
+const LegacyFormatVersion = "1.0"
+
+// SerializeDataFileV1 encodes a data file in format 1, gob-encoded and
+// compressed, for releases of this package that predate format 2 and can't
+// read it. Its version is LegacyFormatVersion. Fields added since format 1
+// are written too; gob skips those the reader's DataFile doesn't have.
+func SerializeDataFileV1(data *DataFile, level int) ([]byte, error) {
+	legacy := *data
+	legacy.Version = LegacyFormatVersion
 
-	// Encode to gob
 	var gobBuf bytes.Buffer
-	encoder := gob.NewEncoder(&gobBuf)
-	if err := encoder.Encode(data); err != nil {
+	if err := gob.NewEncoder(&gobBuf).Encode(&legacy); err != nil {
 		return nil, fmt.Errorf("gob encode failed: %w", err)
 	}
 
-	// Compress with gzip
+	return compress(gobBuf.Bytes(), level)
+}
+
+// compress compresses payload with gzip at the given level.
+func compress(payload []byte, level int) ([]byte, error) {
 	var gzipBuf bytes.Buffer
-	gzipWriter, err := gzip.NewWriterLevel(&gzipBuf, gzip.BestCompression)
+	gzipWriter, err := gzip.NewWriterLevel(&gzipBuf, level)
 	if err != nil {
 		return nil, fmt.Errorf("gzip writer creation failed: %w", err)
 	}
 
-	if _, err := gzipWriter.Write(gobBuf.Bytes()); err != nil {
+	if _, err := gzipWriter.Write(payload); err != nil {
 		return nil, fmt.Errorf("gzip write failed: %w", err)
 	}
 
@@ -55,42 +239,57 @@ func Serialize(blocklist, allowlist *Trie) ([]byte, error) {
 
 // Deserialize deserializes compressed binary data into blocklist and allowlist tries.
 func Deserialize(data []byte) (*Trie, *Trie, *DataFile, error) {
+	dataFile, err := DecodeDataFile(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	blocklist, allowlist := NewTries(dataFile)
+	return blocklist, allowlist, dataFile, nil
+}
+
+// NewTries returns tries holding the blocklist and allowlist of df. The lists
+// are read-only, so they are kept in compact DAFSAs under the tries, which
+// only hold domains inserted later.
+func NewTries(df *DataFile) (blocklist, allowlist *Trie) {
+	return NewOverlay(NewDAFSA(df.Blocklist)), NewOverlay(NewDAFSA(df.Allowlist))
+}
+
+// DecodeDataFile decodes compressed binary data like Deserialize, without
+// building tries, for callers that only need the lists as they are stored.
+func DecodeDataFile(data []byte) (*DataFile, error) {
 	// Decompress with gzip
 	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("gzip reader creation failed: %w", err)
+		return nil, fmt.Errorf("gzip reader creation failed: %w", err)
 	}
 	defer gzipReader.Close()
 
 	decompressed, err := io.ReadAll(gzipReader)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("gzip read failed: %w", err)
+		return nil, fmt.Errorf("gzip read failed: %w", err)
 	}
 
-	// Decode from gob
-	var dataFile DataFile
-	decoder := gob.NewDecoder(bytes.NewReader(decompressed))
-	if err := decoder.Decode(&dataFile); err != nil {
-		return nil, nil, nil, fmt.Errorf("gob decode failed: %w", err)
+	// Decode with the decoder for its major version
+	version, err := readVersion(decompressed)
+	if err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
 	}
-
-	// Build tries from domain lists
-	blocklist := New()
-	for _, domain := range dataFile.Blocklist {
-		blocklist.Insert(domain)
+	decode, ok := decoders[majorVersion(version)]
+	if !ok {
+		return nil, &UnsupportedFormatError{Version: version}
 	}
-
-	allowlist := New()
-	for _, domain := range dataFile.Allowlist {
-		allowlist.Insert(domain)
+	dataFile, err := decode(decompressed)
+	if err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
 	}
-
-	return blocklist, allowlist, &dataFile, nil
+	return dataFile, nil
 }
 
-// SerializeToWriter serializes the tries and writes to an io.Writer.
+// SerializeToWriter serializes the tries at DefaultCompressionLevel and writes
+// to an io.Writer.
 func SerializeToWriter(blocklist, allowlist *Trie, w io.Writer) error {
-	data, err := Serialize(blocklist, allowlist)
+	data, err := Serialize(blocklist, allowlist, DefaultCompressionLevel)
 	if err != nil {
 		return err
 	}
@@ -2,7 +2,17 @@ package trie
 
 import (
 	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
 func TestSerializeDeserialize(t *testing.T) {
@@ -17,7 +27,7 @@ func TestSerializeDeserialize(t *testing.T) {
 	allowlist.Insert("outlook.com")
 
 	// Serialize
-	data, err := Serialize(blocklist, allowlist)
+	data, err := Serialize(blocklist, allowlist, DefaultCompressionLevel)
 	if err != nil {
 		t.Fatalf("Serialize failed: %v", err)
 	}
@@ -56,14 +66,169 @@ func TestSerializeDeserialize(t *testing.T) {
 	}
 
 	// Verify metadata
-	if dataFile.Version != "1.0" {
-		t.Errorf("Version mismatch: got %s, want 1.0", dataFile.Version)
+	if dataFile.Version != FormatVersion {
+		t.Errorf("Version mismatch: got %s, want %s", dataFile.Version, FormatVersion)
 	}
 	if dataFile.DomainCount != 3 {
 		t.Errorf("DomainCount mismatch: got %d, want 3", dataFile.DomainCount)
 	}
 }
 
+func TestDecodeDataFile(t *testing.T) {
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	df := &DataFile{Version: FormatVersion, CreatedAt: created, DomainCount: 2,
+		Blocklist: []string{"tempmail.com", "mail.tempmail.com"}, Allowlist: []string{"gmail.com"}}
+	data, err := SerializeDataFile(df, DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDataFile() error = %v", err)
+	}
+
+	got, err := DecodeDataFile(data)
+	if err != nil {
+		t.Fatalf("DecodeDataFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Blocklist, df.Blocklist) || !reflect.DeepEqual(got.Allowlist, df.Allowlist) || !got.CreatedAt.Equal(created) {
+		t.Errorf("DecodeDataFile() = %+v, want %+v", got, df)
+	}
+
+	if _, err := DecodeDataFile([]byte("not gzip")); err == nil {
+		t.Error("DecodeDataFile() of garbage succeeded, want an error")
+	}
+}
+
+func TestSerializeCreatedAt(t *testing.T) {
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+
+	blocklist := New()
+	blocklist.Insert("test.com")
+
+	data, err := SerializeDataFile(NewDataFile(blocklist, New(), fixed), DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDataFile failed: %v", err)
+	}
+
+	_, _, dataFile, err := Deserialize(data)
+	if err != nil {
+		t.Fatalf("Deserialize failed: %v", err)
+	}
+
+	if !dataFile.CreatedAt.Equal(fixed) {
+		t.Errorf("CreatedAt = %v, want %v", dataFile.CreatedAt, fixed)
+	}
+}
+
+func TestSerializeSourceCounts(t *testing.T) {
+	blocklist := New()
+	blocklist.Insert("tempmail.com")
+	blocklist.Insert("yopmail.com")
+
+	df := NewDataFile(blocklist, New(), time.Now())
+	df.SourceCounts = map[string]int{"tempmail.com": 3, "yopmail.com": 1}
+
+	data, err := SerializeDataFile(df, DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDataFile failed: %v", err)
+	}
+	_, _, restored, err := Deserialize(data)
+	if err != nil {
+		t.Fatalf("Deserialize failed: %v", err)
+	}
+	if !reflect.DeepEqual(restored.SourceCounts, df.SourceCounts) {
+		t.Errorf("SourceCounts = %v, want %v", restored.SourceCounts, df.SourceCounts)
+	}
+
+	// Counts are optional
+	data, err = Serialize(blocklist, New(), DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	_, _, restored, err = Deserialize(data)
+	if err != nil {
+		t.Fatalf("Deserialize failed: %v", err)
+	}
+	if restored.SourceCounts != nil {
+		t.Errorf("SourceCounts = %v, want nil", restored.SourceCounts)
+	}
+}
+
+func TestSerializeLevel(t *testing.T) {
+	blocklist := New()
+	for i := 0; i < 1000; i++ {
+		blocklist.Insert(fmt.Sprintf("domain%d.com", i))
+	}
+	allowlist := New()
+	allowlist.Insert("gmail.com")
+
+	fast, err := Serialize(blocklist, allowlist, gzip.BestSpeed)
+	if err != nil {
+		t.Fatalf("Serialize(BestSpeed) failed: %v", err)
+	}
+	best, err := Serialize(blocklist, allowlist, gzip.BestCompression)
+	if err != nil {
+		t.Fatalf("Serialize(BestCompression) failed: %v", err)
+	}
+	t.Logf("BestSpeed: %d bytes, BestCompression: %d bytes", len(fast), len(best))
+
+	restoredBlocklist, restoredAllowlist, _, err := Deserialize(fast)
+	if err != nil {
+		t.Fatalf("Deserialize failed: %v", err)
+	}
+	if restoredBlocklist.Size() != blocklist.Size() || !restoredBlocklist.Contains("domain999.com") {
+		t.Errorf("Restored blocklist has %d domains, want %d", restoredBlocklist.Size(), blocklist.Size())
+	}
+	if !restoredAllowlist.Contains("gmail.com") {
+		t.Error("Restored allowlist should contain gmail.com")
+	}
+
+	if _, err := Serialize(blocklist, allowlist, 42); err == nil {
+		t.Error("Expected error for invalid compression level")
+	}
+}
+
+func TestSerializeDataFileV1(t *testing.T) {
+	df := &DataFile{Version: FormatVersion, CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), DomainCount: 1,
+		Blocklist: []string{"tempmail.com"}, Allowlist: []string{"gmail.com"}, SourceCounts: map[string]int{"tempmail.com": 2},
+		Providers: []string{"gmail.com"}, BlockPatterns: []string{"*.temp-mail.*"},
+		ProviderRules: []ProviderRule{{Domains: []string{"gmail.com"}, Separators: "+", IgnoreDots: true}}}
+
+	data, err := SerializeDataFileV1(df, DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDataFileV1() error = %v", err)
+	}
+
+	// Releases before format 2 decode it into the DataFile they have,
+	// down to the first one, which had no optional fields
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	var initial struct {
+		Version     string
+		CreatedAt   time.Time
+		DomainCount int
+		Blocklist   []string
+		Allowlist   []string
+	}
+	if err := gob.NewDecoder(gzipReader).Decode(&initial); err != nil {
+		t.Fatalf("gob decode into the initial DataFile failed: %v", err)
+	}
+	if initial.Version != LegacyFormatVersion || !initial.CreatedAt.Equal(df.CreatedAt) ||
+		!reflect.DeepEqual(initial.Blocklist, df.Blocklist) || !reflect.DeepEqual(initial.Allowlist, df.Allowlist) {
+		t.Errorf("Initial DataFile = %+v, want the fields of %+v", initial, df)
+	}
+
+	// Current releases read it as format 1
+	_, _, got, err := Deserialize(data)
+	if err != nil {
+		t.Fatalf("Deserialize() error = %v", err)
+	}
+	want := *df
+	want.Version = LegacyFormatVersion
+	if !reflect.DeepEqual(got, &want) {
+		t.Errorf("Deserialize() = %+v, want %+v", got, &want)
+	}
+}
+
 func TestSerializeToWriter(t *testing.T) {
 	blocklist := New()
 	blocklist.Insert("test.com")
@@ -87,7 +252,7 @@ func TestDeserializeFromReader(t *testing.T) {
 
 	allowlist := New()
 
-	data, err := Serialize(blocklist, allowlist)
+	data, err := Serialize(blocklist, allowlist, DefaultCompressionLevel)
 	if err != nil {
 		t.Fatalf("Serialize failed: %v", err)
 	}
@@ -106,6 +271,189 @@ func TestDeserializeFromReader(t *testing.T) {
 	}
 }
 
+func TestDeserializeUnsupportedFormat(t *testing.T) {
+	tests := []struct {
+		version   string
+		protobuf  bool // encoded as in format 2, else with gob as in format 1
+		supported bool
+	}{
+		{"1.0", false, true},
+		{"1.7", false, true},
+		{"", false, true},
+		{"2.0", true, true},
+		{"2.3", true, true},
+		{"3.0", true, false},
+		{"10.1", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			df := &DataFile{Version: tt.version, Blocklist: []string{"test.com"}}
+			var data []byte
+			if tt.protobuf {
+				data = encodePayload(t, df)
+			} else {
+				data = encodeGob(t, df)
+			}
+
+			_, _, _, err := Deserialize(data)
+			if tt.supported {
+				if err != nil {
+					t.Errorf("Deserialize(version %q) error = %v, want nil", tt.version, err)
+				}
+				return
+			}
+
+			var formatErr *UnsupportedFormatError
+			if !errors.As(err, &formatErr) {
+				t.Fatalf("Deserialize(version %q) error = %v, want UnsupportedFormatError", tt.version, err)
+			}
+			if formatErr.Version != tt.version {
+				t.Errorf("UnsupportedFormatError.Version = %q, want %q", formatErr.Version, tt.version)
+			}
+		})
+	}
+}
+
+// encodeGob gob-encodes and compresses v as format 1 data files were, for
+// values other than the current DataFile.
+func encodeGob(t *testing.T, v any) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	if err := gob.NewEncoder(gzipWriter).Encode(v); err != nil {
+		t.Fatalf("gob encode failed: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("gzip close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// encodePayload encodes and compresses df as SerializeDataFile does, but
+// keeping its version.
+func encodePayload(t *testing.T, df *DataFile) []byte {
+	t.Helper()
+	return gzipPayload(t, append([]byte(protobufMagic), mustMarshalDataFile(t, df)...))
+}
+
+// gzipPayload compresses the decompressed contents of a data file.
+func gzipPayload(t *testing.T, payload []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	if _, err := gzipWriter.Write(payload); err != nil {
+		t.Fatalf("gzip write failed: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("gzip close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDeserializeCompatibility(t *testing.T) {
+	// A format 1 file from before the optional fields were added
+	type dataFileV1Initial struct {
+		Version     string
+		CreatedAt   time.Time
+		DomainCount int
+		Blocklist   []string
+		Allowlist   []string
+	}
+	old := encodeGob(t, dataFileV1Initial{Version: "1.0", DomainCount: 1,
+		Blocklist: []string{"tempmail.com"}, Allowlist: []string{"gmail.com"}})
+
+	blocklist, allowlist, df, err := Deserialize(old)
+	if err != nil {
+		t.Fatalf("Deserialize(old file) failed: %v", err)
+	}
+	if !blocklist.Contains("tempmail.com") || !allowlist.Contains("gmail.com") {
+		t.Error("Expected the domains of the old file to be loaded")
+	}
+	if df.SourceCounts != nil || df.Providers != nil || df.BlockPatterns != nil {
+		t.Errorf("Optional fields of an old file = %+v, want nil", df)
+	}
+
+	// A format 1 file with all fields, as cached before format 2
+	full := &DataFile{Version: "1.0", CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), DomainCount: 1,
+		Blocklist: []string{"tempmail.com"}, SourceCounts: map[string]int{"tempmail.com": 2},
+		Providers: []string{"gmail.com"}, BlockPatterns: []string{"*.temp-mail.*"},
+		ProviderRules: []ProviderRule{{Domains: []string{"gmail.com"}, Separators: "+", IgnoreDots: true}}}
+	_, _, df, err = Deserialize(encodeGob(t, full))
+	if err != nil {
+		t.Fatalf("Deserialize(format 1 file) failed: %v", err)
+	}
+	if !reflect.DeepEqual(df, full) {
+		t.Errorf("Deserialize(format 1 file) = %+v, want %+v", df, full)
+	}
+
+	// A file from a later minor version, with fields this reader doesn't know
+	later := mustMarshalDataFile(t, &DataFile{Version: "2.9", Blocklist: []string{"tempmail.com"}, Providers: []string{"gmail.com"}})
+	later = protowire.AppendString(protowire.AppendTag(later, 99, protowire.BytesType), "new section")
+	later = protowire.AppendVarint(protowire.AppendTag(later, 100, protowire.VarintType), 42)
+	later = protowire.AppendFixed64(protowire.AppendTag(later, 101, protowire.Fixed64Type), 7)
+
+	blocklist, _, df, err = Deserialize(gzipPayload(t, append([]byte(protobufMagic), later...)))
+	if err != nil {
+		t.Fatalf("Deserialize(newer minor version) failed: %v", err)
+	}
+	if !blocklist.Contains("tempmail.com") || !reflect.DeepEqual(df.Providers, []string{"gmail.com"}) {
+		t.Errorf("Deserialize(newer minor version) = %+v, want its known fields", df)
+	}
+
+	// A file from a later major version whose fields this reader can't decode
+	type dataFileV3 struct {
+		Version   string
+		Blocklist []int
+	}
+	incompatible := encodeGob(t, dataFileV3{Version: "3.0", Blocklist: []int{1}})
+
+	_, _, _, err = Deserialize(incompatible)
+	var formatErr *UnsupportedFormatError
+	if !errors.As(err, &formatErr) || formatErr.Version != "3.0" {
+		t.Errorf("Deserialize(newer major version) error = %v, want UnsupportedFormatError", err)
+	}
+}
+
+func TestDeserializeMigration(t *testing.T) {
+	// A major version is read by its decoder, which migrates it to DataFile
+	type dataFileV3 struct {
+		Version string
+		Domains map[string]bool // blocked or allowed
+	}
+	decoders["3"] = func(payload []byte) (*DataFile, error) {
+		var v3 dataFileV3
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&v3); err != nil {
+			return nil, err
+		}
+		df := &DataFile{Version: v3.Version}
+		for domain, blocked := range v3.Domains {
+			if blocked {
+				df.Blocklist = append(df.Blocklist, domain)
+			} else {
+				df.Allowlist = append(df.Allowlist, domain)
+			}
+		}
+		return df, nil
+	}
+	defer delete(decoders, "3")
+
+	data := encodeGob(t, dataFileV3{Version: "3.0", Domains: map[string]bool{"tempmail.com": true, "gmail.com": false}})
+	blocklist, allowlist, df, err := Deserialize(data)
+	if err != nil {
+		t.Fatalf("Deserialize failed: %v", err)
+	}
+	if !blocklist.Contains("tempmail.com") || !allowlist.Contains("gmail.com") || df.Version != "3.0" {
+		t.Errorf("Deserialize(version 3.0) = %+v, want migrated domains", df)
+	}
+
+	if got := (&UnsupportedFormatError{Version: "4.0"}).Error(); !strings.Contains(got, "1.x, 2.x, 3.x") {
+		t.Errorf("UnsupportedFormatError.Error() = %q, want the supported versions", got)
+	}
+}
+
 func TestDeserializeInvalidData(t *testing.T) {
 	// Test with invalid data
 	invalidData := []byte("this is not valid gzip data")
@@ -115,6 +463,87 @@ func TestDeserializeInvalidData(t *testing.T) {
 	}
 }
 
+// fuzzDomains turns arbitrary fuzz input into a set of valid domains: the
+// input is split on commas and every character outside [a-z0-9.-] is dropped.
+func fuzzDomains(input string) *Trie {
+	t := New()
+	for _, part := range strings.Split(input, ",") {
+		domain := strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' {
+				return r
+			}
+			return -1
+		}, strings.ToLower(part))
+		if domain != "" {
+			t.Insert(domain)
+		}
+	}
+	return t
+}
+
+func FuzzSerializeRoundTrip(f *testing.F) {
+	f.Add("tempmail.com,mail.tempmail.com", "gmail.com")
+	f.Add("a.b.c.d.e.f.g.h.i.j.k.example.co.uk", "")
+	f.Add("", "")
+	f.Add("-.-,..,x", "UPPER.COM,weird$chars.org")
+
+	f.Fuzz(func(t *testing.T, block, allow string) {
+		blocklist := fuzzDomains(block)
+		allowlist := fuzzDomains(allow)
+
+		data, err := Serialize(blocklist, allowlist, DefaultCompressionLevel)
+		if err != nil {
+			t.Fatalf("Serialize failed: %v", err)
+		}
+
+		restoredBlocklist, restoredAllowlist, dataFile, err := Deserialize(data)
+		if err != nil {
+			t.Fatalf("Deserialize failed: %v", err)
+		}
+
+		for _, tc := range []struct {
+			name           string
+			want, restored *Trie
+		}{
+			{"blocklist", blocklist, restoredBlocklist},
+			{"allowlist", allowlist, restoredAllowlist},
+		} {
+			want, got := tc.want.GetAll(), tc.restored.GetAll()
+			sort.Strings(want)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Restored %s = %q, want %q", tc.name, got, want)
+			}
+		}
+
+		if dataFile.DomainCount != blocklist.Size() {
+			t.Errorf("DomainCount = %d, want %d", dataFile.DomainCount, blocklist.Size())
+		}
+	})
+}
+
+func FuzzDeserialize(f *testing.F) {
+	valid, err := Serialize(fuzzDomains("tempmail.com"), fuzzDomains("gmail.com"), DefaultCompressionLevel)
+	if err != nil {
+		f.Fatalf("Serialize failed: %v", err)
+	}
+	f.Add(valid)
+	f.Add([]byte{})
+	f.Add([]byte("this is not valid gzip data"))
+	f.Add(valid[:len(valid)/2])
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Must never panic; errors are expected for most inputs
+		blocklist, allowlist, dataFile, err := Deserialize(data)
+		if err != nil {
+			return
+		}
+		if blocklist == nil || allowlist == nil || dataFile == nil {
+			t.Fatal("Deserialize returned nil results without an error")
+		}
+	})
+}
+
 func BenchmarkSerialize(b *testing.B) {
 	blocklist := New()
 	for i := 0; i < 1000; i++ {
@@ -128,7 +557,7 @@ func BenchmarkSerialize(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = Serialize(blocklist, allowlist)
+		_, _ = Serialize(blocklist, allowlist, DefaultCompressionLevel)
 	}
 }
 
@@ -140,7 +569,7 @@ func BenchmarkDeserialize(b *testing.B) {
 
 	allowlist := New()
 
-	data, _ := Serialize(blocklist, allowlist)
+	data, _ := Serialize(blocklist, allowlist, DefaultCompressionLevel)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
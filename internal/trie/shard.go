@@ -0,0 +1,153 @@
+package trie
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// TLD returns the last label of a domain, e.g. "com" for "mail.example.com".
+// A domain without dots is its own TLD.
+func TLD(domain string) string {
+	return domain[strings.LastIndexByte(domain, '.')+1:]
+}
+
+// SplitByTLD splits a data file into one data file per top-level domain,
+// keyed by TLD. Every shard keeps the version and creation time of df, and
+// holds the blocklist and allowlist entries under its TLD, along with their
+// source counts if df has them. Since all parents
+// of a domain share its TLD, a shard is enough for hierarchical matching of
+// any domain under that TLD.
+func SplitByTLD(df *DataFile) map[string]*DataFile {
+	shards := make(map[string]*DataFile)
+	shard := func(domain string) *DataFile {
+		tld := TLD(domain)
+		s, ok := shards[tld]
+		if !ok {
+			s = &DataFile{Version: df.Version, CreatedAt: df.CreatedAt}
+			shards[tld] = s
+		}
+		return s
+	}
+
+	for _, domain := range df.Blocklist {
+		s := shard(domain)
+		s.Blocklist = append(s.Blocklist, domain)
+		s.DomainCount++
+		if n, ok := df.SourceCounts[domain]; ok {
+			if s.SourceCounts == nil {
+				s.SourceCounts = make(map[string]int)
+			}
+			s.SourceCounts[domain] = n
+		}
+	}
+	for _, domain := range df.Allowlist {
+		s := shard(domain)
+		s.Allowlist = append(s.Allowlist, domain)
+	}
+
+	return shards
+}
+
+// Shards is a Base holding one DAFSA per TLD, for data loaded a TLD at a
+// time. Since all parents of a domain share its TLD, a lookup only consults
+// the DAFSA of the domain's TLD. Add may run alongside lookups: shards are
+// published as a new immutable set, so readers never see one half-added.
+type Shards struct {
+	set atomic.Pointer[shardSet]
+}
+
+// shardSet is the set of shards published by Shards.
+type shardSet struct {
+	tlds map[string]*DAFSA
+	n    int // total number of domains
+}
+
+// NewShards returns an empty set of shards.
+func NewShards() *Shards {
+	s := &Shards{}
+	s.set.Store(&shardSet{tlds: map[string]*DAFSA{}})
+	return s
+}
+
+// Add sets the domains under tld to those of d, replacing any shard added
+// for tld before. All domains of d must have tld as their TLD.
+func (s *Shards) Add(tld string, d *DAFSA) {
+	old := s.set.Load()
+	set := &shardSet{tlds: make(map[string]*DAFSA, len(old.tlds)+1), n: old.n + d.Len()}
+	for t, shard := range old.tlds {
+		set.tlds[t] = shard
+	}
+	if prev, ok := set.tlds[tld]; ok {
+		set.n -= prev.Len()
+	}
+	set.tlds[tld] = d
+	s.set.Store(set)
+}
+
+// shard returns the DAFSA for the TLD of domain, or nil if none was added.
+func (s *Shards) shard(domain string) *DAFSA {
+	return s.set.Load().tlds[TLD(domain)]
+}
+
+// Len returns the number of domains in all shards.
+func (s *Shards) Len() int {
+	return s.set.Load().n
+}
+
+func (s *Shards) contains(domain string) bool {
+	d := s.shard(domain)
+	return d != nil && d.contains(domain)
+}
+
+func (s *Shards) walk(domain string, fn func(n int) bool) {
+	if d := s.shard(domain); d != nil {
+		d.walk(domain, fn)
+	}
+}
+
+func (s *Shards) match(domain string, minLen int) int {
+	if d := s.shard(domain); d != nil {
+		return d.match(domain, minLen)
+	}
+	return 0
+}
+
+func (s *Shards) longest(domain string, proper bool) int {
+	if d := s.shard(domain); d != nil {
+		return d.longest(domain, proper)
+	}
+	return 0
+}
+
+func (s *Shards) all() []string {
+	set := s.set.Load()
+	domains := make([]string, 0, set.n)
+	for _, d := range set.tlds {
+		domains = append(domains, d.all()...)
+	}
+	return domains
+}
+
+func (s *Shards) under(suffix string) []string {
+	if d := s.shard(suffix); d != nil {
+		return d.under(suffix)
+	}
+	return nil
+}
+
+// validate checks every shard, and that each only holds domains under its
+// TLD.
+func (s *Shards) validate() error {
+	for tld, d := range s.set.Load().tlds {
+		if err := d.validate(); err != nil {
+			return fmt.Errorf("shard %q: %w", tld, err)
+		}
+		for _, domain := range d.all() {
+			if TLD(domain) != tld {
+				return fmt.Errorf("shard %q holds %q", tld, domain)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package trie
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTLD(t *testing.T) {
+	tests := []struct {
+		domain   string
+		expected string
+	}{
+		{"mail.example.com", "com"},
+		{"example.co.uk", "uk"},
+		{"localhost", "localhost"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if result := TLD(tt.domain); result != tt.expected {
+			t.Errorf("TLD(%q) = %q, want %q", tt.domain, result, tt.expected)
+		}
+	}
+}
+
+func TestSplitByTLD(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	df := &DataFile{
+		Version:      "1.5",
+		CreatedAt:    created,
+		DomainCount:  3,
+		Blocklist:    []string{"tempmail.com", "mail.tempmail.com", "yopmail.fr"},
+		Allowlist:    []string{"gmail.com", "free.de"},
+		SourceCounts: map[string]int{"tempmail.com": 2, "mail.tempmail.com": 1},
+	}
+
+	shards := SplitByTLD(df)
+
+	expected := map[string]*DataFile{
+		"com": {Version: "1.5", CreatedAt: created, DomainCount: 2, Blocklist: []string{"tempmail.com", "mail.tempmail.com"}, Allowlist: []string{"gmail.com"},
+			SourceCounts: map[string]int{"tempmail.com": 2, "mail.tempmail.com": 1}},
+		"fr": {Version: "1.5", CreatedAt: created, DomainCount: 1, Blocklist: []string{"yopmail.fr"}},
+		"de": {Version: "1.5", CreatedAt: created, Allowlist: []string{"free.de"}},
+	}
+	if !reflect.DeepEqual(shards, expected) {
+		t.Errorf("SplitByTLD() = %+v, want %+v", shards, expected)
+	}
+
+	// Shards round-trip through the regular format, which records the
+	// version it is written in
+	data, err := SerializeDataFile(shards["com"], DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDataFile() error = %v", err)
+	}
+	blocklist, allowlist, restored, err := Deserialize(data)
+	if err != nil {
+		t.Fatalf("Deserialize() error = %v", err)
+	}
+	if !blocklist.ContainsHierarchical("x.tempmail.com") || !allowlist.Contains("gmail.com") {
+		t.Error("Expected restored shard to contain its domains")
+	}
+	if restored.Version != FormatVersion || !restored.CreatedAt.Equal(created) {
+		t.Errorf("Restored metadata = (%q, %v), want (%q, %v)", restored.Version, restored.CreatedAt, FormatVersion, created)
+	}
+}
+
+func TestShards(t *testing.T) {
+	domains := []string{"tempmail.com", "mail.tempmail.com", "yopmail.com", "yopmail.fr", "wegwerfmail.de", "co.uk", "tempmail.co.uk"}
+
+	// The same domains split into one shard per TLD, and as a single DAFSA
+	shards := NewShards()
+	for tld, shard := range SplitByTLD(&DataFile{Blocklist: domains}) {
+		shards.Add(tld, NewDAFSA(shard.Blocklist))
+	}
+	sharded := NewOverlay(shards)
+	whole := NewOverlay(NewDAFSA(domains))
+
+	if err := sharded.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if sharded.Size() != whole.Size() {
+		t.Errorf("Size() = %d, want %d", sharded.Size(), whole.Size())
+	}
+
+	lookups := []string{"tempmail.com", "x.mail.tempmail.com", "mytempmail.com", "yopmail.fr", "yopmail.de",
+		"a.wegwerfmail.de", "tempmail.co.uk", "x.co.uk", "gmail.com", "com", "example.org"}
+	for _, domain := range lookups {
+		if got, want := sharded.Contains(domain), whole.Contains(domain); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", domain, got, want)
+		}
+		got, gotOK := sharded.HierarchicalMatch(domain)
+		want, wantOK := whole.HierarchicalMatch(domain)
+		if got != want || gotOK != wantOK {
+			t.Errorf("HierarchicalMatch(%q) = %q, %v, want %q, %v", domain, got, gotOK, want, wantOK)
+		}
+		got, gotOK = sharded.LongestHierarchicalMatch(domain)
+		want, wantOK = whole.LongestHierarchicalMatch(domain)
+		if got != want || gotOK != wantOK {
+			t.Errorf("LongestHierarchicalMatch(%q) = %q, %v, want %q, %v", domain, got, gotOK, want, wantOK)
+		}
+	}
+	for _, suffix := range []string{"com", "tempmail.com", "uk", "org"} {
+		got, want := sharded.GetUnder(suffix), whole.GetUnder(suffix)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetUnder(%q) = %v, want %v", suffix, got, want)
+		}
+	}
+	if got := sharded.Minimal(); !reflect.DeepEqual(got, whole.Minimal()) {
+		t.Errorf("Minimal() = %v, want %v", got, whole.Minimal())
+	}
+
+	// Replacing a shard replaces its domains and count
+	shards.Add("fr", NewDAFSA([]string{"a.fr", "b.fr"}))
+	if sharded.Size() != whole.Size()+1 || sharded.Contains("yopmail.fr") || !sharded.Contains("b.fr") {
+		t.Errorf("after replacing shard \"fr\": Size() = %d, Contains(yopmail.fr) = %v, Contains(b.fr) = %v",
+			sharded.Size(), sharded.Contains("yopmail.fr"), sharded.Contains("b.fr"))
+	}
+
+	// A shard holding a domain of another TLD is caught
+	shards.Add("de", NewDAFSA([]string{"tempmail.com"}))
+	if err := sharded.Validate(); err == nil {
+		t.Error("Validate() = nil, want an error for a domain in the wrong shard")
+	}
+}
+
+func TestShardsDoesNotAllocate(t *testing.T) {
+	shards := NewShards()
+	shards.Add("com", NewDAFSA([]string{"tempmail.com", "mailinator.com"}))
+	sharded := NewOverlay(shards)
+
+	for _, domain := range []string{"mail.tempmail.com", "gmail.com", "gmail.de"} {
+		if n := testing.AllocsPerRun(100, func() { sharded.HierarchicalMatch(domain) }); n != 0 {
+			t.Errorf("HierarchicalMatch(%q) made %v allocations, want 0", domain, n)
+		}
+	}
+}
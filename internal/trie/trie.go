@@ -3,9 +3,24 @@
 package trie
 
 import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"unicode/utf8"
 )
 
+// MaxLabels is the maximum number of trailing labels lookups such as
+// ContainsHierarchical inspect. Labels beyond this depth are ignored, which
+// bounds the work done for pathological inputs with hundreds of labels. A
+// stored domain with more labels could never match, so Insert rejects those.
+const MaxLabels = 10
+
+// DefaultMaxDomainLength is the maximum domain length applied by Insert, the
+// DNS limit on the length of a domain name.
+const DefaultMaxDomainLength = 253
+
 // Node represents a node in the trie.
 type Node struct {
 	Children map[rune]*Node
@@ -25,26 +40,89 @@ type Trie struct {
 	mu   sync.RWMutex
 	root *Node
 	size int
+
+	maxLength int // maximum domain length accepted by Insert, 0 for no limit
+	maxLabels int // maximum label count accepted by Insert, at most MaxLabels
+
+	base Base // read-only domains beneath the nodes, nil if none
+}
+
+// Base is a read-only set of domains under the nodes of a trie: a Flat or a
+// DAFSA.
+type Base interface {
+	// Len returns the number of domains.
+	Len() int
+
+	contains(domain string) bool
+	// walk calls fn with the length of each suffix of domain at a label
+	// boundary that is in the set, shortest first, until fn returns false.
+	walk(domain string, fn func(n int) bool)
+	// match returns the length of the shortest suffix walk reports that is
+	// domain itself or longer than minLen, and longest the length of the
+	// longest one, excluding domain itself if proper is set; 0 if none.
+	// Closures passed to walk through the interface would allocate on every
+	// lookup.
+	match(domain string, minLen int) int
+	longest(domain string, proper bool) int
+	all() []string
+	under(suffix string) []string
+	validate() error
 }
 
 // New creates a new empty trie.
 func New() *Trie {
 	return &Trie{
-		root: NewNode(),
-		size: 0,
+		root:      NewNode(),
+		size:      0,
+		maxLength: DefaultMaxDomainLength,
+		maxLabels: MaxLabels,
 	}
 }
 
+// NewOverlay creates an empty trie on top of base. Lookups and listings see
+// the domains of base as well as those inserted, which are kept apart in
+// nodes, so that base can stay memory-mapped or compact instead of being
+// copied into the trie. Domains of base can't be removed; Trace, GetRoot and
+// SetRoot only see the nodes.
+func NewOverlay(base Base) *Trie {
+	t := New()
+	t.base = base
+	return t
+}
+
+// SetLimits sets the maximum domain length and label count accepted by Insert.
+// A maxLength of 0 disables the length limit. The label count can only be
+// lowered: a maxLabels of 0 or above MaxLabels means MaxLabels, since lookups
+// never see deeper labels.
+func (t *Trie) SetLimits(maxLength, maxLabels int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.maxLength = maxLength
+	if maxLabels <= 0 || maxLabels > MaxLabels {
+		maxLabels = MaxLabels
+	}
+	t.maxLabels = maxLabels
+}
+
 // Insert adds a domain to the trie.
 // The domain is stored in reverse order for efficient suffix matching.
-func (t *Trie) Insert(domain string) {
+// Empty domains and domains exceeding the trie's limits are ignored,
+// in which case Insert returns false.
+func (t *Trie) Insert(domain string) bool {
 	if domain == "" {
-		return
+		return false
 	}
 
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	if !t.withinLimits(domain) {
+		return false
+	}
+	if t.base != nil && t.base.contains(domain) {
+		return true
+	}
+
 	// Reverse the domain for efficient suffix matching
 	reversed := reverseString(domain)
 
@@ -60,6 +138,77 @@ func (t *Trie) Insert(domain string) {
 		node.IsEnd = true
 		t.size++
 	}
+	return true
+}
+
+// withinLimits reports whether domain satisfies the trie's length and label
+// limits.
+func (t *Trie) withinLimits(domain string) bool {
+	return withinLimits(domain, t.maxLength, t.maxLabels)
+}
+
+// withinLimits reports whether domain has at most maxLength bytes, where 0
+// means no limit, and maxLabels labels.
+func withinLimits(domain string, maxLength, maxLabels int) bool {
+	if maxLength > 0 && len(domain) > maxLength {
+		return false
+	}
+	return strings.Count(domain, ".")+1 <= maxLabels
+}
+
+// sortReversed sorts domains by their reversed form, the order NewDAFSA adds
+// them in, so files and deltas list them in a stable order that NewDAFSA
+// doesn't need to rearrange.
+func sortReversed(domains []string) {
+	for i, domain := range domains {
+		domains[i] = reverseString(domain)
+	}
+	sort.Strings(domains)
+	for i, domain := range domains {
+		domains[i] = reverseString(domain)
+	}
+}
+
+// Remove deletes a domain from the trie. It returns false if the domain
+// was not stored, or is in the base of an overlay. Nodes left without
+// children are pruned.
+func (t *Trie) Remove(domain string) bool {
+	if domain == "" {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reversed := []rune(reverseString(domain))
+	path := make([]*Node, 0, len(reversed)+1)
+
+	node := t.root
+	path = append(path, node)
+	for _, char := range reversed {
+		node = node.Children[char]
+		if node == nil {
+			return false
+		}
+		path = append(path, node)
+	}
+
+	if !node.IsEnd {
+		return false
+	}
+	node.IsEnd = false
+	t.size--
+
+	// Prune nodes that no longer lead to any domain
+	for i := len(reversed) - 1; i >= 0; i-- {
+		child := path[i+1]
+		if child.IsEnd || len(child.Children) > 0 {
+			break
+		}
+		delete(path[i].Children, reversed[i])
+	}
+
+	return true
 }
 
 // Contains checks if the exact domain exists in the trie.
@@ -76,49 +225,270 @@ func (t *Trie) Contains(domain string) bool {
 
 	for _, char := range reversed {
 		if node.Children[char] == nil {
-			return false
+			return t.base != nil && t.base.contains(domain)
 		}
 		node = node.Children[char]
 	}
 
-	return node.IsEnd
+	return node.IsEnd || t.base != nil && t.base.contains(domain)
 }
 
 // ContainsHierarchical checks if the domain or any of its parent domains
 // exist in the trie. For example, if "tempmail.com" is in the trie,
-// this returns true for "mail.tempmail.com".
+// this returns true for "mail.tempmail.com". Matches are label-aligned:
+// "tempmail.com" does not match "xtempmail.com". The walk stops at the
+// shortest stored suffix, the one HierarchicalMatch reports.
 func (t *Trie) ContainsHierarchical(domain string) bool {
+	_, ok := t.HierarchicalMatch(domain)
+	return ok
+}
+
+// HierarchicalMatch returns the stored domain that makes ContainsHierarchical
+// true for domain: the domain itself or its nearest-to-root stored parent.
+// For example, with "tempmail.com" stored, it returns "tempmail.com" for
+// "mail.tempmail.com". When several stored domains match, the shortest one
+// wins; use LongestHierarchicalMatch for the most specific one.
+func (t *Trie) HierarchicalMatch(domain string) (string, bool) {
+	return t.hierarchicalMatch(domain, 0)
+}
+
+// HierarchicalMatchBelow is HierarchicalMatch, but parents of domain that
+// are no longer than suffix, normally the public suffix of domain, are
+// skipped. A stored "co.uk" thus matches "co.uk" itself but not
+// "example.co.uk", while a stored "example.co.uk" still matches
+// "mail.example.co.uk".
+func (t *Trie) HierarchicalMatchBelow(domain, suffix string) (string, bool) {
+	return t.hierarchicalMatch(domain, len(suffix))
+}
+
+// hierarchicalMatch implements HierarchicalMatch, skipping parents of domain
+// of at most minLen bytes.
+func (t *Trie) hierarchicalMatch(domain string, minLen int) (string, bool) {
 	if domain == "" {
-		return false
+		return "", false
 	}
 
+	// Only the deepest MaxLabels labels can match; a stored suffix of those
+	// still means the full domain is a subdomain of it.
+	domain = lastLabels(domain, MaxLabels)
+
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	// Reverse the domain
-	reversed := reverseString(domain)
+	// The shortest match wins, so only base matches shorter than the one
+	// in the nodes matter
+	match := t.nodeMatch(domain, minLen)
+	if t.base != nil {
+		if n := t.base.match(domain, minLen); n > 0 && (match == "" || n < len(match)) {
+			match = domain[len(domain)-n:]
+		}
+	}
+	return match, match != ""
+}
+
+// nodeMatch is hierarchicalMatch for the domains in the nodes. The caller
+// must hold t.mu.
+func (t *Trie) nodeMatch(domain string, minLen int) string {
+	// Walk the domain from its end instead of reversing it, so lookups
+	// don't allocate and misses in the TLD bail out immediately. This is
+	// the TLD-miss fast path: a separate pre-check of the last label, as
+	// done before the walk stopped reversing, would only walk it twice.
 	node := t.root
+	for i := len(domain); i > 0; {
+		char, size := utf8.DecodeLastRuneInString(domain[:i])
+		i -= size
 
-	for _, char := range reversed {
-		if node.Children[char] == nil {
-			return false
+		node = node.Children[char]
+		if node == nil {
+			return ""
+		}
+
+		// domain[i:] is stored and starts at a label boundary
+		if node.IsEnd && (i == 0 || domain[i-1] == '.' && len(domain)-i > minLen) {
+			return domain[i:]
+		}
+	}
+
+	return ""
+}
+
+// LongestHierarchicalMatch is HierarchicalMatch, but when several stored
+// domains match, it returns the longest, most specific one. For example, with
+// "example.com" and "sub.example.com" stored, it returns "sub.example.com"
+// for "x.sub.example.com", where HierarchicalMatch returns "example.com".
+func (t *Trie) LongestHierarchicalMatch(domain string) (string, bool) {
+	if domain == "" {
+		return "", false
+	}
+
+	domain = lastLabels(domain, MaxLabels)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	best := -1
+	node := t.root
+	for i := len(domain); i > 0; {
+		char, size := utf8.DecodeLastRuneInString(domain[:i])
+		i -= size
+
+		node = node.Children[char]
+		if node == nil {
+			break
+		}
+
+		// Keep walking past matches to find the deepest one
+		if node.IsEnd && (i == 0 || domain[i-1] == '.') {
+			best = i
+		}
+	}
+
+	if t.base != nil {
+		if n := t.base.longest(domain, false); n > 0 && (best == -1 || len(domain)-n < best) {
+			best = len(domain) - n
 		}
+	}
+
+	if best == -1 {
+		return "", false
+	}
+	return domain[best:], true
+}
+
+// AllSuffixMatches returns every stored domain that matches domain
+// hierarchically, i.e. the domain itself and all of its stored parents,
+// ordered from the root. For example, with "example.com" and
+// "mail.example.com" stored, it returns both for "a.mail.example.com".
+// HierarchicalMatch reports only the first of them.
+func (t *Trie) AllSuffixMatches(domain string) []string {
+	domain = lastLabels(domain, MaxLabels)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var matches []string
+	node := t.root
+	for i := len(domain); i > 0; {
+		char, size := utf8.DecodeLastRuneInString(domain[:i])
+		i -= size
+
 		node = node.Children[char]
+		if node == nil {
+			break
+		}
+
+		if node.IsEnd && (i == 0 || domain[i-1] == '.') {
+			matches = append(matches, domain[i:])
+		}
+	}
 
-		// Check if this is the end of a stored domain
-		// This means the current suffix matches a domain in the trie
-		if node.IsEnd {
+	if t.base != nil {
+		t.base.walk(domain, func(n int) bool {
+			matches = append(matches, domain[len(domain)-n:])
 			return true
+		})
+		slices.SortStableFunc(matches, func(a, b string) int { return len(a) - len(b) })
+	}
+
+	return matches
+}
+
+// TraceStep describes one node visited by Trace.
+type TraceStep struct {
+	Char   rune   // Character consumed to reach this node
+	Suffix string // Part of the domain matched so far, e.g. "com", ".com", "e.com"
+	IsEnd  bool   // Whether the node marks the end of a stored domain
+	Match  bool   // Whether IsEnd falls on a label boundary, i.e. Suffix is a hierarchical match
+}
+
+// Trace returns the nodes visited when looking up domain, from the root
+// towards the first character of the domain. The walk stops early when the
+// trie has no matching child, and continues past matches so every stored
+// parent shows up. It is intended for debugging and visualization tools and
+// is not optimized for lookups; use HierarchicalMatch for those.
+func (t *Trie) Trace(domain string) []TraceStep {
+	domain = lastLabels(domain, MaxLabels)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var steps []TraceStep
+	node := t.root
+	for i := len(domain); i > 0; {
+		char, size := utf8.DecodeLastRuneInString(domain[:i])
+		i -= size
+
+		node = node.Children[char]
+		if node == nil {
+			break
 		}
+
+		steps = append(steps, TraceStep{
+			Char:   char,
+			Suffix: domain[i:],
+			IsEnd:  node.IsEnd,
+			Match:  node.IsEnd && (i == 0 || domain[i-1] == '.'),
+		})
 	}
 
-	return node.IsEnd
+	return steps
+}
+
+// NearestBlockedAncestor returns the longest stored domain that is a proper
+// parent of domain, e.g. "tempmail.com" for "mail.tempmail.com". Unlike
+// ContainsHierarchical, the domain itself is never reported, so this can be
+// used to hint that a domain belongs to a known entry even when it doesn't
+// match on its own.
+func (t *Trie) NearestBlockedAncestor(domain string) (string, bool) {
+	if domain == "" {
+		return "", false
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.nearestAncestor(domain)
+}
+
+// nearestAncestor implements NearestBlockedAncestor. The caller must hold
+// t.mu.
+func (t *Trie) nearestAncestor(domain string) (string, bool) {
+	best := -1
+	node := t.root
+	for i := len(domain); i > 0; {
+		char, size := utf8.DecodeLastRuneInString(domain[:i])
+
+		// domain[i:] is a whole parent domain when preceded by a dot
+		if char == '.' && node.IsEnd {
+			best = i
+		}
+
+		i -= size
+		node = node.Children[char]
+		if node == nil {
+			break
+		}
+	}
+
+	if t.base != nil {
+		if n := t.base.longest(domain, true); n > 0 && (best == -1 || len(domain)-n < best) {
+			best = len(domain) - n
+		}
+	}
+
+	if best == -1 {
+		return "", false
+	}
+	return domain[best:], true
 }
 
 // Size returns the number of domains in the trie.
 func (t *Trie) Size() int {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
+	if t.base != nil {
+		return t.size + t.base.Len()
+	}
 	return t.size
 }
 
@@ -127,8 +497,50 @@ func (t *Trie) GetAll() []string {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
+	return t.getAll()
+}
+
+// getAll implements GetAll. The caller must hold t.mu.
+func (t *Trie) getAll() []string {
 	var domains []string
 	t.collectDomains(t.root, "", &domains)
+	if t.base != nil {
+		domains = append(domains, t.base.all()...)
+	}
+	return domains
+}
+
+// GetUnder returns the stored domains that equal suffix or are subdomains of
+// it, at label boundaries: with suffix "zip", it returns "a.zip" and
+// "b.a.zip" but not "xzip". It walks only the part of the trie below suffix.
+func (t *Trie) GetUnder(suffix string) []string {
+	if suffix == "" {
+		return nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var domains []string
+	if t.base != nil {
+		domains = t.base.under(suffix)
+	}
+
+	reversed := reverseString(suffix)
+	node := t.root
+	for _, char := range reversed {
+		node = node.Children[char]
+		if node == nil {
+			return domains
+		}
+	}
+
+	if node.IsEnd {
+		domains = append(domains, suffix)
+	}
+	if child := node.Children['.']; child != nil {
+		t.collectDomains(child, reversed+".", &domains)
+	}
 	return domains
 }
 
@@ -144,13 +556,138 @@ func (t *Trie) collectDomains(node *Node, prefix string, domains *[]string) {
 	}
 }
 
-// Clear removes all domains from the trie.
+// Minimal returns the smallest set of stored domains that covers every stored
+// domain hierarchically. Domains that are subdomains of another stored domain
+// are dropped, e.g. {"tempmail.com", "mail.tempmail.com"} yields
+// {"tempmail.com"}. The result is sorted.
+func (t *Trie) Minimal() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var domains []string
+	if t.base != nil {
+		// Parents may be in the nodes or in the base
+		for _, domain := range t.getAll() {
+			if _, ok := t.nearestAncestor(domain); !ok {
+				domains = append(domains, domain)
+			}
+		}
+	} else {
+		collectMinimal(t.root, "", &domains)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// collectMinimal collects stored domains, skipping the subdomains of any
+// domain already collected.
+func collectMinimal(node *Node, prefix string, domains *[]string) {
+	if node.IsEnd {
+		*domains = append(*domains, reverseString(prefix))
+	}
+
+	for char, child := range node.Children {
+		// Everything below "." of a stored domain is one of its subdomains
+		if node.IsEnd && char == '.' {
+			continue
+		}
+		collectMinimal(child, prefix+string(char), domains)
+	}
+}
+
+// MinimalSize returns the number of domains Minimal would return, without
+// collecting them.
+func (t *Trie) MinimalSize() int {
+	if t.base != nil {
+		return len(t.Minimal())
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return countMinimal(t.root)
+}
+
+// countMinimal counts stored domains, skipping the subdomains of any domain
+// already counted.
+func countMinimal(node *Node) int {
+	if node.IsEnd {
+		// Only the '.' child leads to subdomains; other children are
+		// distinct domains sharing a suffix, such as "xtempmail.com"
+		count := 1
+		for char, child := range node.Children {
+			if char != '.' {
+				count += countMinimal(child)
+			}
+		}
+		return count
+	}
+
+	count := 0
+	for _, child := range node.Children {
+		count += countMinimal(child)
+	}
+	return count
+}
+
+// Validate checks the internal consistency of the trie: the recorded size
+// must match the number of stored domains, and every stored domain must be
+// returned by GetAll exactly once. For an overlay, the base must also be
+// sorted. It is meant to catch serialization and data-structure bugs after
+// loading.
+func (t *Trie) Validate() error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.base != nil {
+		if err := t.base.validate(); err != nil {
+			return err
+		}
+	}
+
+	ends := countEnds(t.root)
+	if ends != t.size {
+		return fmt.Errorf("trie size is %d but %d domains are stored", t.size, ends)
+	}
+
+	var domains []string
+	t.collectDomains(t.root, "", &domains)
+	if len(domains) != t.size {
+		return fmt.Errorf("trie size is %d but %d domains were collected", t.size, len(domains))
+	}
+
+	seen := make(map[string]struct{}, len(domains))
+	for _, domain := range domains {
+		if _, ok := seen[domain]; ok || t.base != nil && t.base.contains(domain) {
+			return fmt.Errorf("domain %q is stored more than once", domain)
+		}
+		seen[domain] = struct{}{}
+	}
+
+	return nil
+}
+
+// countEnds counts the nodes marking the end of a domain.
+func countEnds(node *Node) int {
+	count := 0
+	if node.IsEnd {
+		count++
+	}
+	for _, child := range node.Children {
+		count += countEnds(child)
+	}
+	return count
+}
+
+// Clear removes all domains from the trie, including the base of an
+// overlay.
 func (t *Trie) Clear() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	t.root = NewNode()
 	t.size = 0
+	t.base = nil
 }
 
 // GetRoot returns the root node (used for serialization).
@@ -168,6 +705,19 @@ func (t *Trie) SetRoot(root *Node, size int) {
 	t.size = size
 }
 
+// lastLabels returns the last n dot-separated labels of domain.
+func lastLabels(domain string, n int) string {
+	for i := len(domain) - 1; i >= 0; i-- {
+		if domain[i] == '.' {
+			n--
+			if n == 0 {
+				return domain[i+1:]
+			}
+		}
+	}
+	return domain
+}
+
 // reverseString reverses a string.
 func reverseString(s string) string {
 	runes := []rune(s)
@@ -1,7 +1,10 @@
 package trie
 
 import (
+	"fmt"
+	"reflect"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -106,6 +109,95 @@ func TestTrieGetAll(t *testing.T) {
 	}
 }
 
+func TestTrieGetUnder(t *testing.T) {
+	tr := New()
+	for _, d := range []string{"a.zip", "b.a.zip", "xzip", "zip.com", "co.uk", "mail.co.uk"} {
+		tr.Insert(d)
+	}
+
+	tests := []struct {
+		suffix   string
+		expected []string
+	}{
+		{"zip", []string{"a.zip", "b.a.zip"}},
+		{"a.zip", []string{"a.zip", "b.a.zip"}},
+		{"co.uk", []string{"co.uk", "mail.co.uk"}},
+		{"uk", []string{"co.uk", "mail.co.uk"}},
+		{"com", []string{"zip.com"}},
+		{"org", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.suffix, func(t *testing.T) {
+			got := tr.GetUnder(tt.suffix)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("GetUnder(%q) = %v, want %v", tt.suffix, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTrieMinimal(t *testing.T) {
+	tr := New()
+	for _, d := range []string{
+		"tempmail.com",
+		"mail.tempmail.com",
+		"deep.mail.tempmail.com",
+		"xtempmail.com", // shares a suffix but is not a subdomain
+		"sub.other.org",
+		"yopmail.fr",
+	} {
+		tr.Insert(d)
+	}
+
+	expected := []string{"sub.other.org", "tempmail.com", "xtempmail.com", "yopmail.fr"}
+	result := tr.Minimal()
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Minimal() = %v, want %v", result, expected)
+	}
+	if size := tr.MinimalSize(); size != len(expected) {
+		t.Errorf("MinimalSize() = %d, want %d", size, len(expected))
+	}
+
+	// The minimal set must cover every original domain
+	minimal := New()
+	for _, d := range result {
+		minimal.Insert(d)
+	}
+	for _, d := range tr.GetAll() {
+		if !minimal.ContainsHierarchical(d) {
+			t.Errorf("Minimal set does not cover %q", d)
+		}
+	}
+}
+
+func TestTrieValidate(t *testing.T) {
+	tr := New()
+	tr.Insert("tempmail.com")
+	tr.Insert("mail.tempmail.com")
+	tr.Remove("tempmail.com")
+
+	if err := tr.Validate(); err != nil {
+		t.Fatalf("Validate() on consistent trie error = %v", err)
+	}
+
+	// Corrupt the recorded size
+	tr.size++
+	if err := tr.Validate(); err == nil {
+		t.Error("Expected Validate() to catch a size mismatch")
+	}
+
+	// Corrupt the nodes behind the trie's back
+	tr = New()
+	tr.Insert("tempmail.com")
+	tr.GetRoot().Children['x'] = &Node{Children: map[rune]*Node{}, IsEnd: true}
+	if err := tr.Validate(); err == nil {
+		t.Error("Expected Validate() to catch an unaccounted domain")
+	}
+}
+
 func TestTrieClear(t *testing.T) {
 	tr := New()
 
@@ -127,6 +219,118 @@ func TestTrieClear(t *testing.T) {
 	}
 }
 
+func TestTrieNearestBlockedAncestor(t *testing.T) {
+	tr := New()
+	tr.Insert("tempmail.com")
+	tr.Insert("mail.tempmail.com")
+
+	tests := []struct {
+		domain   string
+		expected string
+		found    bool
+	}{
+		{"a.mail.tempmail.com", "mail.tempmail.com", true},
+		{"other.tempmail.com", "tempmail.com", true},
+		{"mail.tempmail.com", "tempmail.com", true}, // itself is stored, parent reported
+		{"tempmail.com", "", false},                 // no proper parent stored
+		{"xtempmail.com", "", false},                // not label-aligned
+		{"example.com", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, found := tr.NearestBlockedAncestor(tt.domain)
+		if got != tt.expected || found != tt.found {
+			t.Errorf("NearestBlockedAncestor(%q) = (%q, %v), want (%q, %v)",
+				tt.domain, got, found, tt.expected, tt.found)
+		}
+	}
+}
+
+func TestTrieRemove(t *testing.T) {
+	tr := New()
+	tr.Insert("tempmail.com")
+	tr.Insert("mail.tempmail.com")
+
+	if tr.Remove("other.com") {
+		t.Error("Remove should return false for a missing domain")
+	}
+	if tr.Remove("mail.com") {
+		t.Error("Remove should return false for a prefix path that isn't stored")
+	}
+
+	if !tr.Remove("tempmail.com") {
+		t.Fatal("Remove should return true for a stored domain")
+	}
+	if tr.Contains("tempmail.com") {
+		t.Error("Expected tempmail.com to be removed")
+	}
+	if !tr.Contains("mail.tempmail.com") {
+		t.Error("Expected mail.tempmail.com to remain after removing its parent")
+	}
+	if tr.Size() != 1 {
+		t.Errorf("Expected size 1, got %d", tr.Size())
+	}
+
+	if !tr.Remove("mail.tempmail.com") {
+		t.Fatal("Remove should return true for a stored domain")
+	}
+	if len(tr.GetRoot().Children) != 0 {
+		t.Error("Expected empty nodes to be pruned")
+	}
+}
+
+func TestTrieInsertLimits(t *testing.T) {
+	tr := New()
+
+	long := strings.Repeat("a", DefaultMaxDomainLength) + ".com"
+	if tr.Insert(long) {
+		t.Error("Expected overlong domain to be rejected")
+	}
+
+	// Lookups never see labels past MaxLabels, so such entries are dead
+	manyLabels := strings.Repeat("a.", MaxLabels) + "com"
+	if tr.Insert(manyLabels) {
+		t.Error("Expected domain with too many labels to be rejected")
+	}
+	deepest := strings.Repeat("a.", MaxLabels-1) + "com"
+	if !tr.Insert(deepest) || !tr.ContainsHierarchical("x."+deepest) {
+		t.Errorf("Expected domain with %d labels to be stored and matched", MaxLabels)
+	}
+	tr.Remove(deepest)
+
+	if tr.Size() != 0 {
+		t.Errorf("Expected rejected domains to not be stored, size = %d", tr.Size())
+	}
+
+	if !tr.Insert("tempmail.com") {
+		t.Error("Expected normal domain to be accepted")
+	}
+
+	// Limits are configurable and can be disabled
+	tr.SetLimits(0, 3)
+	if !tr.Insert(long) {
+		t.Error("Expected overlong domain to be accepted with length limit disabled")
+	}
+	if tr.Insert("a.b.c.com") {
+		t.Error("Expected 4-label domain to be rejected with a 3-label limit")
+	}
+	tr.SetLimits(0, 0)
+	if tr.Insert(manyLabels) {
+		t.Error("Expected the label limit not to be raised past MaxLabels")
+	}
+}
+
+func TestSortReversed(t *testing.T) {
+	domains := []string{"yopmail.fr", "tempmail.com", "b.com", "mail.tempmail.com", "a.com"}
+	sortReversed(domains)
+
+	want := []string{"a.com", "b.com", "tempmail.com", "mail.tempmail.com", "yopmail.fr"}
+	if !reflect.DeepEqual(domains, want) {
+		t.Errorf("sortReversed() = %v, want %v", domains, want)
+	}
+}
+
 func TestTrieEmptyDomain(t *testing.T) {
 	tr := New()
 
@@ -144,6 +348,26 @@ func TestTrieEmptyDomain(t *testing.T) {
 	}
 }
 
+func TestTrieContainsHierarchicalMaxLabels(t *testing.T) {
+	tr := New()
+	tr.Insert("tempmail.com")
+
+	deep := strings.Repeat("a.", 200)
+	if !tr.ContainsHierarchical(deep + "tempmail.com") {
+		t.Error("Expected 200-label subdomain of tempmail.com to match")
+	}
+	if tr.ContainsHierarchical(deep + "example.com") {
+		t.Error("Expected 200-label subdomain of example.com to not match")
+	}
+
+	if got := lastLabels(deep+"tempmail.com", MaxLabels); strings.Count(got, ".")+1 != MaxLabels {
+		t.Errorf("lastLabels returned %d labels, want %d", strings.Count(got, ".")+1, MaxLabels)
+	}
+	if got := lastLabels("tempmail.com", MaxLabels); got != "tempmail.com" {
+		t.Errorf("lastLabels(%q) = %q, want unchanged", "tempmail.com", got)
+	}
+}
+
 func BenchmarkTrieInsert(b *testing.B) {
 	domains := []string{
 		"tempmail.com",
@@ -162,6 +386,31 @@ func BenchmarkTrieInsert(b *testing.B) {
 	}
 }
 
+// benchmarkDomains returns n distinct domains sharing common suffixes, sorted
+// by reversed form.
+func benchmarkDomains(n int) []string {
+	tlds := []string{"com", "net", "org", "io", "de"}
+	domains := make([]string, n)
+	for i := range domains {
+		domains[i] = fmt.Sprintf("mail%d.temp%d.%s", i, i%100, tlds[i%len(tlds)])
+	}
+	sortReversed(domains)
+	return domains
+}
+
+func BenchmarkTrieBuildInsert(b *testing.B) {
+	domains := benchmarkDomains(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr := New()
+		for _, d := range domains {
+			tr.Insert(d)
+		}
+	}
+}
+
 func BenchmarkTrieContains(b *testing.B) {
 	tr := New()
 	domains := []string{
@@ -213,3 +462,240 @@ func BenchmarkTrieContainsHierarchical(b *testing.B) {
 		tr.ContainsHierarchical(testDomains[i%len(testDomains)])
 	}
 }
+
+func TestTrieContainsHierarchicalFastPath(t *testing.T) {
+	tr := New()
+	tr.Insert("tempmail.com")
+
+	tests := []struct {
+		domain   string
+		expected bool
+	}{
+		{"user.de", false},       // TLD not in trie
+		{"tempmail.co", false},   // TLD diverges mid-label
+		{"example.com", false},   // TLD matches, rest doesn't
+		{"tempmail.com", true},   // exact match
+		{"a.tempmail.com", true}, // subdomain match
+	}
+
+	for _, tt := range tests {
+		if got := tr.ContainsHierarchical(tt.domain); got != tt.expected {
+			t.Errorf("ContainsHierarchical(%q) = %v, want %v", tt.domain, got, tt.expected)
+		}
+	}
+}
+
+func TestTrieContainsHierarchicalLabelAligned(t *testing.T) {
+	tr := New()
+	tr.Insert("tmail.com")
+	tr.Insert("om")
+
+	tests := []struct {
+		domain   string
+		expected bool
+	}{
+		{"tmail.com", true},
+		{"sub.tmail.com", true},
+		{"hotmail.com", false}, // shares a suffix but not a label
+		{"mail.hotmail.com", false},
+		{"com", false},
+	}
+
+	for _, tt := range tests {
+		if got := tr.ContainsHierarchical(tt.domain); got != tt.expected {
+			t.Errorf("ContainsHierarchical(%q) = %v, want %v", tt.domain, got, tt.expected)
+		}
+	}
+}
+
+func TestTrieLongestHierarchicalMatch(t *testing.T) {
+	tr := New()
+	tr.Insert("example.com")
+	tr.Insert("sub.example.com")
+
+	tests := []struct {
+		domain   string
+		shortest string
+		longest  string
+		found    bool
+	}{
+		{"x.sub.example.com", "example.com", "sub.example.com", true},
+		{"sub.example.com", "example.com", "sub.example.com", true},
+		{"other.example.com", "example.com", "example.com", true},
+		{"example.com", "example.com", "example.com", true},
+		{"xsub.example.com", "example.com", "example.com", true},
+		{"example.org", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		got, found := tr.HierarchicalMatch(tt.domain)
+		if got != tt.shortest || found != tt.found {
+			t.Errorf("HierarchicalMatch(%q) = (%q, %v), want (%q, %v)", tt.domain, got, found, tt.shortest, tt.found)
+		}
+		got, found = tr.LongestHierarchicalMatch(tt.domain)
+		if got != tt.longest || found != tt.found {
+			t.Errorf("LongestHierarchicalMatch(%q) = (%q, %v), want (%q, %v)", tt.domain, got, found, tt.longest, tt.found)
+		}
+		if contains := tr.ContainsHierarchical(tt.domain); contains != tt.found {
+			t.Errorf("ContainsHierarchical(%q) = %v, want %v", tt.domain, contains, tt.found)
+		}
+	}
+}
+
+func TestTrieAllSuffixMatches(t *testing.T) {
+	tr := New()
+	tr.Insert("example.com")
+	tr.Insert("mail.example.com")
+	tr.Insert("a.mail.example.com")
+	tr.Insert("ample.com")
+
+	tests := []struct {
+		domain   string
+		expected []string
+	}{
+		{"a.mail.example.com", []string{"example.com", "mail.example.com", "a.mail.example.com"}},
+		{"b.a.mail.example.com", []string{"example.com", "mail.example.com", "a.mail.example.com"}},
+		{"x.mail.example.com", []string{"example.com", "mail.example.com"}},
+		{"other.example.com", []string{"example.com"}},
+		{"example.com", []string{"example.com"}},
+		{"ample.com", []string{"ample.com"}},
+		{"xmail.example.com", []string{"example.com"}},
+		{"example.org", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got := tr.AllSuffixMatches(tt.domain)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("AllSuffixMatches(%q) = %v, want %v", tt.domain, got, tt.expected)
+		}
+	}
+}
+
+func TestTrieHierarchicalMatch(t *testing.T) {
+	tr := New()
+	tr.Insert("example.com")
+	tr.Insert("mail.example.com")
+
+	tests := []struct {
+		domain   string
+		expected string
+		found    bool
+	}{
+		{"example.com", "example.com", true},
+		{"a.mail.example.com", "example.com", true}, // nearest-to-root entry wins
+		{"other.com", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, found := tr.HierarchicalMatch(tt.domain)
+		if got != tt.expected || found != tt.found {
+			t.Errorf("HierarchicalMatch(%q) = (%q, %v), want (%q, %v)", tt.domain, got, found, tt.expected, tt.found)
+		}
+	}
+}
+
+func TestTrieHierarchicalMatchBelow(t *testing.T) {
+	tr := New()
+	tr.Insert("co.uk")
+	tr.Insert("tempmail.co.uk")
+	tr.Insert("com")
+
+	tests := []struct {
+		domain   string
+		suffix   string
+		expected string
+		found    bool
+	}{
+		{"co.uk", "co.uk", "co.uk", true}, // a suffix still matches itself
+		{"example.co.uk", "co.uk", "", false},
+		{"mail.tempmail.co.uk", "co.uk", "tempmail.co.uk", true},
+		{"tempmail.co.uk", "co.uk", "tempmail.co.uk", true},
+		{"example.com", "com", "", false},
+		{"example.com", "", "com", true}, // no suffix: like HierarchicalMatch
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		got, found := tr.HierarchicalMatchBelow(tt.domain, tt.suffix)
+		if got != tt.expected || found != tt.found {
+			t.Errorf("HierarchicalMatchBelow(%q, %q) = (%q, %v), want (%q, %v)", tt.domain, tt.suffix, got, found, tt.expected, tt.found)
+		}
+	}
+}
+
+func TestTrieTrace(t *testing.T) {
+	tr := New()
+	tr.Insert("om")
+	tr.Insert("b.com")
+
+	// The walk stops after "b.com" since nothing deeper is stored
+	steps := tr.Trace("a.b.com")
+	if len(steps) != 5 {
+		t.Fatalf("Trace(%q) returned %d steps, want 5", "a.b.com", len(steps))
+	}
+
+	expected := []TraceStep{
+		{Char: 'm', Suffix: "m"},
+		{Char: 'o', Suffix: "om", IsEnd: true},
+		{Char: 'c', Suffix: "com"},
+		{Char: '.', Suffix: ".com"},
+		{Char: 'b', Suffix: "b.com", IsEnd: true, Match: true},
+	}
+	for i, want := range expected {
+		if steps[i] != want {
+			t.Errorf("Trace(%q)[%d] = %+v, want %+v", "a.b.com", i, steps[i], want)
+		}
+	}
+
+	if steps := tr.Trace("x.org"); len(steps) != 0 {
+		t.Errorf("Trace(%q) = %+v, want no steps", "x.org", steps)
+	}
+}
+
+func TestContainsHierarchicalDoesNotAllocate(t *testing.T) {
+	tr := New()
+	tr.Insert("tempmail.com")
+
+	// Lookups walk the domain in place, and misses in the TLD, the common
+	// case for legitimate domains, stop at its first character
+	for _, domain := range []string{"gmail.de", "company.co.uk", "mail.tempmail.com"} {
+		if n := testing.AllocsPerRun(100, func() { tr.ContainsHierarchical(domain) }); n != 0 {
+			t.Errorf("ContainsHierarchical(%q) allocates %v times, want 0", domain, n)
+		}
+	}
+}
+
+func BenchmarkTrieContainsHierarchicalNegative(b *testing.B) {
+	tr := New()
+	domains := []string{
+		"tempmail.com",
+		"guerrillamail.com",
+		"10minutemail.com",
+		"yopmail.fr",
+		"test.org",
+	}
+	for _, d := range domains {
+		tr.Insert(d)
+	}
+
+	// Mostly legitimate domains, as seen in real signup traffic
+	testDomains := []string{
+		"gmail.de",
+		"company.co.uk",
+		"outlook.jp",
+		"example.io",
+		"startup.dev",
+		"school.edu",
+		"gmail.com",
+		"mail.tempmail.com",
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.ContainsHierarchical(testDomains[i%len(testDomains)])
+	}
+}
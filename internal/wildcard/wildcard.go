@@ -0,0 +1,116 @@
+// Package wildcard matches domains against patterns such as "*.temp-mail.*"
+// or "mail-temp-*.com", so that lists can cover providers that keep
+// registering the same name under new TLDs or with new numbers.
+package wildcard
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalid is returned for patterns that can't be compiled.
+var ErrInvalid = errors.New("wildcard: invalid pattern")
+
+// Pattern is a compiled wildcard pattern.
+type Pattern struct {
+	text  string
+	parts []string // Literal text between the wildcards; first and last may be empty
+}
+
+// IsPattern reports whether s contains a wildcard other than a leading
+// "*.", which lists use for "the domain and its subdomains" and which
+// domain normalization removes.
+func IsPattern(s string) bool {
+	return strings.Contains(strings.TrimPrefix(s, "*."), "*")
+}
+
+// Compile compiles a lowercase pattern. Each "*" matches any run of
+// characters, including none and including dots, so "temp-mail.*" matches
+// "temp-mail.org" as well as "temp-mail.co.uk". A leading "*." is removed,
+// since a pattern also matches all subdomains of the domains it matches.
+//
+// Patterns may only contain the characters of domain names and "*". They
+// must contain a dot and at least one other literal character, and their
+// labels must not be empty, so "*.*" and "a..*" are rejected. Compile
+// returns ErrInvalid for such patterns and for patterns without a wildcard.
+func Compile(s string) (Pattern, error) {
+	text := strings.TrimPrefix(s, "*.")
+	if !strings.Contains(text, "*") || !strings.Contains(text, ".") ||
+		strings.HasPrefix(text, ".") || strings.HasSuffix(text, ".") || strings.Contains(text, "..") {
+		return Pattern{}, ErrInvalid
+	}
+
+	literal := false
+	for _, c := range text {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_':
+			literal = true
+		case c == '.', c == '*':
+		default:
+			return Pattern{}, ErrInvalid
+		}
+	}
+	if !literal {
+		return Pattern{}, ErrInvalid
+	}
+
+	// Consecutive wildcards match the same as one
+	for strings.Contains(text, "**") {
+		text = strings.ReplaceAll(text, "**", "*")
+	}
+	return Pattern{text: text, parts: strings.Split(text, "*")}, nil
+}
+
+// String returns the pattern as compiled, without a leading "*.".
+func (p Pattern) String() string {
+	return p.text
+}
+
+// Match reports whether p matches domain or one of its parent domains, the
+// same hierarchical matching that exact list entries get.
+func (p Pattern) Match(domain string) bool {
+	for {
+		if p.matchExact(domain) {
+			return true
+		}
+		i := strings.IndexByte(domain, '.')
+		if i < 0 {
+			return false
+		}
+		domain = domain[i+1:]
+	}
+}
+
+// matchExact reports whether p matches the whole of domain. The first part
+// must be a prefix and the last a suffix; the parts in between are matched
+// leftmost, which is enough since the wildcards between them match anything.
+func (p Pattern) matchExact(domain string) bool {
+	first, last := p.parts[0], p.parts[len(p.parts)-1]
+	if len(domain) < len(first)+len(last) ||
+		!strings.HasPrefix(domain, first) || !strings.HasSuffix(domain, last) {
+		return false
+	}
+
+	middle := domain[len(first) : len(domain)-len(last)]
+	for _, part := range p.parts[1 : len(p.parts)-1] {
+		i := strings.Index(middle, part)
+		if i < 0 {
+			return false
+		}
+		middle = middle[i+len(part):]
+	}
+	return true
+}
+
+// Set is a list of patterns, matched in order.
+type Set []Pattern
+
+// Match returns the first pattern in s that matches domain.
+func (s Set) Match(domain string) (Pattern, bool) {
+	for _, p := range s {
+		if p.Match(domain) {
+			return p, true
+		}
+	}
+	return Pattern{}, false
+}
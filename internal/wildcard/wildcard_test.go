@@ -0,0 +1,124 @@
+package wildcard
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"*.temp-mail.*", "temp-mail.*"},
+		{"mail-temp-*.com", "mail-temp-*.com"},
+		{"*mail*.org", "*mail*.org"},
+		{"temp-mail.**", "temp-mail.*"},
+		{"*.*.example.com", "*.example.com"},
+	}
+
+	for _, tt := range tests {
+		p, err := Compile(tt.input)
+		if err != nil {
+			t.Errorf("Compile(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got := p.String(); got != tt.expected {
+			t.Errorf("Compile(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"example.com",
+		"*.example.com",
+		"*.*",
+		"*",
+		"temp-mail*",
+		".temp-mail.*",
+		"temp-mail.*.",
+		"temp..mail.*",
+		"temp mail.*",
+		"Temp-Mail.*",
+		"tëmp-mail.*",
+	} {
+		if _, err := Compile(input); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Compile(%q) error = %v, want ErrInvalid", input, err)
+		}
+	}
+}
+
+func TestIsPattern(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"*.temp-mail.*", true},
+		{"mail-temp-*.com", true},
+		{"*.example.com", false},
+		{"example.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPattern(tt.input); got != tt.expected {
+			t.Errorf("IsPattern(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		domain   string
+		expected bool
+	}{
+		{"*.temp-mail.*", "temp-mail.org", true},
+		{"*.temp-mail.*", "temp-mail.co.uk", true},
+		{"*.temp-mail.*", "inbox.temp-mail.io", true},
+		{"*.temp-mail.*", "mytemp-mail.org", false},
+		{"*.temp-mail.*", "temp-mail", false},
+		{"mail-temp-*.com", "mail-temp-42.com", true},
+		{"mail-temp-*.com", "x.mail-temp-42.com", true},
+		{"mail-temp-*.com", "mail-temp-42.net", false},
+		{"mail-temp-*.com", "mail-temp-42.com.evil.net", false},
+		{"*mail*.org", "tempmail24.org", true},
+		{"*mail*.org", "temp.org", false},
+		{"a*b*c.com", "abc.com", true},
+		{"a*b*c.com", "axbxbxc.com", true},
+		{"a*b*c.com", "acb.com", false},
+		{"a*a.com", "a.com", false},
+	}
+
+	for _, tt := range tests {
+		p, err := Compile(tt.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q) error = %v", tt.pattern, err)
+		}
+		if got := p.Match(tt.domain); got != tt.expected {
+			t.Errorf("Compile(%q).Match(%q) = %v, want %v", tt.pattern, tt.domain, got, tt.expected)
+		}
+	}
+}
+
+func TestSetMatch(t *testing.T) {
+	var set Set
+	for _, s := range []string{"mail-temp-*.com", "*.temp-mail.*"} {
+		p, err := Compile(s)
+		if err != nil {
+			t.Fatalf("Compile(%q) error = %v", s, err)
+		}
+		set = append(set, p)
+	}
+
+	if p, ok := set.Match("a.temp-mail.net"); !ok || p.String() != "temp-mail.*" {
+		t.Errorf("Match(%q) = %q, %v, want %q, true", "a.temp-mail.net", p, ok, "temp-mail.*")
+	}
+	if _, ok := set.Match("example.com"); ok {
+		t.Errorf("Match(%q) matched, want no match", "example.com")
+	}
+	if _, ok := Set(nil).Match("temp-mail.org"); ok {
+		t.Error("empty set matched")
+	}
+}
@@ -0,0 +1,315 @@
+package disposable
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
+)
+
+// shardManifestFileName is the name of the manifest in the shard directory.
+const shardManifestFileName = "manifest.json"
+
+// shardManifest describes the TLD shards written for WithLazyTLDLoading.
+type shardManifest struct {
+	Version   string                 `json:"version"`
+	CreatedAt time.Time              `json:"created_at"`
+	TLDs      map[string]shardCounts `json:"tlds"`
+	Providers []string               `json:"providers,omitempty"`
+
+	ProviderRules []trie.ProviderRule `json:"provider_rules,omitempty"`
+	BlockPatterns []string            `json:"block_patterns,omitempty"`
+	AllowPatterns []string            `json:"allow_patterns,omitempty"`
+}
+
+// shardCounts holds the sizes of a TLD's lists, so Stats can report the full
+// data before all of it is loaded.
+type shardCounts struct {
+	Blocklist int `json:"blocklist"`
+	Allowlist int `json:"allowlist"`
+
+	// EffectiveBlocklist is the size of the minimal covering set of the
+	// blocklist. Since all parents of a domain share its TLD, the sizes of
+	// the shards add up to that of the full blocklist.
+	EffectiveBlocklist int `json:"effective_blocklist"`
+}
+
+// dataFile returns the metadata of the data the shards were written from.
+func (m *shardManifest) dataFile() *trie.DataFile {
+	return &trie.DataFile{Version: m.Version, CreatedAt: m.CreatedAt, Providers: m.Providers, ProviderRules: m.ProviderRules,
+		BlockPatterns: m.BlockPatterns, AllowPatterns: m.AllowPatterns}
+}
+
+// shardState tracks which TLD shards exist and which are loaded. Loaded
+// shards are kept as one DAFSA per TLD in blocklist and allowlist, the bases
+// of the checker's tries. It is guarded by Checker.mu.
+type shardState struct {
+	available map[string]shardCounts
+	loaded    map[string]struct{}
+
+	blocklist *trie.Shards
+	allowlist *trie.Shards
+}
+
+// newShardState returns the state for freshly written or read shards, with
+// nothing loaded yet.
+func newShardState(m *shardManifest) *shardState {
+	s := &shardState{
+		available: m.TLDs,
+		loaded:    make(map[string]struct{}),
+		blocklist: trie.NewShards(),
+		allowlist: trie.NewShards(),
+	}
+	if s.available == nil {
+		s.available = make(map[string]shardCounts)
+	}
+	return s
+}
+
+// unloaded returns the sum of the counts of the shards not loaded yet.
+func (s *shardState) unloaded() shardCounts {
+	var sum shardCounts
+	for tld, n := range s.available {
+		if _, loaded := s.loaded[tld]; !loaded {
+			sum.Blocklist += n.Blocklist
+			sum.Allowlist += n.Allowlist
+			sum.EffectiveBlocklist += n.EffectiveBlocklist
+		}
+	}
+	return sum
+}
+
+// needs reports whether the shard for tld exists and isn't loaded yet.
+func (s *shardState) needs(tld string) bool {
+	if _, ok := s.available[tld]; !ok {
+		return false
+	}
+	_, loaded := s.loaded[tld]
+	return !loaded
+}
+
+// shardDir returns the directory holding the TLD shards of the data file.
+func (c *Checker) shardDir() string {
+	return c.getDataFilePath() + ".shards"
+}
+
+// shardPath returns the path of the shard for tld. The TLD is hex-encoded
+// since the data may contain labels that aren't safe as file names.
+func (c *Checker) shardPath(tld string) string {
+	return filepath.Join(c.shardDir(), hex.EncodeToString([]byte(tld))+".bin")
+}
+
+// readShardManifest reads the shard manifest, failing if it is missing,
+// older than the data file it was written from, or in an older format.
+func (c *Checker) readShardManifest() (*shardManifest, error) {
+	manifestPath := filepath.Join(c.shardDir(), shardManifestFileName)
+
+	info, err := os.Stat(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if dataInfo, err := os.Stat(c.getDataFilePath()); err == nil && dataInfo.ModTime().After(info.ModTime()) {
+		return nil, errors.New("shards are older than the data file")
+	}
+
+	raw, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var m shardManifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// writeShards splits df by TLD and writes one shard per TLD plus a manifest.
+// Files are replaced atomically so concurrent shard loads never see partial
+// data. The manifest is written last, so it only exists for complete shards.
+func (c *Checker) writeShards(df *trie.DataFile) (*shardManifest, error) {
+	dir := c.shardDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	m := &shardManifest{
+		Version:       df.Version,
+		CreatedAt:     df.CreatedAt,
+		Providers:     df.Providers,
+		ProviderRules: df.ProviderRules,
+		BlockPatterns: df.BlockPatterns,
+		AllowPatterns: df.AllowPatterns,
+		TLDs:          make(map[string]shardCounts),
+	}
+	for tld, shard := range trie.SplitByTLD(df) {
+		data, err := trie.SerializeDataFile(shard, trie.DefaultCompressionLevel)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(c.shardPath(tld), data); err != nil {
+			return nil, err
+		}
+
+		// Counted as the tries built by ensureShard would count them; only
+		// one shard's DAFSA is held at a time
+		blocklist := trie.NewOverlay(trie.NewDAFSA(shard.Blocklist))
+		m.TLDs[tld] = shardCounts{
+			Blocklist:          blocklist.Size(),
+			Allowlist:          trie.NewDAFSA(shard.Allowlist).Len(),
+			EffectiveBlocklist: blocklist.MinimalSize(),
+		}
+	}
+
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, shardManifestFileName), raw); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it over path, so readers see either the old or the new contents in full.
+// The temporary file has a unique name, so checkers sharing a cache
+// directory don't write into each other's.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// lazyTLDLoading reports whether data is split into TLD shards. Shards are
+// written to the cache directory, so they are never used without cache writes.
+func (c *Checker) lazyTLDLoading() bool {
+	return c.config.LazyTLDLoading && !c.config.NoCacheWrite
+}
+
+// setDecoded swaps in freshly decoded data. With lazy TLD loading, the data
+// is written out as shards and the checker starts with no TLD loaded; if the
+// shards can't be written, the full data is used instead. blocklist and
+// allowlist may be nil, in which case they are built from dataFile when the
+// full data is used.
+func (c *Checker) setDecoded(blocklist, allowlist *trie.Trie, dataFile *trie.DataFile, raw []byte) {
+	if c.lazyTLDLoading() {
+		m, err := c.writeShards(dataFile)
+		if err == nil {
+			// Only the metadata is kept: the lists are read back from the
+			// shards, one TLD at a time
+			c.setShards(m, m.dataFile(), raw)
+			return
+		}
+		c.config.Logger.Printf("Warning: failed to write TLD shards, loading all data: %v", err)
+	}
+
+	if blocklist == nil {
+		blocklist, allowlist = trie.NewTries(dataFile)
+	}
+	c.setData(blocklist, allowlist, dataFile, nil, raw)
+}
+
+// setShards swaps in the data of the shards described by m, with no TLD
+// loaded yet: the tries start as overlays of the empty sets of shards that
+// ensureShard adds to.
+func (c *Checker) setShards(m *shardManifest, dataFile *trie.DataFile, raw []byte) {
+	shards := newShardState(m)
+	c.setData(trie.NewOverlay(shards.blocklist), trie.NewOverlay(shards.allowlist), dataFile, shards, raw)
+}
+
+// ensureShard loads the shard for the TLD of domain if lazy TLD loading is
+// enabled and it isn't loaded yet. The first lookup of a TLD reads its shard
+// from disk while holding the write lock.
+func (c *Checker) ensureShard(domain string) {
+	tld := trie.TLD(domain)
+
+	c.mu.RLock()
+	needed := c.shards != nil && c.shards.needs(tld)
+	c.mu.RUnlock()
+	if !needed {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another lookup may have loaded it, or a refresh replaced the shards
+	if c.shards == nil || !c.shards.needs(tld) {
+		return
+	}
+
+	// Marked as loaded even on failure so a broken shard isn't re-read on
+	// every lookup; the next refresh rewrites it
+	c.shards.loaded[tld] = struct{}{}
+
+	data, err := os.ReadFile(c.shardPath(tld))
+	if err != nil {
+		c.config.Logger.Printf("Warning: failed to read shard for %q: %v", tld, err)
+		return
+	}
+	shard, err := trie.DecodeDataFile(data)
+	if err != nil {
+		c.config.Logger.Printf("Warning: failed to load shard for %q: %v", tld, err)
+		return
+	}
+
+	// The shard joins the bases of the tries in one compact DAFSA. Entries
+	// now backed by the data are no longer custom or runtime additions,
+	// like after a refresh, so their copies in the nodes are dropped
+	c.shards.blocklist.Add(tld, trie.NewDAFSA(shard.Blocklist))
+	for _, d := range shard.Blocklist {
+		if untrack(d, c.runtimeBlocklist, c.customBlocklist) {
+			c.blocklist.Remove(d)
+		}
+	}
+	for d, n := range shard.SourceCounts {
+		if c.sourceCounts == nil {
+			c.sourceCounts = make(map[string]int)
+		}
+		c.sourceCounts[d] = n
+	}
+	if c.config.AllowlistMode != AllowlistReplace {
+		c.shards.allowlist.Add(tld, trie.NewDAFSA(shard.Allowlist))
+		for _, d := range shard.Allowlist {
+			if untrack(d, c.runtimeAllowlist, c.customAllowlist) {
+				c.allowlist.Remove(d)
+			}
+		}
+	}
+	c.effectiveCount.Store(0)
+}
+
+// untrack removes domain from sets of custom or runtime additions and
+// reports whether it was in any of them.
+func untrack(domain string, sets ...map[string]struct{}) bool {
+	tracked := false
+	for _, set := range sets {
+		if _, ok := set[domain]; ok {
+			delete(set, domain)
+			tracked = true
+		}
+	}
+	return tracked
+}
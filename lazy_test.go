@@ -0,0 +1,164 @@
+package disposable
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckerLazyTLDLoading(t *testing.T) {
+	dir := newTestCacheDir(t)
+
+	full, err := New(WithCacheDir(dir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer full.Close()
+
+	want := full.Stats()
+
+	checker, err := New(WithCacheDir(dir), WithLazyTLDLoading())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	// Stats counts all TLDs, loaded or not, like a checker with all data
+	if got := checker.Stats(); got != want {
+		t.Errorf("Stats() = %+v before any lookup, want %+v", got, want)
+	}
+	if got := len(checker.GetBlocklist()); got != 0 {
+		t.Errorf("len(GetBlocklist()) = %d before any lookup, want 0", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data.bin.shards", shardManifestFileName)); err != nil {
+		t.Errorf("Expected shard manifest to be written: %v", err)
+	}
+
+	// Looking up a .com domain loads the .com shard only
+	if !checker.IsDisposable("user@mailinator.com") {
+		t.Error("Expected mailinator.com to be disposable")
+	}
+	if checker.IsDisposable("user@gmail.com") {
+		t.Error("Expected gmail.com not to be disposable")
+	}
+	if got := checker.Stats(); got != want {
+		t.Errorf("Stats() = %+v after .com lookups, want %+v", got, want)
+	}
+
+	var comCount int
+	for _, domain := range full.GetBlocklist() {
+		if strings.HasSuffix(domain, ".com") {
+			comCount++
+		}
+	}
+	if got := len(checker.GetBlocklist()); got != comCount {
+		t.Errorf("len(GetBlocklist()) = %d after .com lookups, want %d", got, comCount)
+	}
+}
+
+func TestCheckerLazyTLDLoadingWithoutCacheWrites(t *testing.T) {
+	dir := newTestCacheDir(t)
+
+	checker, err := New(WithCacheDir(dir), WithLazyTLDLoading(), WithNoCacheWrite())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	// Without cache writes no shards are written and all data is loaded
+	if _, err := os.Stat(filepath.Join(dir, "data.bin.shards")); !os.IsNotExist(err) {
+		t.Errorf("Stat(shard directory) error = %v, want not exist", err)
+	}
+	if got, want := len(checker.GetBlocklist()), checker.Stats().BlocklistCount; got != want {
+		t.Errorf("len(GetBlocklist()) = %d, want %d", got, want)
+	}
+}
+
+func TestCheckerLazyTLDLoadingOldManifest(t *testing.T) {
+	dir := newTestCacheDir(t)
+
+	first, err := New(WithCacheDir(dir), WithLazyTLDLoading())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	want := first.Stats()
+	first.Close()
+
+	// A manifest without counts is rewritten from the data file
+	manifestPath := filepath.Join(dir, "data.bin.shards", shardManifestFileName)
+	if err := os.WriteFile(manifestPath, []byte(`{"version":"2.0","tlds":["com"]}`), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	checker, err := New(WithCacheDir(dir), WithLazyTLDLoading(), WithDataURL("http://127.0.0.1:0/unused"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	if got := checker.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if !checker.IsDisposable("mailinator.com") {
+		t.Error("Expected mailinator.com to be disposable")
+	}
+}
+
+func TestCheckerLazyTLDLoadingFromShards(t *testing.T) {
+	dir := newTestCacheDir(t)
+
+	first, err := New(WithCacheDir(dir), WithLazyTLDLoading())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	first.Close()
+
+	// Corrupt the data file without making it newer than the shards; a
+	// later checker must start from the shards without decoding it
+	dataPath := filepath.Join(dir, "data.bin")
+	info, err := os.Stat(dataPath)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if err := os.WriteFile(dataPath, []byte("corrupt"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.Chtimes(dataPath, info.ModTime(), info.ModTime().Add(-time.Hour)); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	checker, err := New(WithCacheDir(dir), WithLazyTLDLoading(), WithDataURL("http://127.0.0.1:0/unused"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	if !checker.IsDisposable("mailinator.com") {
+		t.Error("Expected mailinator.com to be disposable")
+	}
+}
+
+func TestCheckerLazyTLDLoadingRuntimeDomains(t *testing.T) {
+	dir := newTestCacheDir(t)
+
+	checker, err := New(WithCacheDir(dir), WithLazyTLDLoading())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	// A runtime addition that the .com shard also contains stays after
+	// ClearCustom once the shard is loaded
+	checker.AddDomains("mailinator.com", "runtime-only.com")
+	checker.Prime("example.com")
+	checker.ClearCustom()
+
+	if !checker.IsDisposable("mailinator.com") {
+		t.Error("Expected mailinator.com to stay disposable after ClearCustom")
+	}
+	if checker.IsDisposable("runtime-only.com") {
+		t.Error("Expected runtime-only.com to be removed by ClearCustom")
+	}
+}
@@ -0,0 +1,166 @@
+// Package middleware provides net/http middleware that rejects requests
+// carrying a disposable email address, e.g. on signup handlers:
+//
+//	http.Handle("/signup", middleware.RejectDisposableField(checker, "email", signupHandler))
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	disposable "github.com/rezmoss/go-is-disposable-email"
+)
+
+// DefaultMaxBodySize is the largest JSON body inspected by default.
+const DefaultMaxBodySize = 1 << 20
+
+// rejectionError is the JSON body written for rejected requests.
+type rejectionError struct {
+	Error string `json:"error"`
+	Field string `json:"field,omitempty"`
+}
+
+// Options configures New.
+type Options struct {
+	// Fields are the form or top-level JSON fields to check, in order.
+	// Default: "email"
+	Fields []string
+
+	// Status is the status code of rejected requests.
+	// Default: 422 Unprocessable Entity
+	Status int
+
+	// Body returns the value encoded as the JSON body of a rejected request,
+	// given the field and its disposable value. Default: an object like
+	// {"error":"disposable email addresses are not allowed","field":"email"}
+	Body func(field, value string) any
+
+	// MaxBodySize is the largest JSON body read to find the fields. Larger
+	// bodies are rejected with 413 Request Entity Too Large, since padding
+	// would otherwise get any address past the check.
+	// Default: DefaultMaxBodySize
+	MaxBodySize int64
+}
+
+// RejectDisposableField returns middleware that rejects requests whose form
+// field fieldName holds a disposable email address or domain according to
+// checker. It is New with only fieldName and the default status and body.
+func RejectDisposableField(checker *disposable.Checker, fieldName string, next http.Handler) http.Handler {
+	return New(checker, Options{Fields: []string{fieldName}})(next)
+}
+
+// New returns middleware that rejects requests with a disposable email
+// address or domain in any of opts.Fields, checked with checker, or with the
+// package's default checker if checker is nil. Fields are read from a JSON
+// object body if the request has Content-Type application/json, and with
+// http.Request.FormValue otherwise, so they may come from the query string
+// or a URL-encoded or multipart body. JSON bodies are restored before the
+// next handler reads them.
+//
+// Rejected requests get opts.Status and the JSON body from opts.Body, by
+// default 422 Unprocessable Entity and a body like
+// {"error":"disposable email addresses are not allowed","field":"email"}.
+// JSON bodies larger than opts.MaxBodySize get 413 Request Entity Too Large.
+// Requests whose fields are missing, empty or not disposable are passed on,
+// as are JSON bodies that aren't an object.
+//
+// Note: With a nil checker, requests are passed on if the default checker
+// cannot be initialized.
+func New(checker *disposable.Checker, opts Options) func(http.Handler) http.Handler {
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = []string{"email"}
+	}
+	status := opts.Status
+	if status == 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	body := opts.Body
+	if body == nil {
+		body = func(field, value string) any {
+			return rejectionError{
+				Error: "disposable email addresses are not allowed",
+				Field: field,
+			}
+		}
+	}
+	maxBodySize := opts.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	isDisposable := disposable.IsDisposable
+	if checker != nil {
+		isDisposable = checker.IsDisposable
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lookup := r.FormValue
+			if isJSONRequest(r) {
+				var ok bool
+				if lookup, ok = readJSONFields(r, maxBodySize); !ok {
+					writeJSON(w, http.StatusRequestEntityTooLarge, rejectionError{Error: "request body too large"})
+					return
+				}
+			}
+
+			for _, field := range fields {
+				value := lookup(field)
+				if value == "" || !isDisposable(value) {
+					continue
+				}
+				writeJSON(w, status, body(field, value))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeJSON writes v as the JSON body of a reply with status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// isJSONRequest reports whether r has a JSON body.
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// readJSONFields reads r's JSON object body, restores it for the next
+// handler and returns a lookup of its top-level string fields. The lookup
+// returns "" for everything if the body can't be read or parsed. ok is false
+// if the body is larger than maxBodySize.
+func readJSONFields(r *http.Request, maxBodySize int64) (lookup func(string) string, ok bool) {
+	none := func(string) string { return "" }
+	if r.Body == nil {
+		return none, true
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if int64(len(data)) > maxBodySize {
+		return nil, false
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if err != nil {
+		return none, true
+	}
+
+	var object map[string]any
+	if json.Unmarshal(data, &object) != nil {
+		return none, true
+	}
+	return func(field string) string {
+		s, _ := object[field].(string)
+		return s
+	}, true
+}
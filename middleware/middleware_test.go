@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	disposable "github.com/rezmoss/go-is-disposable-email"
+)
+
+// newTestChecker returns a checker loaded from the repository's data.v2.bin.
+func newTestChecker(t *testing.T) *disposable.Checker {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("..", "data", "data.v2.bin"))
+	if err != nil {
+		t.Skipf("data/data.v2.bin not available: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), data, 0644); err != nil {
+		t.Fatalf("Failed to write data.bin: %v", err)
+	}
+
+	checker, err := disposable.New(disposable.WithCacheDir(dir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { checker.Close() })
+	return checker
+}
+
+func TestRejectDisposableField(t *testing.T) {
+	checker := newTestChecker(t)
+
+	handler := RejectDisposableField(checker, "email", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	tests := []struct {
+		name   string
+		form   url.Values
+		status int
+	}{
+		{"disposable", url.Values{"email": {"user@mailinator.com"}}, http.StatusUnprocessableEntity},
+		{"disposable subdomain", url.Values{"email": {"user@sub.guerrillamail.com"}}, http.StatusUnprocessableEntity},
+		{"legit", url.Values{"email": {"user@gmail.com"}}, http.StatusCreated},
+		{"missing field", url.Values{"name": {"user@mailinator.com"}}, http.StatusCreated},
+		{"empty field", url.Values{"email": {""}}, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status != http.StatusUnprocessableEntity {
+				return
+			}
+
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want %q", got, "application/json")
+			}
+			var body rejectionError
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode body: %v", err)
+			}
+			if body.Field != "email" || body.Error == "" {
+				t.Errorf("body = %+v, want field %q and an error message", body, "email")
+			}
+		})
+	}
+
+	// Fields in the query string are checked too
+	req := httptest.NewRequest(http.MethodGet, "/signup?email=user%40yopmail.com", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status for query field = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestNewDefaultChecker(t *testing.T) {
+	handler := New(nil, Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		email  string
+		status int
+	}{
+		{"user@sharklasers.com", http.StatusUnprocessableEntity},
+		{"user@outlook.com", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/?email="+url.QueryEscape(tt.email), nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("New(nil) for %q status = %d, want %d", tt.email, rec.Code, tt.status)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	checker := newTestChecker(t)
+
+	// The next handler echoes the body it receives, so tests can check that
+	// JSON bodies are restored
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		io.Copy(w, r.Body)
+	})
+	handler := New(checker, Options{
+		Fields: []string{"email", "backup_email"},
+		Status: http.StatusBadRequest,
+		Body: func(field, value string) any {
+			return map[string]string{"code": "disposable_email", "field": field, "value": value}
+		},
+		MaxBodySize: 1024,
+	})(next)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		field       string // rejected field
+	}{
+		{"json", "application/json", `{"email":"user@mailinator.com"}`, http.StatusBadRequest, "email"},
+		{"json second field", "application/json; charset=utf-8", `{"email":"user@gmail.com","backup_email":"x@yopmail.com"}`, http.StatusBadRequest, "backup_email"},
+		{"json legit", "application/json", `{"email":"user@gmail.com","age":30}`, http.StatusCreated, ""},
+		{"json not a string", "application/json", `{"email":["user@mailinator.com"]}`, http.StatusCreated, ""},
+		{"json array", "application/json", `["user@mailinator.com"]`, http.StatusCreated, ""},
+		{"json malformed", "application/json", `{"email":`, http.StatusCreated, ""},
+		{"form second field", "application/x-www-form-urlencoded", "backup_email=user%40mailinator.com", http.StatusBadRequest, "backup_email"},
+		{"form legit", "application/x-www-form-urlencoded", "email=user%40gmail.com", http.StatusCreated, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.field == "" {
+				if strings.HasPrefix(tt.contentType, "application/json") && rec.Body.String() != tt.body {
+					t.Errorf("next handler read body %q, want %q", rec.Body, tt.body)
+				}
+				return
+			}
+
+			var body map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode body: %v", err)
+			}
+			if body["code"] != "disposable_email" || body["field"] != tt.field || body["value"] == "" {
+				t.Errorf("body = %v, want the custom body for field %q", body, tt.field)
+			}
+		})
+	}
+
+	// Padding a body past the limit doesn't get an address through
+	large := `{"email":"user@mailinator.com","padding":"` + strings.Repeat("x", 1024) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(large))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body: status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+	var body rejectionError
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Error == "" {
+		t.Errorf("large body: body = %q, want an error message", rec.Body)
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	checker := newTestChecker(t)
+
+	handler := New(checker, Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(`{"email":"user@mailinator.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	var body rejectionError
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if body.Field != "email" || body.Error == "" {
+		t.Errorf("body = %+v, want field %q and an error message", body, "email")
+	}
+}
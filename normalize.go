@@ -0,0 +1,95 @@
+package disposable
+
+import (
+	"strings"
+)
+
+// builtinProviderRules are used by NormalizeEmail when the data has no
+// provider rules of its own. They mirror data/provider-rules.txt.
+var builtinProviderRules = []ProviderRule{
+	{Domains: []string{"gmail.com", "googlemail.com"}, Separators: "+", IgnoreDots: true},
+	{Domains: []string{"yahoo.com"}, Separators: "-"},
+	{Domains: []string{"ymail.com"}, Separators: "-"},
+	{Domains: []string{"rocketmail.com"}, Separators: "-"},
+	{Domains: []string{"outlook.com"}, Separators: "+"},
+	{Domains: []string{"hotmail.com"}, Separators: "+"},
+	{Domains: []string{"icloud.com", "me.com", "mac.com"}, Separators: "+"},
+	{Domains: []string{"protonmail.com", "protonmail.ch", "pm.me", "proton.me"}, Separators: "+"},
+	{Domains: []string{"yandex.ru", "yandex.com", "yandex.by", "yandex.kz", "yandex.ua", "ya.ru"}, Separators: "+"},
+	{Domains: []string{"fastmail.com"}, Separators: "+"},
+}
+
+// defaultProviderRules indexes builtinProviderRules by domain.
+var defaultProviderRules = indexProviderRules(builtinProviderRules)
+
+// indexProviderRules maps every domain of rules to its rule. If a domain
+// appears in several rules, the first one wins.
+func indexProviderRules(rules []ProviderRule) map[string]*ProviderRule {
+	index := make(map[string]*ProviderRule)
+	for i := range rules {
+		for _, domain := range rules[i].Domains {
+			if _, ok := index[domain]; !ok {
+				index[domain] = &rules[i]
+			}
+		}
+	}
+	return index
+}
+
+// NormalizeEmail returns a normalized form of an email address for
+// deduplicating signups. It is CanonicalEmail with provider rules on top,
+// so that addresses delivered to the same mailbox normalize alike:
+//   - the subaddress separators a provider uses are stripped besides the
+//     ones set with WithSubaddressSeparators, so "base-shop@yahoo.com"
+//     becomes "base@yahoo.com";
+//   - dots are removed where the provider ignores them, so
+//     "first.last@gmail.com" becomes "firstlast@gmail.com";
+//   - alias domains are replaced by the provider's main domain, so
+//     "user@googlemail.com" becomes "user@gmail.com".
+//
+// The rules come from the data file, so they can change without a new
+// release, or from a built-in table if the data has none. It returns empty
+// string if the input is not a valid email address.
+func (c *Checker) NormalizeEmail(email string) string {
+	c.mu.RLock()
+	rules := c.providerRules
+	c.mu.RUnlock()
+
+	if len(rules) == 0 {
+		rules = defaultProviderRules
+	}
+	return normalizeEmail(email, c.config.SubaddressSeparators, rules)
+}
+
+// normalizeEmail implements NormalizeEmail with the given separators and
+// rules.
+func normalizeEmail(email string, seps []rune, rules map[string]*ProviderRule) string {
+	email = canonicalEmail(email)
+	if email == "" {
+		return ""
+	}
+
+	at := strings.LastIndexByte(email, '@')
+	domain := email[at+1:]
+	rule, ok := rules[domain]
+	if !ok {
+		return stripSubaddress(email, seps)
+	}
+
+	if rule.Separators != "" {
+		seps = append(append([]rune{}, seps...), []rune(rule.Separators)...)
+	}
+	email = stripSubaddress(email, seps)
+
+	local := email[:strings.LastIndexByte(email, '@')]
+	if rule.IgnoreDots {
+		// Keep the local part if it's made of dots only
+		if stripped := strings.ReplaceAll(local, ".", ""); stripped != "" {
+			local = stripped
+		}
+	}
+	if len(rule.Domains) > 0 {
+		domain = rule.Domains[0]
+	}
+	return local + "@" + domain
+}
@@ -0,0 +1,124 @@
+package disposable
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
+)
+
+func TestCheckerNormalizeEmail(t *testing.T) {
+	dir := newTestCacheDir(t)
+
+	tests := []struct {
+		seps     []rune
+		input    string
+		expected string
+	}{
+		{nil, "User+Spam@Gmail.com", "user@gmail.com"},
+		{nil, "user@gmail.com", "user@gmail.com"},
+		{nil, "First.Last+news@gmail.com", "firstlast@gmail.com"},
+		{nil, "first.last@googlemail.com", "firstlast@gmail.com"},
+		{nil, "...@gmail.com", "...@gmail.com"},
+		{nil, "user+tag@ya.ru", "user@yandex.ru"},
+		{nil, "user@yandex.com", "user@yandex.ru"},
+		{nil, "first.last@yandex.ru", "first.last@yandex.ru"},
+		{nil, "user+tag@me.com", "user@icloud.com"},
+		{nil, "base-shopping@yahoo.com", "base@yahoo.com"},
+		{nil, "base+news@ymail.com", "base@ymail.com"},
+		{nil, "first-last@example.com", "first-last@example.com"}, // '-' only for Yahoo
+		{nil, "-base@yahoo.com", "-base@yahoo.com"},
+		{[]rune{'='}, "user=tag@example.com", "user@example.com"},
+		{[]rune{'='}, "user+tag@example.com", "user+tag@example.com"},
+		{[]rune{'='}, "base-tag@yahoo.com", "base@yahoo.com"},
+		{nil, "example.com", ""},
+		{nil, "", ""},
+		{nil, "user@gmail", ""},
+		{nil, "a@[", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			opts := []Option{WithCacheDir(dir)}
+			if tt.seps != nil {
+				opts = append(opts, WithSubaddressSeparators(tt.seps...))
+			}
+			checker, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer checker.Close()
+
+			if got := checker.NormalizeEmail(tt.input); got != tt.expected {
+				t.Errorf("NormalizeEmail(%q) with %q = %q, want %q", tt.input, string(tt.seps), got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"User+Spam@Gmail.com", "user@gmail.com"},
+		{"u.s.e.r@GoogleMail.com", "user@gmail.com"},
+		{"base-keyword@Yahoo.com", "base@yahoo.com"},
+		{"not-an-address", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeEmail(tt.input); got != tt.expected {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCheckerNormalizeEmailDataRules(t *testing.T) {
+	// Data with its own rules replaces the built-in ones
+	blocklist := trie.New()
+	blocklist.Insert("tempmail.com")
+	df := trie.NewDataFile(blocklist, trie.New(), time.Now())
+	df.ProviderRules = []ProviderRule{
+		{Domains: []string{"corpmail.example", "corp-alias.example"}, Separators: "_", IgnoreDots: true},
+	}
+	data, err := trie.SerializeDataFile(df, trie.DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDataFile() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), data, 0644); err != nil {
+		t.Fatalf("Failed to write data.bin: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"first.last_tag@corp-alias.example", "firstlast@corpmail.example"},
+		{"first.last+tag@corpmail.example", "firstlast@corpmail.example"},
+		{"first.last@gmail.com", "first.last@gmail.com"}, // built-in rules replaced
+	}
+
+	// Lazy loading reads the rules from the shard manifest on later runs
+	for _, lazy := range []bool{false, true, true} {
+		opts := []Option{WithCacheDir(dir)}
+		if lazy {
+			opts = append(opts, WithLazyTLDLoading())
+		}
+		checker, err := New(opts...)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		for _, tt := range tests {
+			if got := checker.NormalizeEmail(tt.input); got != tt.expected {
+				t.Errorf("NormalizeEmail(%q) with lazy=%v = %q, want %q", tt.input, lazy, got, tt.expected)
+			}
+		}
+		checker.Close()
+	}
+}
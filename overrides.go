@@ -0,0 +1,128 @@
+package disposable
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// DefaultOverridesFileName is the name of the overrides file looked up in the
+// cache directory when no overrides file is configured.
+const DefaultOverridesFileName = "overrides.txt"
+
+// parseOverrides reads override directives, one per line. A plain domain
+// blocks it, while a domain prefixed with "!" allows it. Empty lines and lines
+// starting with "#" are ignored.
+func parseOverrides(r io.Reader) (block, allow []string, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if domain, ok := strings.CutPrefix(line, "!"); ok {
+			if domain = NormalizeDomain(domain); domain != "" {
+				allow = append(allow, domain)
+			}
+			continue
+		}
+
+		block = append(block, NormalizeDomain(line))
+	}
+
+	return block, allow, scanner.Err()
+}
+
+// loadOverrides reads the configured overrides file. A missing file is not an
+// error; other failures are logged and the overrides skipped.
+func (c *Checker) loadOverrides() (block, allow []string) {
+	block, allow, err := c.readOverrides()
+	if err != nil {
+		c.config.Logger.Printf("Warning: %v", err)
+		return nil, nil
+	}
+	return block, allow
+}
+
+// readOverrides reads the configured overrides file. A missing file yields no
+// overrides and no error.
+func (c *Checker) readOverrides() (block, allow []string, err error) {
+	path := c.config.OverridesFile
+	if path == "" {
+		return nil, nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read overrides file: %w", err)
+	}
+	defer f.Close()
+
+	block, allow, err = parseOverrides(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read overrides file: %w", err)
+	}
+	return block, allow, nil
+}
+
+// ReloadCustomLists re-reads the overrides file and re-applies it together
+// with the configured custom domains, replacing the previous custom overlay.
+// Operators can thus edit the overrides file and pick up the changes without
+// waiting for a refresh or restarting. Runtime additions are kept.
+//
+// If the file can't be read, the error is returned and the previous overlay
+// stays in effect. A missing file removes all overrides.
+func (c *Checker) ReloadCustomLists() error {
+	block, allow, err := c.readOverrides()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.replaceCustom(block, allow)
+	return nil
+}
+
+// ResetOverlays discards everything added at runtime, like ClearCustom, and
+// re-applies the configured custom domains and the overrides file, like
+// ReloadCustomLists, in one step. The checker is then back to the loaded data
+// plus its configuration, without downloading or decoding anything.
+//
+// If the overrides file can't be read, a warning is logged and the current
+// custom overlay is kept; runtime additions are still removed.
+func (c *Checker) ResetOverlays() {
+	block, allow, err := c.readOverrides()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clearRuntime()
+	if err != nil {
+		c.config.Logger.Printf("Warning: %v", err)
+		return
+	}
+	c.replaceCustom(block, allow)
+}
+
+// replaceCustom replaces the custom overlay with the configured custom
+// domains and the given overrides. The caller must hold c.mu.
+func (c *Checker) replaceCustom(overrideBlock, overrideAllow []string) {
+	for domain := range c.customBlocklist {
+		c.blocklist.Remove(domain)
+	}
+	for domain := range c.customAllowlist {
+		c.allowlist.Remove(domain)
+	}
+	c.applyCustomDomains(c.blocklist, c.allowlist, overrideBlock, overrideAllow)
+	c.effectiveCount.Store(0)
+}
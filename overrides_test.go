@@ -0,0 +1,205 @@
+package disposable
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOverrides(t *testing.T) {
+	input := `# Operator overrides
+Blocked.com
+  !Allowed.com
+
+!
+sub.blocked.org
+`
+	block, allow, err := parseOverrides(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseOverrides error: %v", err)
+	}
+
+	if expected := []string{"blocked.com", "sub.blocked.org"}; !reflect.DeepEqual(block, expected) {
+		t.Errorf("block = %v, want %v", block, expected)
+	}
+	if expected := []string{"allowed.com"}; !reflect.DeepEqual(allow, expected) {
+		t.Errorf("allow = %v, want %v", allow, expected)
+	}
+}
+
+func TestCheckerOverridesFile(t *testing.T) {
+	server, _ := newTestDataServer(t, 0)
+	cacheDir := newTestCacheDir(t)
+
+	overridesPath := filepath.Join(cacheDir, DefaultOverridesFileName)
+	if err := os.WriteFile(overridesPath, []byte("override-blocked.com\n!mailinator.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to write overrides: %v", err)
+	}
+
+	checker, err := New(WithCacheDir(cacheDir), WithDataURL(server.URL))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	if !checker.IsDisposable("override-blocked.com") {
+		t.Error("Expected override-blocked.com to be blocked by overrides file")
+	}
+	if checker.IsDisposable("mailinator.com") {
+		t.Error("Expected mailinator.com to be allowed by overrides file")
+	}
+
+	// Edits take effect on the next refresh, and existing overrides persist
+	if err := os.WriteFile(overridesPath, []byte("override-blocked.com\n!mailinator.com\nnew-override.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to write overrides: %v", err)
+	}
+	if checker.IsDisposable("new-override.com") {
+		t.Error("Expected overrides edit to not apply before refresh")
+	}
+
+	if err := checker.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	if !checker.IsDisposable("override-blocked.com") {
+		t.Error("Expected override to persist across refresh")
+	}
+	if checker.IsDisposable("mailinator.com") {
+		t.Error("Expected allow override to persist across refresh")
+	}
+	if !checker.IsDisposable("new-override.com") {
+		t.Error("Expected new override to apply after refresh")
+	}
+}
+
+func TestCheckerWithOverridesFile(t *testing.T) {
+	overridesPath := filepath.Join(t.TempDir(), "custom-overrides.txt")
+	if err := os.WriteFile(overridesPath, []byte("custom-path-override.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to write overrides: %v", err)
+	}
+
+	checker, err := New(WithCacheDir(newTestCacheDir(t)), WithOverridesFile(overridesPath))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	if !checker.IsDisposable("custom-path-override.com") {
+		t.Error("Expected override from custom path to be applied")
+	}
+}
+
+func TestCheckerReloadCustomLists(t *testing.T) {
+	overridesPath := filepath.Join(t.TempDir(), "overrides.txt")
+	if err := os.WriteFile(overridesPath, []byte("reload-blocked.com\n!mailinator.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to write overrides: %v", err)
+	}
+
+	checker, err := New(
+		WithCacheDir(newTestCacheDir(t)),
+		WithOverridesFile(overridesPath),
+		WithCustomBlocklist("config-blocked.com"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	checker.AddDomains("runtime-blocked.com")
+
+	if err := os.WriteFile(overridesPath, []byte("reload-added.com\n!yopmail.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to write overrides: %v", err)
+	}
+	if err := checker.ReloadCustomLists(); err != nil {
+		t.Fatalf("ReloadCustomLists() error = %v", err)
+	}
+
+	tests := []struct {
+		domain   string
+		expected bool
+	}{
+		{"reload-blocked.com", false}, // removed from the file
+		{"mailinator.com", true},      // no longer allowed
+		{"reload-added.com", true},
+		{"yopmail.com", false},
+		{"config-blocked.com", true},
+		{"runtime-blocked.com", true},
+	}
+	for _, tt := range tests {
+		if result := checker.IsDisposable(tt.domain); result != tt.expected {
+			t.Errorf("IsDisposable(%q) = %v, want %v after reload", tt.domain, result, tt.expected)
+		}
+	}
+
+	// A file that can't be read keeps the previous overlay
+	if err := os.Remove(overridesPath); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := os.Mkdir(overridesPath, 0755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	if err := checker.ReloadCustomLists(); err == nil {
+		t.Error("Expected error for unreadable overrides file")
+	}
+	if !checker.IsDisposable("reload-added.com") {
+		t.Error("Expected previous overrides to stay after a failed reload")
+	}
+
+	// A missing file removes all overrides
+	if err := os.Remove(overridesPath); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := checker.ReloadCustomLists(); err != nil {
+		t.Fatalf("ReloadCustomLists() error = %v", err)
+	}
+	if checker.IsDisposable("reload-added.com") || !checker.IsDisposable("yopmail.com") {
+		t.Error("Expected overrides to be removed after the file was deleted")
+	}
+}
+
+func TestCheckerResetOverlays(t *testing.T) {
+	overridesPath := filepath.Join(t.TempDir(), "overrides.txt")
+	if err := os.WriteFile(overridesPath, []byte("override-blocked.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to write overrides: %v", err)
+	}
+
+	checker, err := New(
+		WithCacheDir(newTestCacheDir(t)),
+		WithOverridesFile(overridesPath),
+		WithCustomBlocklist("config-blocked.com"),
+		WithCustomAllowlist("config-allowed.mailinator.com"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	checker.AddDomains("runtime-blocked.com", "config-blocked.com")
+	checker.AddAllowlist("yopmail.com")
+	checker.AddBlockedEmail("someone@gmail.com")
+
+	checker.ResetOverlays()
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"runtime-blocked.com", false},
+		{"yopmail.com", true},
+		{"someone@gmail.com", false},
+		{"config-blocked.com", true},
+		{"config-allowed.mailinator.com", false},
+		{"override-blocked.com", true},
+		{"mailinator.com", true},
+	}
+	for _, tt := range tests {
+		if result := checker.IsDisposable(tt.input); result != tt.expected {
+			t.Errorf("IsDisposable(%q) = %v, want %v after reset", tt.input, result, tt.expected)
+		}
+	}
+	if block, allow := checker.OverlaySize(); block != 0 || allow != 0 {
+		t.Errorf("OverlaySize() = (%d, %d), want (0, 0)", block, allow)
+	}
+}
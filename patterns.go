@@ -0,0 +1,68 @@
+package disposable
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/wildcard"
+)
+
+// patternList holds the wildcard patterns of a list, such as
+// "*.temp-mail.*", keeping those from the data apart from the custom ones
+// so that the custom ones can be replaced on reload.
+type patternList struct {
+	data   wildcard.Set
+	custom wildcard.Set
+}
+
+// compilePatterns compiles the patterns of a data file. Invalid patterns are
+// skipped, as they can only match nothing or everything.
+func compilePatterns(patterns []string) wildcard.Set {
+	var set wildcard.Set
+	for _, p := range patterns {
+		if compiled, err := wildcard.Compile(p); err == nil {
+			set = append(set, compiled)
+		}
+	}
+	return set
+}
+
+// addCustom adds a custom pattern unless it is invalid or already listed.
+func (l *patternList) addCustom(pattern string) {
+	compiled, err := wildcard.Compile(pattern)
+	if err != nil {
+		return
+	}
+	for _, p := range append(l.data, l.custom...) {
+		if p.String() == compiled.String() {
+			return
+		}
+	}
+	l.custom = append(l.custom, compiled)
+}
+
+// match returns the first pattern matching domain and whether it is a custom
+// one. Patterns from the data are tried first.
+func (l *patternList) match(domain string) (pattern string, custom, ok bool) {
+	if p, ok := l.data.Match(domain); ok {
+		return p.String(), false, true
+	}
+	if p, ok := l.custom.Match(domain); ok {
+		return p.String(), true, true
+	}
+	return "", false, false
+}
+
+// compileBlockPatterns compiles the regular expressions of
+// WithBlockPatterns, failing on the first invalid one.
+func compileBlockPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("block pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
@@ -0,0 +1,169 @@
+package disposable
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
+)
+
+func TestCheckerCustomPatterns(t *testing.T) {
+	checker, err := New(
+		WithCacheDir(newTestCacheDir(t)),
+		WithCustomBlocklist("*.Temp-Mail.*", "mail-temp-*.com", "*.*"),
+		WithCustomAllowlist("temp-mail.gov*"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	tests := []struct {
+		input    string
+		expected Classification
+	}{
+		{"user@temp-mail.xyz", Classification{Domain: "temp-mail.xyz", Rule: RuleBlocklist, Matched: "temp-mail.*", Custom: true}},
+		{"inbox.temp-mail.co.uk", Classification{Domain: "inbox.temp-mail.co.uk", Rule: RuleBlocklist, Matched: "temp-mail.*", Custom: true}},
+		{"user@mail-temp-7.com", Classification{Domain: "mail-temp-7.com", Rule: RuleBlocklist, Matched: "mail-temp-*.com", Custom: true}},
+		{"user@temp-mail.gov.uk", Classification{Domain: "temp-mail.gov.uk", Rule: RuleAllowlist, Matched: "temp-mail.gov*", Custom: true}},
+		{"user@gmail.com", Classification{Domain: "gmail.com", Rule: RuleNone}}, // "*.*" is ignored
+	}
+
+	for _, tt := range tests {
+		if got := checker.Classify(tt.input); got != tt.expected {
+			t.Errorf("Classify(%q) = %+v, want %+v", tt.input, got, tt.expected)
+		}
+		if got, want := checker.IsDisposable(tt.input), tt.expected.Rule == RuleBlocklist; got != want {
+			t.Errorf("IsDisposable(%q) = %v, want %v", tt.input, got, want)
+		}
+	}
+}
+
+func TestCheckerOverridePatterns(t *testing.T) {
+	dir := newTestCacheDir(t)
+	overrides := filepath.Join(dir, "overrides.txt")
+	if err := os.WriteFile(overrides, []byte("*.temp-mail.*\n!temp-mail.gov*\n"), 0644); err != nil {
+		t.Fatalf("Failed to write overrides: %v", err)
+	}
+
+	checker, err := New(WithCacheDir(dir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	if !checker.IsDisposable("temp-mail.xyz") || checker.IsDisposable("temp-mail.gov.uk") {
+		t.Fatal("Expected override patterns to block temp-mail.xyz and allow temp-mail.gov.uk")
+	}
+
+	// Reloading replaces the patterns of the previous overrides
+	if err := os.WriteFile(overrides, []byte("mail-temp-*.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to write overrides: %v", err)
+	}
+	if err := checker.ReloadCustomLists(); err != nil {
+		t.Fatalf("ReloadCustomLists() error = %v", err)
+	}
+	if checker.IsDisposable("temp-mail.xyz") || !checker.IsDisposable("mail-temp-1.com") {
+		t.Error("Expected reloaded overrides to replace the previous patterns")
+	}
+}
+
+func TestCheckerDataPatterns(t *testing.T) {
+	blocklist := trie.New()
+	blocklist.Insert("tempmail.com")
+	df := trie.NewDataFile(blocklist, trie.New(), time.Now())
+	df.BlockPatterns = []string{"temp-mail.*", "*.*", "mail-temp-*.com"}
+	df.AllowPatterns = []string{"temp-mail.gov*"}
+	data, err := trie.SerializeDataFile(df, trie.DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDataFile() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), data, 0644); err != nil {
+		t.Fatalf("Failed to write data.bin: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected Classification
+	}{
+		{"user@temp-mail.xyz", Classification{Domain: "temp-mail.xyz", Rule: RuleBlocklist, Matched: "temp-mail.*"}},
+		{"user@mail-temp-7.com", Classification{Domain: "mail-temp-7.com", Rule: RuleBlocklist, Matched: "mail-temp-*.com"}},
+		{"user@temp-mail.gov.uk", Classification{Domain: "temp-mail.gov.uk", Rule: RuleAllowlist, Matched: "temp-mail.gov*"}},
+		{"user@tempmail.com", Classification{Domain: "tempmail.com", Rule: RuleBlocklist, Matched: "tempmail.com"}},
+		{"user@gmail.com", Classification{Domain: "gmail.com", Rule: RuleNone}}, // invalid "*.*" is skipped
+	}
+
+	// Lazy loading reads the patterns from the shard manifest on later runs
+	for _, lazy := range []bool{false, true, true} {
+		opts := []Option{WithCacheDir(dir)}
+		if lazy {
+			opts = append(opts, WithLazyTLDLoading())
+		}
+		checker, err := New(opts...)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		for _, tt := range tests {
+			if got := checker.Classify(tt.input); got != tt.expected {
+				t.Errorf("Classify(%q) with lazy=%v = %+v, want %+v", tt.input, lazy, got, tt.expected)
+			}
+		}
+		checker.Close()
+	}
+}
+
+func TestCheckerBlockPatterns(t *testing.T) {
+	checker, err := New(
+		WithCacheDir(newTestCacheDir(t)),
+		WithBlockPatterns(`^[a-z]{2}[0-9]{6}\.(com|net)$`, `^mx-[0-9a-f]{8}\.`),
+		WithCustomAllowlist("ab123456.com"),
+		WithHeuristicPatterns("mx-"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	tests := []struct {
+		input   string
+		rule    Rule
+		matched string
+	}{
+		{"user@qz482913.com", RuleBlockPattern, `^[a-z]{2}[0-9]{6}\.(com|net)$`},
+		{"user@QZ482913.NET", RuleBlockPattern, `^[a-z]{2}[0-9]{6}\.(com|net)$`},
+		{"user@mx-0badc0de.org", RuleBlockPattern, `^mx-[0-9a-f]{8}\.`}, // before heuristics
+		{"user@mx-relay.org", RuleHeuristic, "mx-"},
+		{"user@qz482913.org", RuleNone, ""},
+		{"user@ab123456.com", RuleAllowlist, "ab123456.com"},
+		{"user@mailinator.com", RuleBlocklist, "mailinator.com"}, // list entries are reported first
+	}
+
+	for _, tt := range tests {
+		cl := checker.Classify(tt.input)
+		if cl.Rule != tt.rule || cl.Matched != tt.matched {
+			t.Errorf("Classify(%q) = {%v %q}, want {%v %q}", tt.input, cl.Rule, cl.Matched, tt.rule, tt.matched)
+		}
+		if cl.Disposable() != checker.IsDisposable(tt.input) {
+			t.Errorf("Classify(%q).Disposable() disagrees with IsDisposable", tt.input)
+		}
+	}
+}
+
+func TestNewInvalidBlockPattern(t *testing.T) {
+	_, err := New(WithCacheDir(t.TempDir()), WithBlockPatterns(`^ok\.com$`, `^bad(\.com$`))
+
+	var initErr *InitializationError
+	if !errors.As(err, &initErr) {
+		t.Fatalf("New() error = %v, want an InitializationError", err)
+	}
+	if !strings.Contains(err.Error(), `block pattern "^bad(\\.com$"`) {
+		t.Errorf("New() error = %q, want it to name the invalid pattern", err)
+	}
+}
@@ -0,0 +1,36 @@
+package disposable
+
+import (
+	"golang.org/x/net/publicsuffix"
+)
+
+// publicSuffix returns the public suffix of a normalized domain, e.g. "co.uk"
+// for "mail.example.co.uk" and "com" for "example.com". Suffixes come from
+// the Public Suffix List (https://publicsuffix.org), including its private
+// section, as compiled into golang.org/x/net/publicsuffix; domains under no
+// listed suffix have their last label as suffix, the list's default rule.
+func publicSuffix(domain string) string {
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	return suffix
+}
+
+// RegistrableDomain returns the registrable part of a domain (its public
+// suffix plus one label, also known as eTLD+1), e.g. "example.co.uk" for
+// "a.b.example.co.uk". This is useful for grouping domains by organization.
+// It returns empty string if the domain has no registrable part, such as a
+// bare public suffix like "co.uk".
+//
+// Public suffixes are determined from the Public Suffix List; updating
+// golang.org/x/net updates the list.
+func RegistrableDomain(domain string) string {
+	domain = NormalizeDomain(domain)
+	if domain == "" {
+		return ""
+	}
+
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return ""
+	}
+	return registrable
+}
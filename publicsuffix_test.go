@@ -0,0 +1,98 @@
+package disposable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegistrableDomain(t *testing.T) {
+	tests := []struct {
+		domain   string
+		expected string
+	}{
+		{"a.b.example.co.uk", "example.co.uk"},
+		{"example.co.uk", "example.co.uk"},
+		{"mail.tempmail.com", "tempmail.com"},
+		{"tempmail.com", "tempmail.com"},
+		{"Sub.Example.COM.", "example.com"},
+		{"sub.user.github.io", "user.github.io"},
+		{"shop.example.com.au", "example.com.au"},
+		{"a.b.example.kawasaki.jp", "b.example.kawasaki.jp"},
+		{"www.city.kawasaki.jp", "city.kawasaki.jp"},
+		{"example.unknowntld", "example.unknowntld"},
+		{"co.uk", ""},
+		{"com", ""},
+		{"github.io", ""},
+		{".co.uk", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			result := RegistrableDomain(tt.domain)
+			if result != tt.expected {
+				t.Errorf("RegistrableDomain(%q) = %q, want %q", tt.domain, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPublicSuffix(t *testing.T) {
+	tests := []struct {
+		domain   string
+		expected string
+	}{
+		{"mail.example.co.uk", "co.uk"},
+		{"example.com", "com"},
+		{"co.uk", "co.uk"},
+		{"com", "com"},
+		{"example.org.mt", "org.mt"},
+		{"x.example.kawasaki.jp", "example.kawasaki.jp"},
+		{"mail.example.unknowntld", "unknowntld"},
+	}
+
+	for _, tt := range tests {
+		if result := publicSuffix(tt.domain); result != tt.expected {
+			t.Errorf("publicSuffix(%q) = %q, want %q", tt.domain, result, tt.expected)
+		}
+	}
+}
+
+func TestCheckerPublicSuffixEntries(t *testing.T) {
+	// Public suffixes that slipped into the lists only match themselves
+	checker, err := New(
+		WithCacheDir(newTestCacheDir(t)),
+		WithCustomBlocklist("co.uk", "github.io", "burner.co.uk", "spam.com.au"),
+		WithCustomAllowlist("com.au"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	tests := []struct {
+		input    string
+		expected Classification
+	}{
+		{"user@example.co.uk", Classification{Domain: "example.co.uk", Rule: RuleNone}},
+		{"user@mail.example.co.uk", Classification{Domain: "mail.example.co.uk", Rule: RuleNone}},
+		{"user@someone.github.io", Classification{Domain: "someone.github.io", Rule: RuleNone}},
+		{"user@co.uk", Classification{Domain: "co.uk", Rule: RuleBlocklist, Matched: "co.uk", Custom: true}},
+		{"user@x.burner.co.uk", Classification{Domain: "x.burner.co.uk", Rule: RuleBlocklist, Matched: "burner.co.uk", Custom: true}},
+		{"user@spam.com.au", Classification{Domain: "spam.com.au", Rule: RuleBlocklist, Matched: "spam.com.au", Custom: true}},
+		{"user@mailinator.com", Classification{Domain: "mailinator.com", Rule: RuleBlocklist, Matched: "mailinator.com"}},
+	}
+
+	for _, tt := range tests {
+		if got := checker.Classify(tt.input); got != tt.expected {
+			t.Errorf("Classify(%q) = %+v, want %+v", tt.input, got, tt.expected)
+		}
+		if got, want := checker.IsDisposable(tt.input), tt.expected.Rule == RuleBlocklist; got != want {
+			t.Errorf("IsDisposable(%q) = %v, want %v", tt.input, got, want)
+		}
+	}
+
+	if blocked, allowed := checker.MatchingEntries("a.burner.co.uk"); !reflect.DeepEqual(blocked, []string{"burner.co.uk"}) || len(allowed) != 0 {
+		t.Errorf("MatchingEntries(%q) = %v, %v, want [burner.co.uk], []", "a.burner.co.uk", blocked, allowed)
+	}
+}
@@ -16,16 +16,16 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
-// setupTestData copies data/data.bin to the cache directory for tests.
+// setupTestData copies data/data.v2.bin to the cache directory for tests.
 // This ensures tests work in CI where there's no pre-existing cache.
 func setupTestData() error {
 	// Find the data.bin file relative to the package
-	srcPath := filepath.Join("data", "data.bin")
+	srcPath := filepath.Join("data", "data.v2.bin")
 
 	// Check if source file exists
 	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
 		// Try parent directory (in case tests run from subdirectory)
-		srcPath = filepath.Join("..", "data", "data.bin")
+		srcPath = filepath.Join("..", "data", "data.v2.bin")
 		if _, err := os.Stat(srcPath); os.IsNotExist(err) {
 			return nil // No local data.bin, will download from GitHub
 		}
@@ -0,0 +1,180 @@
+package disposable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultSMTPTimeout is the default for Config.SMTPTimeout.
+const defaultSMTPTimeout = 10 * time.Second
+
+// MailboxStatus reports what a mail server said about a mailbox.
+type MailboxStatus int
+
+const (
+	// MailboxUnknown means the mailbox was not probed or the probe was
+	// inconclusive, e.g. because no server could be reached or the server
+	// answered with a temporary failure.
+	MailboxUnknown MailboxStatus = iota
+	// MailboxExists means the server accepted the recipient. Servers that
+	// accept every address report this for any mailbox.
+	MailboxExists
+	// MailboxNotFound means the server permanently rejected the recipient.
+	MailboxNotFound
+)
+
+// String returns the string representation of the MailboxStatus.
+func (s MailboxStatus) String() string {
+	switch s {
+	case MailboxExists:
+		return "exists"
+	case MailboxNotFound:
+		return "not-found"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON encodes the MailboxStatus as its string representation.
+func (s MailboxStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// VerifyMailbox asks the mail servers of email's domain whether they accept
+// mail for it: it connects to the MX hosts in order of preference, or to the
+// domain itself if it has none, and sends RCPT TO without any message. The
+// first server that answers decides the result.
+//
+// Each connection is limited by the timeout set with WithSMTPProbe and by
+// ctx. Inconclusive probes return MailboxUnknown with the error. The probe
+// works without WithSMTPProbe, using a null sender and the default timeout.
+//
+// Note: Many servers accept every recipient or block probes from unknown
+// hosts, so only MailboxNotFound is a strong signal. Probing makes outbound
+// connections to port 25, which many networks block; use it sparingly, e.g.
+// for high-value signups.
+func (c *Checker) VerifyMailbox(ctx context.Context, email string) (MailboxStatus, error) {
+	email = strings.TrimSpace(email)
+	local, domain, ok := splitAddress(email)
+	if !ok || local == "" {
+		return MailboxUnknown, fmt.Errorf("%w: %q", ErrNotAnAddress, email)
+	}
+	domain = NormalizeDomain(domain)
+	if !IsValidDomain(domain) {
+		return MailboxUnknown, fmt.Errorf("%w: %q", ErrInvalidDomain, email)
+	}
+
+	hosts, err := c.mailHosts(ctx, domain)
+	if err != nil {
+		return MailboxUnknown, err
+	}
+
+	var errs []error
+	for _, host := range hosts {
+		status, err := c.probeMailbox(ctx, host, local+"@"+domain)
+		if err == nil {
+			return status, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", host, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return MailboxUnknown, errors.Join(errs...)
+}
+
+// mailHosts returns the hosts accepting mail for domain, most preferred
+// first.
+func (c *Checker) mailHosts(ctx context.Context, domain string) ([]string, error) {
+	var resolver DNSResolver = net.DefaultResolver
+	if c.config.DNSResolver != nil {
+		resolver = c.config.DNSResolver
+	}
+
+	mx, err := resolver.LookupMX(ctx, domain)
+	if err != nil && !isDNSNotFound(err) {
+		return nil, err
+	}
+	if len(mx) == 0 {
+		// Without MX records, mail goes to the domain itself
+		return []string{domain}, nil
+	}
+
+	slices.SortStableFunc(mx, func(a, b *net.MX) int { return int(a.Pref) - int(b.Pref) })
+	hosts := make([]string, 0, len(mx))
+	for _, m := range mx {
+		// A null MX (".") means the domain accepts no mail
+		if host := strings.TrimSuffix(m.Host, "."); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("domain %s accepts no mail", domain)
+	}
+	return hosts, nil
+}
+
+// probeMailbox sends RCPT TO for email to the mail server on host.
+func (c *Checker) probeMailbox(ctx context.Context, host, email string) (MailboxStatus, error) {
+	timeout := c.config.SMTPTimeout
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := c.dialSMTP(ctx, net.JoinHostPort(host, "25"))
+	if err != nil {
+		return MailboxUnknown, err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return MailboxUnknown, err
+	}
+	defer client.Close()
+
+	from := c.config.SMTPFrom
+	helo := "localhost"
+	if _, domain, ok := splitAddress(from); ok {
+		helo = domain
+	}
+	if err := client.Hello(helo); err != nil {
+		return MailboxUnknown, err
+	}
+	if err := client.Mail(from); err != nil {
+		return MailboxUnknown, err
+	}
+
+	err = client.Rcpt(email)
+	client.Quit()
+
+	var protoErr *textproto.Error
+	switch {
+	case err == nil:
+		return MailboxExists, nil
+	case errors.As(err, &protoErr) && protoErr.Code >= 500:
+		return MailboxNotFound, nil
+	default:
+		return MailboxUnknown, err
+	}
+}
+
+// dialTCP connects to addr over TCP.
+func dialTCP(ctx context.Context, addr string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", addr)
+}
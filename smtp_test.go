@@ -0,0 +1,229 @@
+package disposable
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestSMTPServer starts a mail server on 127.0.0.1 that accepts RCPT TO
+// only for the given mailboxes and rejects the rest with 550. It returns the
+// server's address.
+func newTestSMTPServer(t *testing.T, mailboxes ...string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		ln.Close()
+		wg.Wait()
+	})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer conn.Close()
+				serveTestSMTP(conn, mailboxes)
+			}()
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+// serveTestSMTP answers just enough SMTP for a probe.
+func serveTestSMTP(conn net.Conn, mailboxes []string) {
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 mx.test ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 mx.test")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			rcpt := strings.Trim(strings.TrimPrefix(cmd, "RCPT TO:"), "<>")
+			if containsFold(mailboxes, rcpt) {
+				reply("250 OK")
+			} else {
+				reply("550 No such user")
+			}
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// newSMTPTestChecker returns a checker whose mail servers for example.org
+// and nomx.example are all the server at addr.
+func newSMTPTestChecker(t *testing.T, addr string, opts ...Option) *Checker {
+	t.Helper()
+
+	resolver := &fakeResolver{
+		mx: map[string][]*net.MX{
+			"example.org":  {{Host: "mx2.example.org.", Pref: 20}, {Host: "mx1.example.org.", Pref: 10}},
+			"null.example": {{Host: ".", Pref: 0}},
+		},
+	}
+	opts = append([]Option{WithCacheDir(newTestCacheDir(t)), WithDNSCheck(resolver)}, opts...)
+	checker, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { checker.Close() })
+
+	checker.dialSMTP = func(ctx context.Context, hostPort string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	return checker
+}
+
+func TestCheckerVerifyMailbox(t *testing.T) {
+	addr := newTestSMTPServer(t, "alice@example.org", "bob@nomx.example")
+	checker := newSMTPTestChecker(t, addr)
+
+	tests := []struct {
+		email    string
+		expected MailboxStatus
+		err      error
+	}{
+		{"alice@example.org", MailboxExists, nil},
+		{"Alice@Example.ORG", MailboxExists, nil},
+		{"carol@example.org", MailboxNotFound, nil},
+		{"bob@nomx.example", MailboxExists, nil},
+		{"example.org", MailboxUnknown, ErrNotAnAddress},
+		{"@example.org", MailboxUnknown, ErrNotAnAddress},
+		{"user@localhost", MailboxUnknown, ErrInvalidDomain},
+	}
+
+	for _, tt := range tests {
+		status, err := checker.VerifyMailbox(context.Background(), tt.email)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("VerifyMailbox(%q) error = %v, want %v", tt.email, err, tt.err)
+		}
+		if status != tt.expected {
+			t.Errorf("VerifyMailbox(%q) = %v, want %v", tt.email, status, tt.expected)
+		}
+	}
+
+	// A null MX means the domain accepts no mail
+	if status, err := checker.VerifyMailbox(context.Background(), "user@null.example"); err == nil || status != MailboxUnknown {
+		t.Errorf("VerifyMailbox() = (%v, %v) for a null MX, want MailboxUnknown and an error", status, err)
+	}
+}
+
+func TestCheckerVerifyMailboxUnreachable(t *testing.T) {
+	// A listener that never answers, so the probe must time out
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	checker := newSMTPTestChecker(t, ln.Addr().String(), WithSMTPProbe("", 100*time.Millisecond))
+
+	start := time.Now()
+	status, err := checker.VerifyMailbox(context.Background(), "alice@example.org")
+	if err == nil || status != MailboxUnknown {
+		t.Errorf("VerifyMailbox() = (%v, %v), want MailboxUnknown and an error", status, err)
+	}
+	// Both MX hosts are tried, each within the timeout
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("VerifyMailbox() took %v, want it limited by the SMTP timeout", elapsed)
+	}
+
+	// A canceled context stops the probe
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if status, err := checker.VerifyMailbox(ctx, "alice@example.org"); err == nil || status != MailboxUnknown {
+		t.Errorf("VerifyMailbox() = (%v, %v) with a canceled context, want MailboxUnknown and an error", status, err)
+	}
+}
+
+func TestCheckerCheckMailbox(t *testing.T) {
+	addr := newTestSMTPServer(t, "alice@example.org")
+
+	plain := newSMTPTestChecker(t, addr)
+	if result, _ := plain.Check("carol@example.org"); result.Mailbox != MailboxUnknown {
+		t.Errorf("Check().Mailbox = %v without WithSMTPProbe, want %v", result.Mailbox, MailboxUnknown)
+	}
+
+	checker := newSMTPTestChecker(t, addr, WithSMTPProbe("verify@sender.example", time.Second))
+
+	tests := []struct {
+		input    string
+		expected MailboxStatus
+	}{
+		{"alice@example.org", MailboxExists},
+		{"carol@example.org", MailboxNotFound},
+		{"example.org", MailboxUnknown}, // No mailbox to probe
+	}
+
+	for _, tt := range tests {
+		result, err := checker.Check(tt.input)
+		if err != nil {
+			t.Fatalf("Check(%q) error = %v", tt.input, err)
+		}
+		if result.Disposable {
+			t.Errorf("Check(%q).Disposable = true, want false", tt.input)
+		}
+		if result.Mailbox != tt.expected {
+			t.Errorf("Check(%q).Mailbox = %v, want %v", tt.input, result.Mailbox, tt.expected)
+		}
+	}
+}
+
+func TestMailboxStatusString(t *testing.T) {
+	tests := []struct {
+		status   MailboxStatus
+		expected string
+	}{
+		{MailboxUnknown, "unknown"},
+		{MailboxExists, "exists"},
+		{MailboxNotFound, "not-found"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.String(); got != tt.expected {
+			t.Errorf("MailboxStatus(%d).String() = %q, want %q", tt.status, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package disposable
+
+import (
+	"strings"
+)
+
+// defaultProviders are suggested by Suggest when the data has no provider
+// list of its own. They mirror data/providers.txt.
+var defaultProviders = []string{
+	"gmail.com", "yahoo.com", "hotmail.com", "outlook.com", "icloud.com",
+	"aol.com", "live.com", "msn.com", "googlemail.com", "me.com", "mac.com",
+	"ymail.com", "rocketmail.com", "protonmail.com", "proton.me", "mail.com",
+	"gmx.com", "gmx.de", "gmx.net", "web.de", "yandex.ru", "yandex.com",
+	"mail.ru", "qq.com", "163.com", "126.com", "naver.com", "zoho.com",
+	"fastmail.com", "tutanota.com", "hotmail.co.uk", "yahoo.co.uk",
+	"yahoo.fr", "hotmail.fr", "orange.fr", "free.fr", "libero.it",
+	"comcast.net", "verizon.net", "att.net", "sbcglobal.net",
+	"btinternet.com",
+}
+
+// longProviderLength is the length from which a provider domain is close
+// enough to suggest at an edit distance of 2 rather than only 1.
+const longProviderLength = 9
+
+// Suggest reports whether the domain of emailOrDomain looks like a typo of a
+// well-known mail provider, such as "gmial.com" or "hotmail.con", and
+// returns the input with the domain corrected, e.g. "user@gmail.com" for
+// "user@gmial.com". The providers come from the data file, or a built-in
+// list if it has none.
+//
+// A domain is corrected if it is within one edit of a provider, counting
+// insertions, deletions, substitutions and swaps of adjacent characters, or
+// two edits for providers of 9 characters or more. Of equally close
+// providers, the most popular one is suggested. Provider domains themselves
+// and allowlisted domains are never corrected.
+func (c *Checker) Suggest(emailOrDomain string) (string, bool) {
+	c.ensureShard(NormalizeDomain(ExtractDomain(emailOrDomain)))
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	providers := c.providers
+	if len(providers) == 0 {
+		providers = defaultProviders
+	}
+	return suggest(emailOrDomain, providers, func(domain string) bool {
+		return c.allowlist != nil && c.allowlist.Contains(domain)
+	})
+}
+
+// suggest implements Suggest for the given providers. allowed reports
+// domains that must not be corrected.
+func suggest(emailOrDomain string, providers []string, allowed func(string) bool) (string, bool) {
+	input := strings.TrimSpace(emailOrDomain)
+	domain := NormalizeDomain(ExtractDomain(input))
+	if !IsValidDomain(domain) || IsIPLiteral(domain) || allowed(domain) {
+		return "", false
+	}
+
+	best, bestDist := "", 3
+	for _, provider := range providers {
+		if provider == domain {
+			return "", false
+		}
+		maxDist := 1
+		if len(provider) >= longProviderLength {
+			maxDist = 2
+		}
+		if d := editDistance(domain, provider); d <= maxDist && d < bestDist {
+			best, bestDist = provider, d
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+
+	if at := strings.LastIndexByte(input, '@'); at != -1 {
+		return input[:at+1] + best, true
+	}
+	return best, true
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b: the number of single-byte insertions, deletions, substitutions and
+// adjacent transpositions needed to turn a into b.
+func editDistance(a, b string) int {
+	// Three rows of the dynamic programming table are enough
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
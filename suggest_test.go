@@ -0,0 +1,116 @@
+package disposable
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rezmoss/go-is-disposable-email/internal/trie"
+)
+
+func TestCheckerSuggest(t *testing.T) {
+	checker, err := New(WithCacheDir(newTestCacheDir(t)), WithCustomAllowlist("gmaill.com"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"user@gmial.com", "user@gmail.com", true},     // transposition
+		{"user@gmail.con", "user@gmail.com", true},     // substitution
+		{"user@gmai.com", "user@gmail.com", true},      // deletion
+		{"User@Hotnail.com", "User@hotmail.com", true}, // local part kept as given
+		{"user@hotmial.con", "user@hotmail.com", true}, // two edits on a long provider
+		{"user@yaho.com", "user@yahoo.com", true},
+		{"user@outlok.com", "user@outlook.com", true},
+		{"gmial.com", "gmail.com", true},
+		{"user@gmail.com", "", false},   // a provider itself
+		{"user@ymail.com", "", false},   // a provider one edit from gmail.com
+		{"user@gmaill.com", "", false},  // allowlisted
+		{"user@aul.con", "", false},     // two edits on a short provider
+		{"user@example.com", "", false}, // not close to any provider
+		{"user@", "", false},
+		{"", "", false},
+		{"user@[192.168.0.1]", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := checker.Suggest(tt.input)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("Suggest(%q) = (%q, %v), want (%q, %v)", tt.input, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestCheckerSuggestDataProviders(t *testing.T) {
+	// Data with its own provider list replaces the built-in one
+	blocklist := trie.New()
+	blocklist.Insert("tempmail.com")
+	df := trie.NewDataFile(blocklist, trie.New(), time.Now())
+	df.Providers = []string{"corpmail.example", "gmail.com"}
+	data, err := trie.SerializeDataFile(df, trie.DefaultCompressionLevel)
+	if err != nil {
+		t.Fatalf("SerializeDataFile() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), data, 0644); err != nil {
+		t.Fatalf("Failed to write data.bin: %v", err)
+	}
+
+	for _, lazy := range []bool{false, true} {
+		opts := []Option{WithCacheDir(dir)}
+		if lazy {
+			opts = append(opts, WithLazyTLDLoading())
+		}
+		checker, err := New(opts...)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		if got, ok := checker.Suggest("user@copmail.example"); !ok || got != "user@corpmail.example" {
+			t.Errorf("Suggest() with lazy=%v = (%q, %v), want the data's provider", lazy, got, ok)
+		}
+		if got, ok := checker.Suggest("user@hotnail.com"); ok {
+			t.Errorf("Suggest() with lazy=%v = %q, want no built-in providers", lazy, got)
+		}
+		checker.Close()
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	if got, ok := Suggest("user@gmial.com"); !ok || got != "user@gmail.com" {
+		t.Errorf("Suggest(%q) = (%q, %v), want (%q, true)", "user@gmial.com", got, ok, "user@gmail.com")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"gmail.com", "gmail.com", 0},
+		{"gmial.com", "gmail.com", 1},
+		{"gmai.com", "gmail.com", 1},
+		{"gmaail.com", "gmail.com", 1},
+		{"gnail.com", "gmail.com", 1},
+		{"hotmial.con", "hotmail.com", 2},
+		{"", "abc", 3},
+		{"ca", "abc", 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+		if got := editDistance(tt.b, tt.a); got != tt.expected {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package disposable
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Length limits from RFC 5321, in octets.
+const (
+	maxAddressLength   = 254
+	maxLocalPartLength = 64
+	maxDomainLength    = 253
+	maxLabelLength     = 63
+)
+
+// ValidateSyntax reports whether email is a syntactically valid address as
+// defined by RFC 5322 and RFC 5321, with the UTF-8 extensions of RFC 6531.
+// It returns nil for a valid address and an error wrapping ErrInvalidSyntax
+// that describes the first problem found otherwise.
+//
+// The local part must be a dot-atom, like "first.last", or a quoted string,
+// like "\"john doe\"". The domain must be a host name whose labels contain
+// only letters, digits, hyphens and non-ASCII characters, or an address
+// literal like "[192.168.0.1]". Single-label domains such as "localhost" are
+// syntactically valid. Obsolete syntax, comments and surrounding whitespace
+// are rejected. The domain isn't checked against the data or DNS.
+func ValidateSyntax(email string) error {
+	if email == "" {
+		return syntaxError("empty address")
+	}
+	if !utf8.ValidString(email) {
+		return syntaxError("invalid UTF-8")
+	}
+	if len(email) > maxAddressLength {
+		return syntaxError("address is longer than %d octets", maxAddressLength)
+	}
+
+	local, domain, ok := splitAddress(email)
+	if !ok {
+		return syntaxError("address must have one @ outside quotes followed by a domain")
+	}
+	if err := validateLocalPart(local); err != nil {
+		return err
+	}
+	return validateDomainPart(domain)
+}
+
+// syntaxError returns an error wrapping ErrInvalidSyntax.
+func syntaxError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSyntax, fmt.Sprintf(format, args...))
+}
+
+// validateLocalPart checks the part of an address before the "@".
+func validateLocalPart(local string) error {
+	switch {
+	case local == "":
+		return syntaxError("empty local part")
+	case len(local) > maxLocalPartLength:
+		return syntaxError("local part is longer than %d octets", maxLocalPartLength)
+	case strings.HasPrefix(local, `"`):
+		return validateQuotedString(local)
+	}
+
+	for atom := range strings.SplitSeq(local, ".") {
+		if atom == "" {
+			return syntaxError("local part has a leading, trailing or repeated dot")
+		}
+		for _, r := range atom {
+			if !isAtext(r) {
+				return syntaxError("local part contains %q outside quotes", r)
+			}
+		}
+	}
+	return nil
+}
+
+// validateQuotedString checks a quoted local part such as "\"john doe\"".
+func validateQuotedString(local string) error {
+	inner, ok := strings.CutSuffix(local[1:], `"`)
+	if !ok || len(local) < 2 {
+		return syntaxError("quoted local part must end with a quote")
+	}
+
+	escaped := false
+	for _, r := range inner {
+		switch {
+		case escaped:
+			if r < ' ' && r != '\t' || r == 0x7f {
+				return syntaxError("local part escapes control character %q", r)
+			}
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			return syntaxError("local part has text outside its quoted string")
+		case r < ' ' && r != '\t' || r == 0x7f:
+			return syntaxError("local part contains control character %q", r)
+		}
+	}
+	if escaped {
+		return syntaxError("quoted local part ends with a backslash")
+	}
+	return nil
+}
+
+// validateDomainPart checks the part of an address after the "@".
+func validateDomainPart(domain string) error {
+	if strings.HasPrefix(domain, "[") {
+		if parseAddressLiteral(strings.ToLower(domain)) == "" {
+			return syntaxError("malformed address literal %q", domain)
+		}
+		return nil
+	}
+
+	if len(domain) > maxDomainLength {
+		return syntaxError("domain is longer than %d octets", maxDomainLength)
+	}
+	for label := range strings.SplitSeq(domain, ".") {
+		switch {
+		case label == "":
+			return syntaxError("domain has a leading, trailing or repeated dot")
+		case len(label) > maxLabelLength:
+			return syntaxError("domain label %q is longer than %d octets", label, maxLabelLength)
+		case strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-"):
+			return syntaxError("domain label %q starts or ends with a hyphen", label)
+		}
+		for _, r := range label {
+			if !isLetterDigit(r) && r != '-' && r < utf8.RuneSelf {
+				return syntaxError("domain contains %q", r)
+			}
+		}
+	}
+	return nil
+}
+
+// isAtext reports whether r may appear in an unquoted local part: the atext
+// characters of RFC 5322 plus non-ASCII characters as allowed by RFC 6531.
+func isAtext(r rune) bool {
+	return isLetterDigit(r) || strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r) || r >= utf8.RuneSelf
+}
+
+// isLetterDigit reports whether r is an ASCII letter or digit.
+func isLetterDigit(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
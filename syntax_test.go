@@ -0,0 +1,97 @@
+package disposable
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateSyntax(t *testing.T) {
+	tests := []struct {
+		email string
+		valid bool
+	}{
+		// Valid
+		{"user@example.com", true},
+		{"First.Last@Example.COM", true},
+		{"user+tag@sub.example.co.uk", true},
+		{"!#$%&'*+-/=?^_`{|}~@example.com", true},
+		{`"john doe"@example.com`, true},
+		{`"weird@name"@example.com`, true},
+		{`"escaped \" quote"@example.com`, true},
+		{`""@example.com`, true},
+		{"user@localhost", true},
+		{"user@[192.168.0.1]", true},
+		{"user@[IPv6:2001:db8::1]", true},
+		{"user@xn--mnchen-3ya.de", true},
+		{"用户@例子.广告", true},
+		{"josé@münchen.de", true},
+		{strings.Repeat("a", 64) + "@example.com", true},
+
+		// Invalid
+		{"", false},
+		{"user@@foo..com", false},
+		{"a@b@example.com", false},
+		{"user", false},
+		{"user@", false},
+		{"@example.com", false},
+		{".user@example.com", false},
+		{"user.@example.com", false},
+		{"us..er@example.com", false},
+		{"user name@example.com", false},
+		{"user(comment)@example.com", false},
+		{`"unterminated@example.com`, false},
+		{`"quoted"text@example.com`, false},
+		{`"ends with backslash\"@example.com`, false},
+		{"\"control\x01\"@example.com", false},
+		{" user@example.com", false},
+		{"user@example..com", false},
+		{"user@.example.com", false},
+		{"user@example.com.", false},
+		{"user@-example.com", false},
+		{"user@example-.com", false},
+		{"user@exa_mple.com", false},
+		{"user@exa mple.com", false},
+		{"user@[300.1.1.1]", false},
+		{"user@[192.168.0.1", false},
+		{"user@\xff.com", false},
+		{strings.Repeat("a", 65) + "@example.com", false},
+		{"user@" + strings.Repeat("a", 64) + ".com", false},
+		{"user@" + strings.Repeat("a.", 125) + "com", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateSyntax(tt.email)
+		if tt.valid && err != nil {
+			t.Errorf("ValidateSyntax(%q) = %v, want nil", tt.email, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidSyntax) {
+			t.Errorf("ValidateSyntax(%q) = %v, want %v", tt.email, err, ErrInvalidSyntax)
+		}
+	}
+}
+
+func TestCheckerCheckSyntax(t *testing.T) {
+	checker, err := New(WithCacheDir(newTestCacheDir(t)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer checker.Close()
+
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{"user name@mailinator.com", ErrInvalidSyntax},
+		{".user@gmail.com", ErrInvalidSyntax},
+		{"user@@foo..com", ErrInvalidDomain},
+		{`"john doe"@mailinator.com`, nil},
+		{"  user@gmail.com  ", nil},
+	}
+
+	for _, tt := range tests {
+		if _, err := checker.Check(tt.input); !errors.Is(err, tt.err) {
+			t.Errorf("Check(%q) error = %v, want %v", tt.input, err, tt.err)
+		}
+	}
+}